@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// accessLogSize is how many accesses are kept per item; older ones are overwritten.
+	accessLogSize = 100
+	// accessLogPage is how many accesses GET /items/{id}/access_log returns.
+	accessLogPage = 50
+)
+
+// AccessLogEntry records one request that read or changed an item.
+// UserID is "admin" for the admin token, "key:" and a fingerprint of the
+// API key for API clients, and empty for anonymous requests.
+type AccessLogEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	UserID    string    `json:"user_id,omitempty"`
+	Action    string    `json:"action"`
+	IP        string    `json:"ip"`
+}
+
+// accessRing is a fixed-size ring buffer of one item's accesses.
+type accessRing struct {
+	entries [accessLogSize]AccessLogEntry
+	next    int // where the next entry goes
+	count   int
+}
+
+// AccessLog keeps the recent accesses of every item in memory. An item's
+// entries outlive the item, so deletions can be traced too.
+type AccessLog struct {
+	mu    sync.Mutex
+	rings map[string]*accessRing
+}
+
+// NewAccessLog returns an empty AccessLog.
+func NewAccessLog() *AccessLog {
+	return &AccessLog{rings: make(map[string]*accessRing)}
+}
+
+// accessLog holds the item accesses recorded by the handlers.
+var accessLog = NewAccessLog()
+
+// Add appends an entry to an item's log, overwriting the oldest when full.
+func (l *AccessLog) Add(itemID string, e AccessLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ring, ok := l.rings[itemID]
+	if !ok {
+		ring = &accessRing{}
+		l.rings[itemID] = ring
+	}
+	ring.entries[ring.next] = e
+	ring.next = (ring.next + 1) % accessLogSize
+	ring.count = min(ring.count+1, accessLogSize)
+}
+
+// Recent returns up to limit of an item's entries, newest first.
+func (l *AccessLog) Recent(itemID string, limit int) []AccessLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := []AccessLogEntry{}
+	ring, ok := l.rings[itemID]
+	if !ok {
+		return entries
+	}
+	for i := 1; i <= min(limit, ring.count); i++ {
+		entries = append(entries, ring.entries[(ring.next-i+accessLogSize)%accessLogSize])
+	}
+	return entries
+}
+
+// requestUserID identifies who made r for the access log, without storing
+// API keys themselves.
+func requestUserID(r *http.Request) string {
+	if hasAdminToken(r) {
+		return "admin"
+	}
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:4])
+	}
+	return ""
+}
+
+// recordAccess adds r to the access log of the item it touched.
+// Dry runs touch nothing, so they are not recorded.
+func recordAccess(r *http.Request, itemID, action string) {
+	if isDryRun(r.Context()) {
+		return
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	accessLog.Add(itemID, AccessLogEntry{
+		Timestamp: time.Now().UTC(),
+		UserID:    requestUserID(r),
+		Action:    action,
+		IP:        ip,
+	})
+}
+
+// getAccessLog (GET /items/{id}/access_log)
+// This returns the 50 most recent reads, updates and deletes of an item,
+// newest first. It needs the admin token, and works for deleted items too.
+func getAccessLog(w http.ResponseWriter, r *http.Request) {
+	if !hasAdminToken(r) {
+		respondWithError(w, http.StatusForbidden, "the access log is only available to admins")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, accessLog.Recent(mux.Vars(r)["id"], accessLogPage))
+}
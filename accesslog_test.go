@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestAccessLog (GET /items/{id}/access_log)
+func TestAccessLog(t *testing.T) {
+	resetGlobalItems()
+	accessLog = NewAccessLog()
+	config.AdminToken = "admin-secret"
+	defer func() { config.AdminToken = "" }()
+	router := newRouter()
+
+	getLog := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/items/1/access_log", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 1. Read the item three times, then update it twice
+	for range 3 {
+		req := httptest.NewRequest("GET", "/items/1", nil)
+		req.RemoteAddr = "192.0.2.7:4321"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	for _, name := range []string{"First", "Second"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("PUT", "/items/1", strings.NewReader(`{"name":"`+name+`"}`)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	}
+
+	// 2. Admins see the five accesses, newest first
+	rr := getLog("admin-secret")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var entries []AccessLogEntry
+	json.NewDecoder(rr.Body).Decode(&entries)
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	if got := strings.Join(actions, ","); got != "update,update,read,read,read" {
+		t.Errorf("wrong actions: got %s want update,update,read,read,read", got)
+	}
+	if len(entries) == 5 && (entries[4].IP != "192.0.2.7" || entries[4].Timestamp.IsZero()) {
+		t.Errorf("wrong read entry: %+v", entries[4])
+	}
+
+	// 3. Everyone else is turned away
+	if rr := getLog(""); rr.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+
+	// Sub-test for "Ring Buffer"
+	t.Run("Ring Buffer", func(t *testing.T) {
+		l := NewAccessLog()
+		for i := range accessLogSize + 20 {
+			l.Add("x", AccessLogEntry{Action: strconv.Itoa(i)})
+		}
+		recent := l.Recent("x", accessLogSize+50)
+		if len(recent) != accessLogSize {
+			t.Fatalf("wrong number of entries kept: got %d want %d", len(recent), accessLogSize)
+		}
+		if newest, oldest := recent[0].Action, recent[accessLogSize-1].Action; newest != "119" || oldest != "20" {
+			t.Errorf("ring kept the wrong entries: newest %s oldest %s want 119 and 20", newest, oldest)
+		}
+		if got := l.Recent("x", accessLogPage); len(got) != accessLogPage {
+			t.Errorf("wrong page size: got %d want %d", len(got), accessLogPage)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// PublicItem is the view of an Item sent to regular API consumers when
+// HIDE_INTERNAL_FIELDS=true. It leaves out the bookkeeping fields only
+// operations staff need: Version and LastRequestID.
+type PublicItem struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Likes       int               `json:"likes"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Pinned      bool              `json:"pinned"`
+	ViewCount   int64             `json:"view_count"`
+	Score       float64           `json:"score,omitempty"`
+	CloneCount  int               `json:"clone_count"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// publicItem returns the public view of item.
+func publicItem(item Item) PublicItem {
+	return PublicItem{
+		ID:          item.ID,
+		Name:        item.Name,
+		Description: item.Description,
+		Tags:        item.Tags,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		Likes:       item.Likes,
+		Namespace:   item.Namespace,
+		Pinned:      item.Pinned,
+		ViewCount:   item.ViewCount,
+		Score:       item.Score,
+		CloneCount:  item.CloneCount,
+		Metadata:    item.Metadata,
+	}
+}
+
+// adminViewWriter marks a response as going to an admin, who sees full items.
+type adminViewWriter struct {
+	http.ResponseWriter
+}
+
+// Unwrap returns the wrapped ResponseWriter.
+func (a adminViewWriter) Unwrap() http.ResponseWriter {
+	return a.ResponseWriter
+}
+
+// adminViewMiddleware marks the responses to requests carrying the admin
+// token, so respondWithJSON keeps their internal item fields.
+func adminViewMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if config.HideInternalFields && hasAdminToken(r) {
+			w = adminViewWriter{w}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAdminView reports whether items sent through w may include internal
+// fields: always, unless HIDE_INTERNAL_FIELDS=true and the caller is no admin.
+func isAdminView(w http.ResponseWriter) bool {
+	if !config.HideInternalFields {
+		return true
+	}
+	for {
+		if _, ok := w.(adminViewWriter); ok {
+			return true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// filterAdminFields returns payload with every Item in it replaced by its
+// PublicItem, unless isAdmin. Items are found wherever they are nested:
+// in slices, maps, pointers and struct fields, embedded ones included, so
+// a new response type cannot leak internal fields by accident. Structs that
+// hold Items are turned into maps with the keys encoding/json would give them.
+// Payloads without Items are returned as they are.
+func filterAdminFields(payload interface{}, isAdmin bool) interface{} {
+	if isAdmin || payload == nil {
+		return payload
+	}
+	// The common shapes skip reflection and keep their PublicItem types
+	switch p := payload.(type) {
+	case Item:
+		return publicItem(p)
+	case *Item:
+		if p != nil {
+			return publicItem(*p)
+		}
+		return payload
+	case []Item:
+		public := make([]PublicItem, len(p))
+		for i, item := range p {
+			public[i] = publicItem(item)
+		}
+		return public
+	case map[string]Item:
+		public := make(map[string]PublicItem, len(p))
+		for id, item := range p {
+			public[id] = publicItem(item)
+		}
+		return public
+	}
+	if public, changed := publicValue(reflect.ValueOf(payload)); changed {
+		return public
+	}
+	return payload
+}
+
+var (
+	itemType      = reflect.TypeFor[Item]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// publicValue is filterAdminFields for any value. It reports whether v held
+// an Item; if not, the returned value must not be used.
+func publicValue(v reflect.Value) (interface{}, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if v.Type() == itemType {
+		return publicItem(v.Interface().(Item)), true
+	}
+	if v.Type().Implements(marshalerType) {
+		return nil, false // it decides its own JSON, e.g. time.Time
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return publicValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, false
+		}
+		if v.Type().Elem() == itemType {
+			return filterAdminFields(v.Convert(reflect.TypeFor[[]Item]()).Interface(), false), true
+		}
+		public := make([]interface{}, v.Len())
+		changed := false
+		for i := range public {
+			elem, elemChanged := publicValue(v.Index(i))
+			if !elemChanged {
+				elem = v.Index(i).Interface()
+			}
+			public[i], changed = elem, changed || elemChanged
+		}
+		return public, changed
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		public := make(map[string]interface{}, v.Len())
+		changed := false
+		for iter := v.MapRange(); iter.Next(); {
+			value, valueChanged := publicValue(iter.Value())
+			if !valueChanged {
+				value = iter.Value().Interface()
+			}
+			public[iter.Key().String()], changed = value, changed || valueChanged
+		}
+		return public, changed
+	case reflect.Struct:
+		public := map[string]interface{}{}
+		return public, publicFields(v, public)
+	}
+	return nil, false
+}
+
+// publicFields adds the JSON fields of struct v to out, with their Items
+// made public, and reports whether any were. Fields already in out win, as
+// the shallower field does in encoding/json.
+func publicFields(v reflect.Value, out map[string]interface{}) bool {
+	changed := false
+	var embedded []reflect.Value
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded = append(embedded, v.Field(i))
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		value := v.Field(i)
+		if strings.Contains(options, "omitempty") && isEmptyJSONValue(value) {
+			continue
+		}
+		public, fieldChanged := publicValue(value)
+		if !fieldChanged {
+			public = value.Interface()
+		}
+		out[name], changed = public, changed || fieldChanged
+	}
+	for _, value := range embedded {
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			continue
+		}
+		if value.Type() == itemType {
+			value, changed = reflect.ValueOf(publicItem(value.Interface().(Item))), true
+		}
+		fields := map[string]interface{}{}
+		changed = publicFields(value, fields) || changed
+		for name, public := range fields {
+			if _, ok := out[name]; !ok {
+				out[name] = public
+			}
+		}
+	}
+	return changed
+}
+
+// isEmptyJSONValue reports whether omitempty leaves v out of the JSON.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestAdminView (GET /items with HIDE_INTERNAL_FIELDS=true)
+func TestAdminView(t *testing.T) {
+	resetGlobalItems()
+	oldConfig := config
+	defer func() { config = oldConfig }()
+	config.HideInternalFields = true
+	config.AdminToken = "secret"
+	router := newRouter()
+
+	getItems := func(t *testing.T, authorization string) []map[string]interface{} {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set(requestIDHeader, "req-1")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var items []map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("got %d items want 2", len(items))
+		}
+		return items
+	}
+	store.Update(t.Context(), "1", func(item *Item) error {
+		item.LastRequestID = "req-0"
+		return nil
+	})
+
+	// Sub-test for "Non-Admin"
+	t.Run("Non-Admin", func(t *testing.T) {
+		for _, auth := range []string{"", "Bearer wrong"} {
+			for _, item := range getItems(t, auth) {
+				for _, field := range []string{"version", "last_request_id"} {
+					if _, ok := item[field]; ok {
+						t.Errorf("non-admin got %s: %v", field, item)
+					}
+				}
+				if item["name"] == nil {
+					t.Errorf("public fields missing: %v", item)
+				}
+			}
+		}
+	})
+
+	// Sub-test for "Admin"
+	t.Run("Admin", func(t *testing.T) {
+		item := getItems(t, "Bearer secret")[0]
+		if item["version"] == nil || item["last_request_id"] != "req-0" {
+			t.Errorf("admin is missing internal fields: %v", item)
+		}
+	})
+
+	// Sub-test for "Disabled"
+	t.Run("Disabled", func(t *testing.T) {
+		config.HideInternalFields = false
+		if item := getItems(t, "")[0]; item["version"] == nil {
+			t.Errorf("internal fields hidden without HIDE_INTERNAL_FIELDS: %v", item)
+		}
+	})
+}
+
+// TestFilterAdminFields
+func TestFilterAdminFields(t *testing.T) {
+	item := Item{ID: "1", Name: "One", Version: 3}
+	if _, ok := filterAdminFields(item, false).(PublicItem); !ok {
+		t.Error("Item was not filtered")
+	}
+	if _, ok := filterAdminFields(item, true).(Item); !ok {
+		t.Error("admin Item was filtered")
+	}
+	if got := filterAdminFields([]Item{item}, false).([]PublicItem); len(got) != 1 || got[0].Name != "One" {
+		t.Errorf("wrong filtered slice: %v", got)
+	}
+	wrapped := filterAdminFields(map[string]interface{}{"updated": []Item{item}, "count": 1}, false).(map[string]interface{})
+	if _, ok := wrapped["updated"].([]PublicItem); !ok || wrapped["count"] != 1 {
+		t.Errorf("wrong filtered map: %v", wrapped)
+	}
+
+	// Structs holding items become maps shaped like their JSON
+	snapshot := filterAdminFields(Snapshot{Name: "s", Items: []Item{item}}, false).(map[string]interface{})
+	if _, ok := snapshot["items"].([]PublicItem); !ok || snapshot["snapshot"] != "s" {
+		t.Errorf("wrong filtered snapshot: %v", snapshot)
+	}
+	groups := filterAdminFields([]DuplicateGroup{{Name: "One", Items: []Item{item, item}}}, false).([]interface{})
+	if group := groups[0].(map[string]interface{}); len(group["items"].([]PublicItem)) != 2 {
+		t.Errorf("wrong filtered duplicate groups: %v", groups)
+	}
+	if _, ok := groups[0].(map[string]interface{})["namespace"]; ok {
+		t.Errorf("omitempty field was kept: %v", groups)
+	}
+	historical := filterAdminFields(HistoricalItem{Item: item}, false).(map[string]interface{})
+	if _, ok := historical["version"]; ok || historical["name"] != "One" || historical["replaced_at"] == nil {
+		t.Errorf("wrong filtered embedded item: %v", historical)
+	}
+	if got := filterAdminFields(map[string]int{"count": 1}, false); got.(map[string]int)["count"] != 1 {
+		t.Errorf("payload without items was changed: %v", got)
+	}
+}
+
+// TestAdminViewNestedItems checks that items inside other response types
+// lose their internal fields too.
+func TestAdminViewNestedItems(t *testing.T) {
+	resetGlobalItems()
+	store = NewHistoryStore(store)
+	defer resetGlobalItems()
+	feed = NewChangeFeed()
+	oldConfig := config
+	defer func() { config = oldConfig }()
+	config.HideInternalFields = true
+	config.AdminToken = "secret"
+	router := newRouter()
+
+	get := func(t *testing.T, target string) []byte {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		return rr.Body.Bytes()
+	}
+	checkPublic := func(t *testing.T, item map[string]interface{}) {
+		for _, field := range []string{"version", "last_request_id"} {
+			if _, ok := item[field]; ok {
+				t.Errorf("non-admin got %s: %v", field, item)
+			}
+		}
+		if item["name"] == nil {
+			t.Errorf("public fields missing: %v", item)
+		}
+	}
+
+	// 1. Update an item, so it has history and a feed event
+	req := httptest.NewRequest("PUT", "/items/1", strings.NewReader(`{"name":"Changed"}`))
+	req.Header.Set(requestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Sub-test for "History"
+	t.Run("History", func(t *testing.T) {
+		var entries []map[string]interface{}
+		json.Unmarshal(get(t, "/items/1/history"), &entries)
+		if len(entries) != 1 || entries[0]["replaced_at"] == nil {
+			t.Fatalf("wrong history: %v", entries)
+		}
+		checkPublic(t, entries[0])
+	})
+
+	// Sub-test for "Feed"
+	t.Run("Feed", func(t *testing.T) {
+		var body struct {
+			Events []map[string]interface{} `json:"events"`
+		}
+		json.Unmarshal(get(t, "/feed?since_cursor=0&timeout=0"), &body)
+		if len(body.Events) != 1 || body.Events[0]["cursor"] == nil || body.Events[0]["type"] != eventItemUpdated {
+			t.Fatalf("wrong events: %v", body.Events)
+		}
+		item, _ := body.Events[0]["item"].(map[string]interface{})
+		checkPublic(t, item)
+	})
+}
+
+// TestAdminViewEncodings checks that the CSV and SCIM renderings of
+// GET /items leave out the version for non-admins too.
+func TestAdminViewEncodings(t *testing.T) {
+	resetGlobalItems()
+	oldConfig := config
+	defer func() { config = oldConfig }()
+	config.HideInternalFields = true
+	config.AdminToken = "secret"
+	router := newRouter()
+
+	get := func(t *testing.T, accept, authorization string) []byte {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set("Accept", accept)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		return rr.Body.Bytes()
+	}
+
+	// Sub-test for "CSV"
+	t.Run("CSV", func(t *testing.T) {
+		for _, tt := range []struct {
+			authorization string
+			want          bool
+		}{{"", false}, {"Bearer secret", true}} {
+			records, err := csv.NewReader(bytes.NewReader(get(t, "text/csv", tt.authorization))).ReadAll()
+			if err != nil {
+				t.Fatalf("Failed to parse CSV body: %v", err)
+			}
+			if got := slices.Contains(records[0], "version"); got != tt.want {
+				t.Errorf("authorization %q: version column present = %v want %v", tt.authorization, got, tt.want)
+			}
+			if len(records[1]) != len(records[0]) {
+				t.Errorf("authorization %q: row has %d cells for %d columns", tt.authorization, len(records[1]), len(records[0]))
+			}
+		}
+	})
+
+	// Sub-test for "SCIM"
+	t.Run("SCIM", func(t *testing.T) {
+		for _, tt := range []struct {
+			authorization string
+			want          bool
+		}{{"", false}, {"Bearer secret", true}} {
+			var list struct {
+				Resources []struct {
+					Meta map[string]interface{} `json:"meta"`
+				} `json:"Resources"`
+			}
+			if err := json.Unmarshal(get(t, scimMediaType, tt.authorization), &list); err != nil {
+				t.Fatal(err)
+			}
+			if _, got := list.Resources[0].Meta["version"]; got != tt.want {
+				t.Errorf("authorization %q: meta.version present = %v want %v", tt.authorization, got, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// apiKeyHeader carries the client's API key.
+	apiKeyHeader = "X-API-Key"
+	// redisAPIKeysHash is the Redis hash whose fields are the valid API keys.
+	redisAPIKeysHash = "demojam:apikeys"
+	// redisTimeout bounds a single Redis lookup so a slow Redis cannot stall requests.
+	redisTimeout = 200 * time.Millisecond
+	// redisCooldown is how long Redis is skipped after it fails.
+	redisCooldown = 30 * time.Second
+)
+
+// errRedisNotConfigured is returned when a key change is requested without Redis.
+var errRedisNotConfigured = errors.New("API key management requires Redis")
+
+// APIKeyStore decides which API keys are valid.
+// Keys from API_KEYS are always accepted. When Redis is configured, keys in
+// the demojam:apikeys hash are accepted as well, so they can be rotated
+// without a restart.
+type APIKeyStore struct {
+	static map[string]bool
+	redis  *redis.Client // nil when Redis is not configured
+
+	// Simple circuit breaker: after a Redis error we fall back to the
+	// static keys alone until skipRedisUntil has passed.
+	mu             sync.Mutex
+	skipRedisUntil time.Time
+}
+
+// NewAPIKeyStore returns a store accepting the given static keys and,
+// if rdb is not nil, the keys held in Redis.
+func NewAPIKeyStore(static []string, rdb *redis.Client) *APIKeyStore {
+	keys := make(map[string]bool, len(static))
+	for _, key := range static {
+		if key = strings.TrimSpace(key); key != "" {
+			keys[key] = true
+		}
+	}
+	return &APIKeyStore{static: keys, redis: rdb}
+}
+
+// apiKeys guards the API when set; nil means API key auth is disabled.
+var apiKeys *APIKeyStore
+
+// Valid reports whether key may use the API.
+func (s *APIKeyStore) Valid(ctx context.Context, key string) bool {
+	if s.static[key] {
+		return true
+	}
+	if s.redis == nil || !s.redisAvailable() {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	ok, err := s.redis.HExists(ctx, redisAPIKeysHash, key).Result()
+	if err != nil {
+		s.tripBreaker(err)
+		return false
+	}
+	return ok
+}
+
+// Add makes key valid until it is removed.
+func (s *APIKeyStore) Add(ctx context.Context, key string) error {
+	if s.redis == nil {
+		return errRedisNotConfigured
+	}
+	return s.redis.HSet(ctx, redisAPIKeysHash, key, time.Now().UTC().Format(time.RFC3339)).Err()
+}
+
+// Remove revokes a key held in Redis. Static keys cannot be removed.
+func (s *APIKeyStore) Remove(ctx context.Context, key string) error {
+	if s.redis == nil {
+		return errRedisNotConfigured
+	}
+	return s.redis.HDel(ctx, redisAPIKeysHash, key).Err()
+}
+
+// Check reports on the Redis connection for GET /health.
+// The static keys keep working without Redis, so a failure only degrades the API.
+func (s *APIKeyStore) Check(ctx context.Context) HealthResult {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := s.redis.Ping(ctx).Err(); err != nil {
+		return HealthResult{Status: healthDegraded, Error: err.Error()}
+	}
+	return HealthResult{Status: healthOK}
+}
+
+// redisAvailable reports whether the circuit breaker allows a Redis call.
+func (s *APIKeyStore) redisAvailable() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().After(s.skipRedisUntil)
+}
+
+// tripBreaker stops Redis lookups for redisCooldown.
+func (s *APIKeyStore) tripBreaker(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log.Printf("redis unavailable, using static API keys for %v: %v", redisCooldown, err)
+	s.skipRedisUntil = time.Now().Add(redisCooldown)
+}
+
+// apiKeyExempt reports whether a path is reachable without an API key.
+// Admin endpoints have their own token and operator endpoints must stay open.
+func apiKeyExempt(path string) bool {
+	return path == "/health" || path == "/metrics" || strings.HasPrefix(path, "/admin/")
+}
+
+// apiKeyMiddleware rejects requests without a valid X-API-Key header.
+// It does nothing while apiKeys is nil.
+func apiKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if apiKeys == nil || apiKeyExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := r.Header.Get(apiKeyHeader)
+		if key == "" || !apiKeys.Valid(r.Context(), key) {
+			respondWithError(w, http.StatusUnauthorized, "invalid or missing API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminAuthMiddleware only lets through requests carrying
+// "Authorization: Bearer <ADMIN_TOKEN>". Without ADMIN_TOKEN admin endpoints are disabled.
+func adminAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if config.AdminToken == "" {
+			respondWithError(w, http.StatusForbidden, "admin endpoints are disabled")
+			return
+		}
+		if !hasAdminToken(r) {
+			respondWithError(w, http.StatusUnauthorized, "invalid admin token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasAdminToken reports whether r carries "Authorization: Bearer <ADMIN_TOKEN>".
+func hasAdminToken(r *http.Request) bool {
+	if config.AdminToken == "" {
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(config.AdminToken)) == 1
+}
+
+// respondWithAPIKeyError maps an error from an API key change to a JSON error response
+func respondWithAPIKeyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errRedisNotConfigured) {
+		respondWithError(w, http.StatusNotImplemented, err.Error())
+		return
+	}
+	log.Printf("failed to change API key: %v", err)
+	respondWithError(w, http.StatusServiceUnavailable, "API key store unavailable")
+}
+
+// addAPIKey (POST /admin/apikeys/{key})
+// This makes a new API key valid immediately.
+func addAPIKey(w http.ResponseWriter, r *http.Request) {
+	if apiKeys == nil {
+		respondWithAPIKeyError(w, errRedisNotConfigured)
+		return
+	}
+	key := mux.Vars(r)["key"]
+	if err := apiKeys.Add(r.Context(), key); err != nil {
+		respondWithAPIKeyError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, map[string]string{"result": "success", "key_added": key})
+}
+
+// removeAPIKey (DELETE /admin/apikeys/{key})
+// This revokes an API key held in Redis.
+func removeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if apiKeys == nil {
+		respondWithAPIKeyError(w, errRedisNotConfigured)
+		return
+	}
+	key := mux.Vars(r)["key"]
+	if err := apiKeys.Remove(r.Context(), key); err != nil {
+		respondWithAPIKeyError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "key_removed": key})
+}
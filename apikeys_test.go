@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// withAPIKeys enables API key auth for the duration of a test.
+func withAPIKeys(t *testing.T, keys *APIKeyStore) {
+	t.Helper()
+	apiKeys = keys
+	config.AdminToken = "admin-secret"
+	t.Cleanup(func() {
+		apiKeys = nil
+		config.AdminToken = ""
+	})
+}
+
+// doWithKey sends a request through the router with an optional API key.
+func doWithKey(router http.Handler, method, path, key string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+// doAdmin sends a request to an admin endpoint with the given bearer token.
+func doAdmin(router http.Handler, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+// TestAPIKeyMiddleware checks API key auth against static keys and Redis.
+func TestAPIKeyMiddleware(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	// Sub-test for "Disabled By Default"
+	t.Run("Disabled By Default", func(t *testing.T) {
+		if status := doWithKey(router, "GET", "/items", ""); status != http.StatusOK {
+			t.Errorf("request without key was rejected while auth is off: got %v", status)
+		}
+	})
+
+	// Sub-test for "Static Keys"
+	t.Run("Static Keys", func(t *testing.T) {
+		withAPIKeys(t, NewAPIKeyStore([]string{"static-key"}, nil))
+
+		if status := doWithKey(router, "GET", "/items", ""); status != http.StatusUnauthorized {
+			t.Errorf("request without key: got %v want %v", status, http.StatusUnauthorized)
+		}
+		if status := doWithKey(router, "GET", "/items", "wrong-key"); status != http.StatusUnauthorized {
+			t.Errorf("request with wrong key: got %v want %v", status, http.StatusUnauthorized)
+		}
+		if status := doWithKey(router, "GET", "/items", "static-key"); status != http.StatusOK {
+			t.Errorf("request with static key: got %v want %v", status, http.StatusOK)
+		}
+		if status := doWithKey(router, "GET", "/health", ""); status != http.StatusOK {
+			t.Errorf("health check should not need a key: got %v want %v", status, http.StatusOK)
+		}
+	})
+
+	// Sub-test for "Redis Keys Via Admin Endpoint"
+	t.Run("Redis Keys Via Admin Endpoint", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		withAPIKeys(t, NewAPIKeyStore(nil, redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+		// 1. Unknown key is rejected
+		if status := doWithKey(router, "GET", "/items", "rotated-key"); status != http.StatusUnauthorized {
+			t.Fatalf("request with unknown key: got %v want %v", status, http.StatusUnauthorized)
+		}
+
+		// 2. Adding it needs the admin token
+		if status := doAdmin(router, "POST", "/admin/apikeys/rotated-key", "not-the-token"); status != http.StatusUnauthorized {
+			t.Errorf("admin call with wrong token: got %v want %v", status, http.StatusUnauthorized)
+		}
+		if status := doAdmin(router, "POST", "/admin/apikeys/rotated-key", "admin-secret"); status != http.StatusCreated {
+			t.Fatalf("admin add key: got %v want %v", status, http.StatusCreated)
+		}
+
+		// 3. The key works straight away
+		if status := doWithKey(router, "GET", "/items", "rotated-key"); status != http.StatusOK {
+			t.Errorf("request with added key: got %v want %v", status, http.StatusOK)
+		}
+
+		// 4. Removing it revokes access
+		if status := doAdmin(router, "DELETE", "/admin/apikeys/rotated-key", "admin-secret"); status != http.StatusOK {
+			t.Fatalf("admin remove key: got %v want %v", status, http.StatusOK)
+		}
+		if status := doWithKey(router, "GET", "/items", "rotated-key"); status != http.StatusUnauthorized {
+			t.Errorf("request with removed key: got %v want %v", status, http.StatusUnauthorized)
+		}
+	})
+
+	// Sub-test for "Redis Down Falls Back To Static Keys"
+	t.Run("Redis Down Falls Back To Static Keys", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		keys := NewAPIKeyStore([]string{"static-key"}, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+		withAPIKeys(t, keys)
+		keys.Add(context.Background(), "redis-key")
+		mr.Close()
+
+		if status := doWithKey(router, "GET", "/items", "redis-key"); status != http.StatusUnauthorized {
+			t.Errorf("redis key accepted while Redis is down: got %v want %v", status, http.StatusUnauthorized)
+		}
+		if keys.redisAvailable() {
+			t.Error("circuit breaker did not open after a Redis error")
+		}
+		if status := doWithKey(router, "GET", "/items", "static-key"); status != http.StatusOK {
+			t.Errorf("static key rejected while Redis is down: got %v want %v", status, http.StatusOK)
+		}
+		if result := keys.Check(context.Background()); result.Status != healthDegraded {
+			t.Errorf("health check while Redis is down: got %+v want status %q", result, healthDegraded)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxAttachmentSize is the largest file accepted by POST /items/{id}/attachments.
+const maxAttachmentSize = 10 << 20
+
+// Attachment describes a file uploaded for an item. The content itself is
+// kept by the AttachmentStore and only served by the download endpoint.
+type Attachment struct {
+	ID          string    `json:"id"`
+	ItemID      string    `json:"item_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StoredAt    time.Time `json:"stored_at"`
+}
+
+// AttachmentStore keeps attachments in memory, separately from the items.
+type AttachmentStore struct {
+	mu          sync.RWMutex
+	attachments map[string]Attachment
+	content     map[string][]byte
+}
+
+// NewAttachmentStore returns an empty AttachmentStore.
+func NewAttachmentStore() *AttachmentStore {
+	return &AttachmentStore{attachments: make(map[string]Attachment), content: make(map[string][]byte)}
+}
+
+// attachments holds the attachments used by the handlers.
+var attachments = NewAttachmentStore()
+
+// newRandomID returns a random 64-bit hex identifier for attachments and notes.
+func newRandomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Add stores data as a new attachment, filling in a's ID, size and StoredAt.
+func (s *AttachmentStore) Add(a Attachment, data []byte) Attachment {
+	a.ID = newRandomID()
+	a.Size = int64(len(data))
+	a.StoredAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attachments[a.ID] = a
+	s.content[a.ID] = data
+	return a
+}
+
+// List returns the attachments of an item, oldest first.
+func (s *AttachmentStore) List(itemID string) []Attachment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := []Attachment{}
+	for _, a := range s.attachments {
+		if a.ItemID == itemID {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StoredAt.Before(list[j].StoredAt) })
+	return list
+}
+
+// Get returns an attachment of an item together with its content.
+func (s *AttachmentStore) Get(itemID, id string) (Attachment, []byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.attachments[id]
+	if !ok || a.ItemID != itemID {
+		return Attachment{}, nil, false
+	}
+	return a, s.content[id], true
+}
+
+// Delete removes an attachment of an item and reports whether it existed.
+func (s *AttachmentStore) Delete(itemID, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.attachments[id]; !ok || a.ItemID != itemID {
+		return false
+	}
+	delete(s.attachments, id)
+	delete(s.content, id)
+	return true
+}
+
+// DeleteItem removes every attachment of an item.
+func (s *AttachmentStore) DeleteItem(itemID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, a := range s.attachments {
+		if a.ItemID == itemID {
+			delete(s.attachments, id)
+			delete(s.content, id)
+		}
+	}
+}
+
+// uploadAttachment (POST /items/{id}/attachments)
+// This stores the multipart form file "file" as a new attachment of the item.
+func uploadAttachment(w http.ResponseWriter, r *http.Request) {
+	itemID := mux.Vars(r)["id"]
+	if _, err := storeFromContext(r.Context()).GetByID(r.Context(), itemID); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+
+	// Leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "attachment is larger than 10 MB")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "expected a multipart form with a 'file' field")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "failed to read the uploaded file")
+		return
+	}
+	if len(data) > maxAttachmentSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "attachment is larger than 10 MB")
+		return
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	a := Attachment{ItemID: itemID, Filename: header.Filename, ContentType: contentType}
+	if isDryRun(r.Context()) {
+		a.Size, a.StoredAt = int64(len(data)), time.Now().UTC()
+	} else {
+		a = attachments.Add(a, data)
+	}
+	respondWithJSON(w, http.StatusCreated, a)
+}
+
+// listAttachments (GET /items/{id}/attachments)
+// This returns the attachments of an item without their content.
+func listAttachments(w http.ResponseWriter, r *http.Request) {
+	itemID := mux.Vars(r)["id"]
+	if _, err := storeFromContext(r.Context()).GetByID(r.Context(), itemID); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, attachments.List(itemID))
+}
+
+// downloadAttachment (GET /items/{id}/attachments/{aid})
+// This sends the attachment's content as a download. Browsers are told not to
+// sniff or render it inline, so an uploaded HTML file cannot run on our origin.
+func downloadAttachment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	a, data, ok := attachments.Get(params["id"], params["aid"])
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Attachment not found")
+		return
+	}
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", a.StoredAt, bytes.NewReader(data))
+}
+
+// deleteAttachment (DELETE /items/{id}/attachments/{aid})
+// This removes an attachment from an item.
+func deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	itemID, id := params["id"], params["aid"]
+	if isDryRun(r.Context()) {
+		if _, _, ok := attachments.Get(itemID, id); !ok {
+			respondWithError(w, http.StatusNotFound, "Attachment not found")
+			return
+		}
+	} else if !attachments.Delete(itemID, id) {
+		respondWithError(w, http.StatusNotFound, "Attachment not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "attachment_deleted": id})
+}
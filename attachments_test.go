@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// uploadRequest builds a multipart POST /items/{id}/attachments request.
+func uploadRequest(itemID, filename string, content []byte) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", filename)
+	part.Write(content)
+	mw.Close()
+	req := httptest.NewRequest("POST", "/items/"+itemID+"/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// TestAttachments (POST, GET and DELETE /items/{id}/attachments)
+func TestAttachments(t *testing.T) {
+	resetGlobalItems()
+	attachments = NewAttachmentStore()
+	router := newRouter()
+	content := []byte("hello, attachment\n")
+
+	// 1. Upload
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, uploadRequest("1", "notes.txt", content))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("upload returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body)
+	}
+	var uploaded Attachment
+	json.NewDecoder(rr.Body).Decode(&uploaded)
+	if uploaded.ID == "" || uploaded.ItemID != "1" || uploaded.Filename != "notes.txt" || uploaded.Size != int64(len(content)) {
+		t.Errorf("wrong attachment metadata: %+v", uploaded)
+	}
+
+	// 2. List
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/1/attachments", nil))
+	var list []Attachment
+	json.NewDecoder(rr.Body).Decode(&list)
+	if len(list) != 1 || list[0].ID != uploaded.ID {
+		t.Errorf("wrong attachment list: %+v", list)
+	}
+
+	// 3. Download
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/1/attachments/"+uploaded.ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("download returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if !bytes.Equal(rr.Body.Bytes(), content) {
+		t.Errorf("downloaded content differs: got %q want %q", rr.Body.Bytes(), content)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment") {
+		t.Errorf("download is not marked as an attachment: %q", cd)
+	}
+
+	// 4. Attachments are scoped to their item
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/2/attachments/"+uploaded.ID, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("attachment served for another item: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+
+	// 5. Delete
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/1/attachments/"+uploaded.ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("delete returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if list := attachments.List("1"); len(list) != 0 {
+		t.Errorf("attachment still listed after delete: %+v", list)
+	}
+}
+
+// TestAttachmentLimits checks uploads that must be rejected.
+func TestAttachmentLimits(t *testing.T) {
+	resetGlobalItems()
+	attachments = NewAttachmentStore()
+	router := newRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, uploadRequest("1", "big.bin", make([]byte, maxAttachmentSize+1)))
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized upload: got %v want %v", rr.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, uploadRequest("999", "notes.txt", []byte("x")))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("upload to a missing item: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+	if len(attachments.List("1")) != 0 || len(attachments.List("999")) != 0 {
+		t.Error("a rejected upload was stored")
+	}
+}
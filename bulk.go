@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// errBatchInvalid wraps validation errors for a batch, which answer 422 Unprocessable Entity.
+var errBatchInvalid = errors.New("invalid batch")
+
+// respondWithBatchError maps an error from a batch operation to a JSON error response
+func respondWithBatchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBatchInvalid) {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	respondWithStorageError(w, err)
+}
+
+// maxBatchAttempts is how often transactionalBatchUpdate retries when an
+// item is deleted between finding it and updating it.
+const maxBatchAttempts = 3
+
+// transactionalBatchUpdate validates every update and then applies them all
+// at once, replacing the writable fields of each listed item like PUT
+// /items/{id}. Nothing is changed unless every update is valid. Updates for
+// items that do not exist are skipped, and their IDs returned in notFound.
+func transactionalBatchUpdate(ctx context.Context, s Storage, updates []Item) (updated []Item, notFound []string, err error) {
+	byID := make(map[string]Item, len(updates))
+	ids := make([]string, len(updates))
+	for i, update := range updates {
+		if update.ID == "" {
+			return nil, nil, fmt.Errorf("%w: update %d: id is required", errBatchInvalid, i)
+		}
+		if _, ok := byID[update.ID]; ok {
+			return nil, nil, fmt.Errorf("%w: update %d: item %s is listed twice", errBatchInvalid, i, update.ID)
+		}
+		if err := validateItem(update); err != nil {
+			return nil, nil, fmt.Errorf("%w: update %d: %v", errBatchInvalid, i, err)
+		}
+		sanitizeItem(&update)
+		byID[update.ID] = update
+		ids[i] = update.ID
+	}
+	apply := func(item *Item) error {
+		update := byID[item.ID]
+		item.Name = update.Name
+		item.Description = update.Description
+		item.Tags = update.Tags
+		return nil
+	}
+
+	for attempt := 0; attempt < maxBatchAttempts; attempt++ {
+		items, err := s.GetAll(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		stored := make(map[string]bool, len(items))
+		for _, item := range items {
+			stored[item.ID] = true
+		}
+		var found []string
+		notFound = []string{}
+		for _, id := range ids {
+			if stored[id] {
+				found = append(found, id)
+			} else {
+				notFound = append(notFound, id)
+			}
+		}
+		if len(found) == 0 {
+			return []Item{}, notFound, nil
+		}
+		updated, err = s.UpdateBatch(ctx, found, apply)
+		if !errors.Is(err, errItemNotFound) {
+			return updated, notFound, err
+		}
+	}
+	return nil, nil, fmt.Errorf("items kept disappearing during the batch: %w", errItemNotFound)
+}
+
+// decodeBatch reads a non-empty JSON array of items from the request body.
+func decodeBatch(r *http.Request) ([]Item, error) {
+	var items []Item
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		return nil, errors.New("Invalid request payload")
+	}
+	if len(items) == 0 {
+		return nil, errors.New("batch must contain at least one item")
+	}
+	return items, nil
+}
+
+// createItemsBulk (POST /items/bulk)
+// This creates every item in the body, or none of them if any is invalid.
+func createItemsBulk(w http.ResponseWriter, r *http.Request) {
+	items, err := decodeBatch(r)
+	defer r.Body.Close()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate the whole batch before anything is stored
+	now := time.Now().UTC()
+	for i := range items {
+		if err := validateItem(items[i]); err != nil {
+			respondWithBatchError(w, fmt.Errorf("%w: item %d: %v", errBatchInvalid, i, err))
+			return
+		}
+		sanitizeItem(&items[i])
+		items[i].CreatedAt = now
+	}
+
+	// On an ID collision the whole batch is retried with fresh IDs
+	var created []Item
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		used := make(map[string]bool, len(items))
+		for i := range items {
+			id := newItemID(r.Context())
+			for used[id] {
+				id = newItemID(r.Context())
+			}
+			used[id] = true
+			items[i].ID = id
+		}
+		if created, err = storeFromContext(r.Context()).CreateBatch(r.Context(), items); !errors.Is(err, errDuplicateID) {
+			break
+		}
+	}
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	for _, item := range created {
+		recordItemChange(r.Context(), eventItemCreated, item)
+	}
+
+	respondWithJSON(w, http.StatusCreated, created)
+}
+
+// updateItemsBulk (PUT /items/bulk)
+// This applies every update in the body in one atomic write, or none of them
+// if any is invalid. IDs of items that do not exist are listed in not_found.
+func updateItemsBulk(w http.ResponseWriter, r *http.Request) {
+	updates, err := decodeBatch(r)
+	defer r.Body.Close()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Locked items can only be part of a batch sent by the lock holder
+	for _, update := range updates {
+		if lock, locked := itemLocks.Check(update.ID, r.Header.Get(lockTokenHeader)); locked {
+			respondWithLocked(w, lock)
+			return
+		}
+	}
+
+	updated, notFound, err := transactionalBatchUpdate(r.Context(), storeFromContext(r.Context()), updates)
+	if err != nil {
+		respondWithBatchError(w, err)
+		return
+	}
+	for _, item := range updated {
+		recordItemChange(r.Context(), eventItemUpdated, item)
+	}
+
+	// An invalid update rejects the whole batch with 422, so errors is empty here
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"updated":   updated,
+		"not_found": notFound,
+		"errors":    []string{},
+	})
+}
+
+// itemFilterParams are the query parameters DELETE /items matches items by.
+var itemFilterParams = []string{"name", "names", "tag", "namespace", "request_id", "created_after", "created_before"}
+
+// filterItems keeps the items matching every filter in query: the filters
+// GET /items has, applied the same way, and a ?tag= among the item's tags.
+// ?name= is a case-insensitive substring match, as it is for GET /items, so
+// a GET with the same query lists exactly the items a DELETE would remove.
+func filterItems(items []Item, query url.Values) ([]Item, error) {
+	after, err := parseTimeParam(query, "created_after")
+	if err != nil {
+		return nil, err
+	}
+	before, err := parseTimeParam(query, "created_before")
+	if err != nil {
+		return nil, err
+	}
+	items = filterByDateRange(items, after, before)
+	items = filterByRequestID(items, query.Get("request_id"))
+	items = filterByNamespace(items, query.Get("namespace"))
+	items = filterByNameSubstring(items, query.Get("name"))
+	items = filterByNames(items, query["names"])
+
+	tag := query.Get("tag")
+	filtered := []Item{}
+	for _, item := range items {
+		if tag != "" && !slices.Contains(item.Tags, tag) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered, nil
+}
+
+// deleteItemsMatching (DELETE /items?name=...&tag=...)
+// This deletes every item matching the filters and lists the deleted IDs.
+// At least one filter is required, so a bare DELETE /items cannot wipe the store.
+// Nothing is deleted if any matching item is locked by someone else.
+func deleteItemsMatching(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !slices.ContainsFunc(itemFilterParams, func(param string) bool { return query.Get(param) != "" }) {
+		respondWithError(w, http.StatusBadRequest, "at least one filter is required to prevent accidental bulk delete")
+		return
+	}
+
+	s := storeFromContext(r.Context())
+	items, err := s.GetAll(r.Context())
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	matching, err := filterItems(items, query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, item := range matching {
+		if lock, locked := itemLocks.Check(item.ID, r.Header.Get(lockTokenHeader)); locked {
+			respondWithLocked(w, lock)
+			return
+		}
+	}
+
+	ids := []string{}
+	for _, item := range matching {
+		err := s.Delete(r.Context(), item.ID)
+		if errors.Is(err, errItemNotFound) {
+			continue // deleted by someone else in the meantime
+		}
+		if err != nil {
+			respondWithStorageError(w, err)
+			return
+		}
+		ids = append(ids, item.ID)
+		recordItemChange(r.Context(), eventItemDeleted, Item{ID: item.ID})
+		if !isDryRun(r.Context()) {
+			attachments.DeleteItem(item.ID)
+			notes.DeleteItem(item.ID)
+			links.DeleteItem(item.ID)
+			views.Delete(item.ID)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"deleted": len(ids), "ids": ids})
+}
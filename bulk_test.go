@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestBulkOperations checks that bulk writes are all or nothing.
+func TestBulkOperations(t *testing.T) {
+	router := newRouter()
+	do := func(method, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, "/items/bulk", bytes.NewBufferString(body)))
+		return rr
+	}
+
+	// Sub-test for "Create Batch"
+	t.Run("Create Batch", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := do("POST", `[{"name":"Bulk 1"},{"name":"Bulk 2","description":"second"}]`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		var created []Item
+		json.NewDecoder(rr.Body).Decode(&created)
+		if len(created) != 2 || created[0].ID == "" || created[0].ID == created[1].ID {
+			t.Errorf("handler returned wrong items: %+v", created)
+		}
+		if len(storedItems()) != 4 {
+			t.Errorf("batch was not stored: got %d items want %d", len(storedItems()), 4)
+		}
+	})
+
+	// Sub-test for "Create Batch With Invalid Item"
+	t.Run("Create Batch With Invalid Item", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := do("POST", `[{"name":"Valid"},{"name":""}]`)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+		}
+		if len(storedItems()) != 2 {
+			t.Errorf("invalid batch stored items: got %d items want %d", len(storedItems()), 2)
+		}
+	})
+
+	// Sub-test for "Update Batch"
+	t.Run("Update Batch", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := do("PUT", `[{"id":"1","name":"A"},{"id":"2","name":"B"}]`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		items := storedItems()
+		if items[0].Name != "A" || items[1].Name != "B" {
+			t.Errorf("batch was not applied: %+v", items)
+		}
+	})
+
+	// Sub-test for "Update Batch With Unknown Item"
+	t.Run("Update Batch With Unknown Item", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := do("PUT", `[{"id":"1","name":"A"},{"id":"id99","name":"X"},{"id":"2","name":"B"}]`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var body struct {
+			Updated  []Item   `json:"updated"`
+			NotFound []string `json:"not_found"`
+			Errors   []string `json:"errors"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		if len(body.Updated) != 2 || !reflect.DeepEqual(body.NotFound, []string{"id99"}) || body.Errors == nil {
+			t.Errorf("handler returned wrong body: %+v", body)
+		}
+		items := storedItems()
+		if len(items) != 2 || items[0].Name != "A" || items[1].Name != "B" {
+			t.Errorf("existing items were not updated: %+v", items)
+		}
+	})
+
+	// Sub-test for "Update Batch With Invalid Item"
+	t.Run("Update Batch With Invalid Item", func(t *testing.T) {
+		cases := map[string]struct {
+			body string
+			want int
+		}{
+			"Missing Name":                 {`[{"id":"1","name":"A"},{"id":"2","name":""}]`, http.StatusUnprocessableEntity},
+			"Missing ID":                   {`[{"id":"1","name":"A"},{"name":"B"}]`, http.StatusUnprocessableEntity},
+			"Invalid Item With Unknown ID": {`[{"id":"1","name":"A"},{"id":"999","name":""}]`, http.StatusUnprocessableEntity},
+		}
+		for name, tc := range cases {
+			resetGlobalItems()
+
+			rr := do("PUT", tc.body)
+			if rr.Code != tc.want {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", name, rr.Code, tc.want)
+			}
+			if items := storedItems(); items[0].Name != "Mock Item 1" || items[0].Version != 1 {
+				t.Errorf("%s: failed batch changed item 1: %+v", name, items[0])
+			}
+		}
+	})
+}
+
+// TestDeleteItemsMatching (DELETE /items?name=...)
+func TestDeleteItemsMatching(t *testing.T) {
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "test", Tags: []string{"demo"}},
+		Item{ID: "2", Name: "test"},
+		Item{ID: "3", Name: "keep", Tags: []string{"demo"}},
+		Item{ID: "4", Name: "testing"},
+		Item{ID: "5", Name: "other"},
+	)
+	defer resetGlobalItems()
+	router := newRouter()
+	do := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("DELETE", path, nil))
+		return rr
+	}
+	remaining := func() []string {
+		var ids []string
+		for _, item := range storedItems() {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	t.Run("No Filter", func(t *testing.T) {
+		rr := do("/items")
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		var body map[string]string
+		json.NewDecoder(rr.Body).Decode(&body)
+		if want := "at least one filter is required to prevent accidental bulk delete"; body["error"] != want {
+			t.Errorf("handler returned wrong error: got %q want %q", body["error"], want)
+		}
+		if len(remaining()) != 5 {
+			t.Errorf("items were deleted without a filter: %v", remaining())
+		}
+	})
+
+	t.Run("By Name", func(t *testing.T) {
+		// GET /items with the same filter lists what is about to be deleted
+		list := httptest.NewRecorder()
+		router.ServeHTTP(list, httptest.NewRequest("GET", "/items?name=TEST", nil))
+		var listed []Item
+		json.NewDecoder(list.Body).Decode(&listed)
+		var listedIDs []string
+		for _, item := range listed {
+			listedIDs = append(listedIDs, item.ID)
+		}
+
+		rr := do("/items?name=TEST")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var body struct {
+			Deleted int      `json:"deleted"`
+			IDs     []string `json:"ids"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.Deleted != 3 || !reflect.DeepEqual(body.IDs, []string{"1", "2", "4"}) {
+			t.Errorf("wrong response: got %+v", body)
+		}
+		if !reflect.DeepEqual(body.IDs, listedIDs) {
+			t.Errorf("DELETE and GET selected different items: deleted %v, listed %v", body.IDs, listedIDs)
+		}
+		if got, want := remaining(), []string{"3", "5"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong items left: got %v want %v", got, want)
+		}
+	})
+
+	t.Run("By Name and Tag", func(t *testing.T) {
+		if rr := do("/items?name=other&tag=demo"); rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if got := remaining(); len(got) != 2 {
+			t.Errorf("filters were not combined: got %v", got)
+		}
+		do("/items?tag=demo")
+		if got, want := remaining(), []string{"5"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong items left: got %v want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CachedStore wraps a Storage with an LRU cache of items by ID, so repeated
+// GET /items/{id} calls for popular items do not reach the backend.
+// Writes through the wrapper keep the cache current; writes made to the
+// backend by anything else are not seen until the item falls out of the cache.
+type CachedStore struct {
+	Storage
+
+	mu      sync.Mutex
+	size    int
+	order   *list.List               // most recently used first; values are Items
+	entries map[string]*list.Element // by item ID
+}
+
+// NewCachedStore returns s with a cache holding up to size items.
+func NewCachedStore(s Storage, size int) *CachedStore {
+	return &CachedStore{Storage: s, size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// cacheOf finds the CachedStore in a chain of Storage wrappers.
+func cacheOf(s Storage) (*CachedStore, bool) {
+	return findWrapper[*CachedStore](s)
+}
+
+// Unwrap returns the wrapped Storage.
+func (c *CachedStore) Unwrap() Storage {
+	return c.Storage
+}
+
+// Ping checks the wrapped backend, if it can be checked.
+func (c *CachedStore) Ping(ctx context.Context) error {
+	if p, ok := c.Storage.(pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// Len returns how many items are cached.
+func (c *CachedStore) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Clear empties the cache, for when the backend changed behind its back.
+func (c *CachedStore) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// get returns a cached item and marks it as recently used.
+func (c *CachedStore) get(id string) (Item, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok {
+		return Item{}, false
+	}
+	c.order.MoveToFront(e)
+	item := e.Value.(Item)
+	ownTags(&item)
+	return item, true
+}
+
+// put caches item as the most recently used, evicting the least recently used beyond size.
+func (c *CachedStore) put(item Item) {
+	ownTags(&item)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[item.ID]; ok {
+		e.Value = item
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[item.ID] = c.order.PushFront(item)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(Item).ID)
+	}
+}
+
+// evict drops an item from the cache.
+func (c *CachedStore) evict(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[id]; ok {
+		c.order.Remove(e)
+		delete(c.entries, id)
+	}
+}
+
+// GetByID serves the item from the cache, loading it on a miss.
+func (c *CachedStore) GetByID(ctx context.Context, id string) (Item, error) {
+	if item, ok := c.get(id); ok {
+		cacheHits.Add(1)
+		return item, nil
+	}
+	cacheMisses.Add(1)
+	item, err := c.Storage.GetByID(ctx, id)
+	if err != nil {
+		return Item{}, err
+	}
+	c.put(item)
+	return item, nil
+}
+
+// Create stores the item and caches it.
+func (c *CachedStore) Create(ctx context.Context, item Item) (Item, error) {
+	created, err := c.Storage.Create(ctx, item)
+	if err != nil {
+		return Item{}, err
+	}
+	c.put(created)
+	return created, nil
+}
+
+// CreateBatch stores the items and caches them.
+func (c *CachedStore) CreateBatch(ctx context.Context, items []Item) ([]Item, error) {
+	created, err := c.Storage.CreateBatch(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range created {
+		c.put(item)
+	}
+	return created, nil
+}
+
+// Update updates the item and caches the new version.
+func (c *CachedStore) Update(ctx context.Context, id string, fn func(*Item) error) (Item, error) {
+	item, err := c.Storage.Update(ctx, id, fn)
+	if err != nil {
+		return Item{}, err
+	}
+	c.put(item)
+	return item, nil
+}
+
+// UpdateBatch updates the items and caches the new versions.
+func (c *CachedStore) UpdateBatch(ctx context.Context, ids []string, fn func(*Item) error) ([]Item, error) {
+	items, err := c.Storage.UpdateBatch(ctx, ids, fn)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		c.put(item)
+	}
+	return items, nil
+}
+
+// Delete removes the item from the backend and the cache.
+func (c *CachedStore) Delete(ctx context.Context, id string) error {
+	defer c.evict(id)
+	return c.Storage.Delete(ctx, id)
+}
+
+// Warm loads the limit most recently updated items into the cache and
+// returns how many were loaded. It never loads more than the cache holds.
+func (c *CachedStore) Warm(ctx context.Context, limit int) (int, error) {
+	items, err := c.Storage.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	slices.SortStableFunc(items, func(a, b Item) int { return b.UpdatedAt.Compare(a.UpdatedAt) })
+	n := min(limit, c.size, len(items))
+	// Insert the most recent last so it ends up as the most recently used
+	for i := n - 1; i >= 0; i-- {
+		c.put(items[i])
+	}
+	return n, nil
+}
+
+// defaultWarmLimit is how many items POST /cache/warm loads without ?limit=.
+const defaultWarmLimit = 100
+
+// warmCache (POST /cache/warm?limit=N)
+// This pre-loads the most recently updated items into the item cache,
+// reporting how many were loaded and how long it took.
+func warmCache(w http.ResponseWriter, r *http.Request) {
+	c, ok := cacheOf(storeFromContext(r.Context()))
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "item cache is not enabled")
+		return
+	}
+	limit := defaultWarmLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			respondWithError(w, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+
+	start := time.Now()
+	warmed, err := c.Warm(r.Context(), limit)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	elapsed := float64(time.Since(start).Microseconds()) / 1000
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"warmed": warmed, "warm_time_ms": elapsed})
+}
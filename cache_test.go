@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// panicStorage fails the test if the backend behind a cache is reached.
+type panicStorage struct {
+	Storage
+}
+
+func (panicStorage) GetByID(ctx context.Context, id string) (Item, error) {
+	panic("storage was called for item " + id)
+}
+
+// TestCachedStore runs the storage test table against a CachedStore small
+// enough that evictions happen.
+func TestCachedStore(t *testing.T) {
+	runStorageTests(t, func(t *testing.T) Storage {
+		return NewCachedStore(NewMemoryStore(), 1)
+	})
+}
+
+// TestWarmCache (POST /cache/warm)
+func TestWarmCache(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var items []Item
+	for i := 0; i < 200; i++ {
+		items = append(items, Item{ID: strconv.Itoa(i), Name: "Item " + strconv.Itoa(i), CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+	cached := NewCachedStore(NewMemoryStore(items...), 100)
+	store = cached
+	defer resetGlobalItems()
+	router := newRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/cache/warm?limit=50", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var result map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&result)
+	if result["warmed"] != 50.0 {
+		t.Errorf("wrong warmed count: got %v want %v", result["warmed"], 50)
+	}
+	if _, ok := result["warm_time_ms"].(float64); !ok {
+		t.Errorf("response has no warm_time_ms: %v", result)
+	}
+	if n := cached.Len(); n != 50 {
+		t.Errorf("wrong number of cached items: got %d want %d", n, 50)
+	}
+
+	// The newest item must now be served without touching storage
+	cached.Storage = panicStorage{}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/199", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var item Item
+	json.NewDecoder(rr.Body).Decode(&item)
+	if item.ID != "199" || item.Name != "Item 199" {
+		t.Errorf("wrong item served from cache: %+v", item)
+	}
+	if _, ok := cached.get("149"); ok {
+		t.Error("an item outside the 50 most recent was warmed")
+	}
+}
+
+// TestWarmCacheDisabled checks the answer without a cache.
+func TestWarmCacheDisabled(t *testing.T) {
+	resetGlobalItems()
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest("POST", "/cache/warm", nil))
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotImplemented)
+	}
+}
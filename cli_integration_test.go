@@ -0,0 +1,141 @@
+//go:build integration
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildCLI compiles cmd/demojam-cli into a temporary directory and returns the binary path.
+func buildCLI(t *testing.T) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "demojam-cli")
+	out, err := exec.Command("go", "build", "-o", bin, "./cmd/demojam-cli").CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to build CLI: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// runCLI runs the CLI binary against the given server and returns stdout, stderr and the exit code.
+func runCLI(t *testing.T, bin, serverURL string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(bin, append([]string{"--url", serverURL}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("failed to run CLI: %v", err)
+	}
+	return stdout.String(), stderr.String(), code
+}
+
+// TestCLI exercises every CLI sub-command against a real test server.
+func TestCLI(t *testing.T) {
+	resetGlobalItems()
+	bin := buildCLI(t)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	// Sub-test for "list"
+	t.Run("list", func(t *testing.T) {
+		stdout, _, code := runCLI(t, bin, server.URL, "list")
+		if code != 0 {
+			t.Fatalf("list exited with code %d", code)
+		}
+		var items []Item
+		if err := json.Unmarshal([]byte(stdout), &items); err != nil {
+			t.Fatalf("list printed invalid JSON: %v\n%s", err, stdout)
+		}
+		if len(items) != 2 {
+			t.Errorf("list returned unexpected number of items: got %d want %d", len(items), 2)
+		}
+		// Output should be pretty-printed
+		if !strings.Contains(stdout, "\n  ") {
+			t.Errorf("list output is not indented: %s", stdout)
+		}
+	})
+
+	// Sub-test for "get"
+	t.Run("get", func(t *testing.T) {
+		stdout, _, code := runCLI(t, bin, server.URL, "get", "1")
+		if code != 0 {
+			t.Fatalf("get exited with code %d", code)
+		}
+		var item Item
+		json.Unmarshal([]byte(stdout), &item)
+		if item.Name != "Mock Item 1" {
+			t.Errorf("get returned wrong item: got %+v", item)
+		}
+	})
+
+	// Sub-test for "get missing"
+	t.Run("get missing", func(t *testing.T) {
+		stdout, stderr, code := runCLI(t, bin, server.URL, "get", "999")
+		if code == 0 {
+			t.Error("get for a missing item exited with code 0")
+		}
+		if stdout != "" {
+			t.Errorf("get for a missing item wrote to stdout: %q", stdout)
+		}
+		if !strings.Contains(stderr, "Item not found") {
+			t.Errorf("stderr does not contain the server error: %q", stderr)
+		}
+	})
+
+	// Sub-test for "create, update, search and delete"
+	t.Run("create, update, search and delete", func(t *testing.T) {
+		stdout, _, code := runCLI(t, bin, server.URL, "create", "--name", "CLI Item", "--description", "made by the CLI")
+		if code != 0 {
+			t.Fatalf("create exited with code %d", code)
+		}
+		var created Item
+		json.Unmarshal([]byte(stdout), &created)
+		if created.ID == "" || created.Name != "CLI Item" {
+			t.Fatalf("create returned wrong item: %+v", created)
+		}
+
+		stdout, _, code = runCLI(t, bin, server.URL, "update", created.ID, "--name", "Renamed CLI Item")
+		if code != 0 {
+			t.Fatalf("update exited with code %d", code)
+		}
+		var updated Item
+		json.Unmarshal([]byte(stdout), &updated)
+		if updated.Name != "Renamed CLI Item" {
+			t.Errorf("update did not change the name: got %q", updated.Name)
+		}
+		// Flags that were not passed should be left alone
+		if updated.Description != "made by the CLI" {
+			t.Errorf("update changed the description: got %q", updated.Description)
+		}
+
+		stdout, _, code = runCLI(t, bin, server.URL, "search", "renamed")
+		if code != 0 {
+			t.Fatalf("search exited with code %d", code)
+		}
+		var found []Item
+		json.Unmarshal([]byte(stdout), &found)
+		if len(found) != 1 || found[0].ID != created.ID {
+			t.Errorf("search returned wrong items: got %+v", found)
+		}
+
+		_, _, code = runCLI(t, bin, server.URL, "delete", created.ID)
+		if code != 0 {
+			t.Fatalf("delete exited with code %d", code)
+		}
+		if _, _, code = runCLI(t, bin, server.URL, "get", created.ID); code == 0 {
+			t.Error("item still exists after delete")
+		}
+	})
+}
@@ -0,0 +1,267 @@
+// demojam-cli is a small command line client for the Demo Jam API.
+//
+// Usage:
+//
+//	demojam-cli [--url http://localhost:8080] <command> [arguments]
+//
+// Commands:
+//
+//	list                                      list every item
+//	get <id>                                  show a single item
+//	create --name N [--description D]         create a new item
+//	update <id> [--name N] [--description D]  change an existing item
+//	delete <id>                               delete an item
+//	search <query>                            list items whose name or description contains query
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// itemInput is the writable part of an item sent in create and update requests.
+type itemInput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// client talks to a single API server.
+type client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// apiError is returned when the server answers with a 4xx or 5xx status.
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+// do sends a request and returns the raw response body.
+// Error responses are turned into an *apiError.
+func (c *client) do(method, path string, payload interface{}) ([]byte, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		var errBody struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &errBody) != nil || errBody.Error == "" {
+			errBody.Error = http.StatusText(resp.StatusCode)
+		}
+		return nil, &apiError{Status: resp.StatusCode, Message: errBody.Error}
+	}
+	return data, nil
+}
+
+// itemPath returns the URL path for a single item.
+func itemPath(id string) string {
+	return "/items/" + url.PathEscape(id)
+}
+
+// printJSON pretty-prints a value as indented JSON.
+func printJSON(w io.Writer, v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+// printRaw pretty-prints a JSON response body as returned by the server.
+func printRaw(w io.Writer, data []byte) error {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, out.String())
+	return err
+}
+
+// run executes the CLI and returns the process exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("demojam-cli", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	baseURL := global.String("url", "http://localhost:8080", "base URL of the Demo Jam API")
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if global.NArg() == 0 {
+		fmt.Fprintln(stderr, "usage: demojam-cli [--url URL] <list|get|create|update|delete|search> [arguments]")
+		return 2
+	}
+
+	c := &client{baseURL: strings.TrimRight(*baseURL, "/"), http: http.DefaultClient}
+	command, rest := global.Arg(0), global.Args()[1:]
+
+	if err := runCommand(c, command, rest, stdout, stderr); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		if _, ok := err.(usageError); ok {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// usageError reports a command that was called with the wrong arguments.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// runCommand dispatches a single sub-command.
+func runCommand(c *client, command string, args []string, stdout, stderr io.Writer) error {
+	switch command {
+	case "list":
+		data, err := c.do("GET", "/items", nil)
+		if err != nil {
+			return err
+		}
+		return printRaw(stdout, data)
+
+	case "get":
+		if len(args) != 1 {
+			return usageError("usage: get <id>")
+		}
+		data, err := c.do("GET", itemPath(args[0]), nil)
+		if err != nil {
+			return err
+		}
+		return printRaw(stdout, data)
+
+	case "create":
+		fs := flag.NewFlagSet("create", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		name := fs.String("name", "", "item name")
+		description := fs.String("description", "", "item description")
+		if err := fs.Parse(args); err != nil {
+			return usageError(err.Error())
+		}
+		data, err := c.do("POST", "/items", itemInput{Name: *name, Description: *description})
+		if err != nil {
+			return err
+		}
+		return printRaw(stdout, data)
+
+	case "update":
+		if len(args) < 1 {
+			return usageError("usage: update <id> [--name N] [--description D]")
+		}
+		id := args[0]
+		fs := flag.NewFlagSet("update", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		name := fs.String("name", "", "new item name")
+		description := fs.String("description", "", "new item description")
+		if err := fs.Parse(args[1:]); err != nil {
+			return usageError(err.Error())
+		}
+
+		// PUT replaces every field, so start from the current item and
+		// only change what was passed on the command line.
+		data, err := c.do("GET", itemPath(id), nil)
+		if err != nil {
+			return err
+		}
+		var item itemInput
+		if err := json.Unmarshal(data, &item); err != nil {
+			return err
+		}
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				item.Name = *name
+			case "description":
+				item.Description = *description
+			}
+		})
+
+		data, err = c.do("PUT", itemPath(id), item)
+		if err != nil {
+			return err
+		}
+		return printRaw(stdout, data)
+
+	case "delete":
+		if len(args) != 1 {
+			return usageError("usage: delete <id>")
+		}
+		data, err := c.do("DELETE", itemPath(args[0]), nil)
+		if err != nil {
+			return err
+		}
+		return printRaw(stdout, data)
+
+	case "search":
+		if len(args) != 1 {
+			return usageError("usage: search <query>")
+		}
+		data, err := c.do("GET", "/items", nil)
+		if err != nil {
+			return err
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		// GET /search only returns IDs and summaries, so filter the full list
+		// here. Matching items are printed exactly as the server sent them.
+		query := strings.ToLower(args[0])
+		matches := []json.RawMessage{}
+		for _, raw := range items {
+			var item itemInput
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return err
+			}
+			if strings.Contains(strings.ToLower(item.Name), query) ||
+				strings.Contains(strings.ToLower(item.Description), query) {
+				matches = append(matches, raw)
+			}
+		}
+		return printJSON(stdout, matches)
+
+	default:
+		return usageError(fmt.Sprintf("unknown command %q", command))
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
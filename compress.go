@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// encoder is a compressor that can be reused for another body after Reset.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoderPools hold idle encoders by Content-Encoding token. Encoders keep
+// large buffers, so allocating one per response would dominate the cost.
+// compressionOffers lists the encodings in order of preference.
+var (
+	encoderPools = map[string]*sync.Pool{
+		"br":   {New: func() any { return brotli.NewWriter(nil) }},
+		"gzip": {New: func() any { return gzip.NewWriter(nil) }},
+	}
+	compressionOffers = []string{"identity", "br", "gzip"}
+)
+
+// compressWriter compresses the body with encoding. The encoder is only
+// started on the first write, so empty and hijacked responses stay untouched.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	encoder     encoder
+	wroteHeader bool
+	skip        bool
+}
+
+// start decides, just before the headers go out, whether to compress.
+// Responses without a body or already encoded by the handler are left alone.
+func (c *compressWriter) start(code int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	h := c.Header()
+	if code == http.StatusNoContent || code == http.StatusNotModified || code < 200 || h.Get("Content-Encoding") != "" {
+		c.skip = true
+		return
+	}
+	h.Set("Content-Encoding", c.encoding)
+	h.Del("Content-Length")
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	c.start(code)
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	c.start(http.StatusOK)
+	if c.skip {
+		return c.ResponseWriter.Write(b)
+	}
+	if c.encoder == nil {
+		c.encoder = encoderPools[c.encoding].Get().(encoder)
+		c.encoder.Reset(c.ResponseWriter)
+	}
+	return c.encoder.Write(b)
+}
+
+// Close flushes the rest of the compressed body and returns the encoder to its pool.
+func (c *compressWriter) Close() error {
+	if c.encoder == nil {
+		return nil
+	}
+	err := c.encoder.Close()
+	encoderPools[c.encoding].Put(c.encoder)
+	c.encoder = nil
+	return err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Flush sends what has been compressed so far, for streaming responses.
+func (c *compressWriter) Flush() {
+	if c.encoder != nil {
+		c.encoder.Flush()
+	}
+	http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Hijack keeps WebSocket upgrades working through the wrapper.
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(c.ResponseWriter).Hijack()
+}
+
+// compressionMiddleware compresses response bodies with the encoding the
+// client's Accept-Encoding prefers, Brotli over gzip when both are equally
+// welcome. Clients asking for neither get the body as it is.
+func compressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		// Encoding tokens have no "/", so negotiate only matches them exactly
+		encoding := negotiate(r.Header.Get("Accept-Encoding"), compressionOffers...)
+		if encoding == "identity" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
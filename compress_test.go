@@ -0,0 +1,81 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// TestCompressionMiddleware
+func TestCompressionMiddleware(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/items", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if vary := rr.Header().Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("wrong Vary header: %q", vary)
+		}
+		return rr
+	}
+	plain := get("").Body.String()
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		wantEncoding   string
+		decompress     func(r io.Reader) (io.Reader, error)
+	}{
+		{"Brotli", "br", "br", func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+		{"Brotli Preferred", "gzip, deflate, br", "br", func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+		{"Gzip Weighted Higher", "br;q=0.5, gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"Unknown Encoding", "xyz", "", nil},
+	}
+	for _, tt := range tests {
+		// Sub-test for each Accept-Encoding header
+		t.Run(tt.name, func(t *testing.T) {
+			rr := get(tt.acceptEncoding)
+			if got := rr.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("wrong Content-Encoding: got %q want %q", got, tt.wantEncoding)
+			}
+			var body io.Reader = rr.Body
+			if tt.decompress != nil {
+				var err error
+				if body, err = tt.decompress(rr.Body); err != nil {
+					t.Fatal(err)
+				}
+			}
+			decoded, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+			if string(decoded) != plain {
+				t.Errorf("decompressed body differs:\ngot  %q\nwant %q", decoded, plain)
+			}
+		})
+	}
+
+	// Sub-test for "Not Modified"
+	t.Run("Not Modified", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set("Accept-Encoding", "br")
+		req.Header.Set("If-Modified-Since", "Fri, 01 Jan 2100 00:00:00 GMT")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotModified || rr.Header().Get("Content-Encoding") != "" || rr.Body.Len() != 0 {
+			t.Errorf("304 was compressed: %v %v %q", rr.Code, rr.Header(), rr.Body)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestConcurrentCreateDelete hammers the full HTTP path with concurrent
+// creates and deletes. Run it with `go test -race` to catch data races.
+func TestConcurrentCreateDelete(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping stress test in short mode")
+	}
+
+	const (
+		workers          = 50
+		createsPerWorker = 100
+	)
+
+	store = NewMemoryStore()
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	// Every worker keeps its connections alive instead of opening new sockets per request
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: workers}}
+
+	var creates, deletes int64
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(worker)))
+			var own []string
+
+			for i := 0; i < createsPerWorker; i++ {
+				payload := fmt.Sprintf(`{"name":"Worker %d Item %d","description":"stress"}`, worker, i)
+				resp, err := client.Post(server.URL+"/items", "application/json", bytes.NewBufferString(payload))
+				if err != nil {
+					t.Errorf("create request failed: %v", err)
+					return
+				}
+				var item Item
+				json.NewDecoder(resp.Body).Decode(&item)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusCreated {
+					t.Errorf("create returned wrong status code: got %v want %v", resp.StatusCode, http.StatusCreated)
+					return
+				}
+				atomic.AddInt64(&creates, 1)
+				own = append(own, item.ID)
+
+				// Randomly delete one of the items this worker created
+				if rng.Intn(2) == 0 {
+					index := rng.Intn(len(own))
+					id := own[index]
+					own = append(own[:index], own[index+1:]...)
+
+					req, _ := http.NewRequest("DELETE", server.URL+"/items/"+id, nil)
+					resp, err := client.Do(req)
+					if err != nil {
+						t.Errorf("delete request failed: %v", err)
+						return
+					}
+					resp.Body.Close()
+					if resp.StatusCode != http.StatusOK {
+						t.Errorf("delete of %s returned wrong status code: got %v want %v", id, resp.StatusCode, http.StatusOK)
+						return
+					}
+					atomic.AddInt64(&deletes, 1)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	// --- Check the final state through the API ---
+
+	resp, err := client.Get(server.URL + "/items")
+	if err != nil {
+		t.Fatalf("list request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var items []Item
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		t.Fatalf("GET /items returned invalid JSON: %v", err)
+	}
+
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if seen[item.ID] {
+			t.Errorf("duplicate ID in store: %s", item.ID)
+		}
+		seen[item.ID] = true
+	}
+
+	if want := creates - deletes; int64(len(items)) != want {
+		t.Errorf("unexpected number of items: got %d want %d (%d creates - %d deletes)",
+			len(items), want, creates, deletes)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerConfig holds the settings that are read from the environment at startup.
+type ServerConfig struct {
+	// AllowHTML disables description sanitization (ALLOW_HTML=true).
+	// Only enable this when every client is trusted.
+	AllowHTML bool
+
+	// ResponseEnvelope wraps every JSON response with request metadata
+	// (RESPONSE_ENVELOPE=true).
+	ResponseEnvelope bool
+
+	// ArtificialDelay is added before every request to simulate a slow
+	// network (ARTIFICIAL_DELAY_MS). Zero disables it; meant for dev only.
+	ArtificialDelay time.Duration
+
+	// DBPath selects the SQLite storage backend when set (DB_PATH).
+	// Without it items are kept in memory and lost on restart.
+	DBPath string
+
+	// DatabaseURL selects the PostgreSQL storage backend when set
+	// (DATABASE_URL). It takes precedence over DBPath.
+	DatabaseURL string
+
+	// ShadowDBPath mirrors all storage traffic into a SQLite database at
+	// this path and logs any result that differs from the primary backend
+	// (SHADOW_DB_PATH). Meant for validating a migration before switching.
+	ShadowDBPath string
+
+	// CacheSize enables an LRU cache of this many items in front of the
+	// storage backend (CACHE_SIZE). Zero, the default, disables it.
+	CacheSize int
+
+	// IDGenerator picks how new item IDs are made (ID_GENERATOR): "random"
+	// numbers (the default), "uuid", or "sequential" numbers continuing
+	// after the highest numeric ID in the store.
+	IDGenerator string
+
+	// IDPrefix is put in front of every new item ID (ID_PREFIX).
+	IDPrefix string
+
+	// APIKeys lists the API keys accepted in X-API-Key (API_KEYS, comma-separated).
+	// When neither APIKeys nor RedisAddr is set, no key is required.
+	APIKeys []string
+
+	// RedisAddr points at a Redis server holding extra, rotatable API keys
+	// (REDIS_ADDR, e.g. "localhost:6379").
+	RedisAddr string
+
+	// AdminToken is the bearer token for /admin endpoints (ADMIN_TOKEN).
+	// Admin endpoints are disabled without it.
+	AdminToken string
+
+	// QueueDepth is how many item events may wait for delivery before new
+	// ones are dropped (QUEUE_DEPTH, default 1000).
+	QueueDepth int
+
+	// QueueWorkers is how many goroutines deliver item events
+	// (QUEUE_WORKERS, default 1). Events stay in order only with one worker.
+	QueueWorkers int
+
+	// CORSAllowedOrigins lists the origins allowed to call the API from a
+	// browser (CORS_ALLOWED_ORIGINS, comma-separated, "*" for any).
+	// CORS headers are only sent when it is set.
+	CORSAllowedOrigins []string
+
+	// CORSMaxAge is how long browsers may cache a preflight response
+	// (CORS_MAX_AGE_SECONDS, default 3600). Zero leaves caching to the browser.
+	CORSMaxAge time.Duration
+
+	// MaxConcurrentRequests caps how many requests are handled at once
+	// (MAX_CONCURRENT_REQUESTS, default 100). Zero or less disables the cap.
+	MaxConcurrentRequests int
+
+	// RateLimitRPS allows each client, by API key or else by IP, this many
+	// requests per second (RATE_LIMIT_RPS), in bursts of up to RateLimitBurst
+	// (RATE_LIMIT_BURST, default RateLimitRPS). Zero, the default, disables it.
+	RateLimitRPS   int
+	RateLimitBurst int
+
+	// DefaultSort and DefaultOrder sort GET /items when the request has no
+	// ?sort= (DEFAULT_SORT, DEFAULT_ORDER). They take the same values as the
+	// query parameters; unset keeps insertion order.
+	DefaultSort  string
+	DefaultOrder string
+
+	// StatsCacheTTL is how long GET /items/stats reuses a result
+	// (STATS_CACHE_TTL_SECONDS, default 30). Zero disables the cache.
+	StatsCacheTTL time.Duration
+
+	// ValidateMarkdown rejects descriptions that do not render as markdown
+	// (VALIDATE_MARKDOWN=true).
+	ValidateMarkdown bool
+
+	// StrictDecode makes PUT /items/{id} reject bodies with unknown fields
+	// instead of ignoring them (STRICT_DECODE=true).
+	StrictDecode bool
+
+	// PageTokenSecret signs the X-Page-Token pagination tokens
+	// (PAGE_TOKEN_SECRET). Without it a random per-process secret is used.
+	PageTokenSecret string
+
+	// SchemaFile is a JSON Schema file that item payloads of POST /items and
+	// PUT /items/{id} must match (SCHEMA_FILE).
+	SchemaFile string
+
+	// MaxResponseBytes truncates response bodies beyond this size
+	// (MAX_RESPONSE_BYTES, default 10 MB). Zero disables the limit.
+	MaxResponseBytes int64
+
+	// MaxURLLength rejects requests whose URL is longer than this with 414
+	// (MAX_URL_LENGTH, default 2048). Zero disables the limit.
+	MaxURLLength int
+
+	// MaxJSONDepth rejects JSON request bodies nested deeper than this with
+	// 400 (MAX_JSON_DEPTH, default 5). Zero disables the check.
+	MaxJSONDepth int
+
+	// WarnResponseBytes logs a warning for responses with a bigger body
+	// (LARGE_RESPONSE_WARN_BYTES, default 100 KB). Zero disables the warning.
+	WarnResponseBytes int64
+
+	// RouteTimeouts limits how long individual routes may take, keyed by
+	// "METHOD /path/template" (ROUTE_TIMEOUTS, a JSON object of durations).
+	RouteTimeouts map[string]time.Duration
+
+	// HTTP2PushCount is how many item detail pages GET /items pushes to
+	// HTTP/2 clients (HTTP2_PUSH_COUNT, default 5). Zero disables push.
+	HTTP2PushCount int
+
+	// HideInternalFields leaves version and last_request_id out of items
+	// sent to callers without the admin token (HIDE_INTERNAL_FIELDS=true).
+	HideInternalFields bool
+
+	// BlockedCIDRs are IP ranges whose requests get 403 Forbidden
+	// (BLOCKED_CIDRS, comma-separated, e.g. "10.0.0.0/8,2001:db8::/32").
+	BlockedCIDRs []string
+
+	// TLSCertFile and TLSKeyFile make the server speak HTTPS on port 8443
+	// instead of HTTP on 8080 (TLS_CERT_FILE, TLS_KEY_FILE).
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// UniqueNames makes POST /items reject a name already used in the same
+	// namespace, ignoring case, with 409 (UNIQUE_NAMES=true).
+	UniqueNames bool
+
+	// EnableDebug serves the expvar counters and runtime stats at
+	// GET /debug/vars (ENABLE_DEBUG=true).
+	EnableDebug bool
+
+	// ReadYourWrites makes writes return an X-Write-Token that
+	// GET /items/{id} accepts as X-Consistency, so clients can wait until
+	// their write is visible (READ_YOUR_WRITES=true).
+	ReadYourWrites bool
+}
+
+// config is the active server configuration.
+// Tests may change fields directly, but should restore them afterwards.
+var config ServerConfig
+
+// loadConfig builds a ServerConfig from environment variables.
+func loadConfig() ServerConfig {
+	c := ServerConfig{
+		AllowHTML:             os.Getenv("ALLOW_HTML") == "true",
+		ResponseEnvelope:      os.Getenv("RESPONSE_ENVELOPE") == "true",
+		ArtificialDelay:       time.Duration(envInt("ARTIFICIAL_DELAY_MS", 0)) * time.Millisecond,
+		DBPath:                os.Getenv("DB_PATH"),
+		DatabaseURL:           os.Getenv("DATABASE_URL"),
+		ShadowDBPath:          os.Getenv("SHADOW_DB_PATH"),
+		CacheSize:             envInt("CACHE_SIZE", 0),
+		IDGenerator:           os.Getenv("ID_GENERATOR"),
+		IDPrefix:              os.Getenv("ID_PREFIX"),
+		APIKeys:               envList("API_KEYS"),
+		RedisAddr:             os.Getenv("REDIS_ADDR"),
+		AdminToken:            os.Getenv("ADMIN_TOKEN"),
+		QueueDepth:            envInt("QUEUE_DEPTH", 1000),
+		QueueWorkers:          envInt("QUEUE_WORKERS", 1),
+		CORSAllowedOrigins:    envList("CORS_ALLOWED_ORIGINS"),
+		CORSMaxAge:            time.Duration(envInt("CORS_MAX_AGE_SECONDS", 3600)) * time.Second,
+		RateLimitRPS:          envInt("RATE_LIMIT_RPS", 0),
+		MaxConcurrentRequests: envInt("MAX_CONCURRENT_REQUESTS", 100),
+		DefaultSort:           os.Getenv("DEFAULT_SORT"),
+		DefaultOrder:          os.Getenv("DEFAULT_ORDER"),
+		StatsCacheTTL:         time.Duration(envInt("STATS_CACHE_TTL_SECONDS", 30)) * time.Second,
+		ValidateMarkdown:      os.Getenv("VALIDATE_MARKDOWN") == "true",
+		StrictDecode:          os.Getenv("STRICT_DECODE") == "true",
+		PageTokenSecret:       os.Getenv("PAGE_TOKEN_SECRET"),
+		SchemaFile:            os.Getenv("SCHEMA_FILE"),
+		MaxResponseBytes:      int64(envInt("MAX_RESPONSE_BYTES", 10<<20)),
+		MaxURLLength:          envInt("MAX_URL_LENGTH", 2048),
+		MaxJSONDepth:          envInt("MAX_JSON_DEPTH", 5),
+		WarnResponseBytes:     int64(envInt("LARGE_RESPONSE_WARN_BYTES", 100<<10)),
+		HTTP2PushCount:        envInt("HTTP2_PUSH_COUNT", 5),
+		HideInternalFields:    os.Getenv("HIDE_INTERNAL_FIELDS") == "true",
+		BlockedCIDRs:          envList("BLOCKED_CIDRS"),
+		TLSCertFile:           os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:            os.Getenv("TLS_KEY_FILE"),
+		UniqueNames:           os.Getenv("UNIQUE_NAMES") == "true",
+		EnableDebug:           os.Getenv("ENABLE_DEBUG") == "true",
+		ReadYourWrites:        os.Getenv("READ_YOUR_WRITES") == "true",
+	}
+	c.RateLimitBurst = envInt("RATE_LIMIT_BURST", c.RateLimitRPS)
+	timeouts, err := parseRouteTimeouts(os.Getenv("ROUTE_TIMEOUTS"))
+	if err != nil {
+		log.Printf("ignoring invalid ROUTE_TIMEOUTS: %v", err)
+	}
+	c.RouteTimeouts = timeouts
+	if err := sortItems(nil, c.DefaultSort, c.DefaultOrder); err != nil {
+		log.Printf("ignoring DEFAULT_SORT=%q DEFAULT_ORDER=%q: %v", c.DefaultSort, c.DefaultOrder, err)
+		c.DefaultSort, c.DefaultOrder = "", ""
+	}
+	return c
+}
+
+// envList reads a comma-separated environment variable, dropping empty entries.
+func envList(name string) []string {
+	return splitList(os.Getenv(name))
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// envInt reads an integer environment variable.
+// Unset or invalid values fall back to def; invalid ones are logged.
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("ignoring invalid %s=%q: %v", name, value, err)
+		return def
+	}
+	return n
+}
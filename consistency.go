@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// writeTokenHeader carries the write token of a create, update or delete.
+	writeTokenHeader = "X-Write-Token"
+	// consistencyHeader passes a write token back on GET /items/{id}.
+	consistencyHeader = "X-Consistency"
+)
+
+// errInvalidWriteToken is returned for X-Consistency values that are not write tokens.
+var errInvalidWriteToken = errors.New("invalid X-Consistency token")
+
+// writeToken is what a write token carries: the item written and when.
+type writeToken struct {
+	ID        string    `json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// encodeWriteToken encodes a write token as base64url JSON. Times are cut
+// to microseconds, the precision the SQL backends keep.
+func encodeWriteToken(id string, at time.Time) string {
+	payload, _ := json.Marshal(writeToken{ID: id, UpdatedAt: at.UTC().Truncate(time.Microsecond)})
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// decodeWriteToken reads a token made by encodeWriteToken.
+func decodeWriteToken(token string) (writeToken, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return writeToken{}, errInvalidWriteToken
+	}
+	var t writeToken
+	if err := json.Unmarshal(payload, &t); err != nil || t.ID == "" {
+		return writeToken{}, errInvalidWriteToken
+	}
+	return t, nil
+}
+
+// setWriteToken sends the write token for item with READ_YOUR_WRITES=true.
+func setWriteToken(w http.ResponseWriter, item Item) {
+	if !config.ReadYourWrites {
+		return
+	}
+	at := item.UpdatedAt
+	if at.IsZero() {
+		at = item.CreatedAt
+	}
+	w.Header().Set(writeTokenHeader, encodeWriteToken(item.ID, at))
+}
+
+// checkConsistency handles X-Consistency on a read of item, with
+// READ_YOUR_WRITES=true. When the write the token stands for is not
+// visible yet, which replicated backends may lag behind on, it answers
+// 202 Accepted with Retry-After and reports false; the client should retry.
+func checkConsistency(w http.ResponseWriter, r *http.Request, item Item) bool {
+	value := r.Header.Get(consistencyHeader)
+	if !config.ReadYourWrites || value == "" {
+		return true
+	}
+	token, err := decodeWriteToken(value)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if token.ID != item.ID {
+		respondWithError(w, http.StatusBadRequest, "X-Consistency token is for another item")
+		return false
+	}
+	seen := item.UpdatedAt
+	if seen.IsZero() {
+		seen = item.CreatedAt
+	}
+	if seen.Before(token.UpdatedAt) {
+		w.Header().Set("Retry-After", "0.1")
+		respondWithJSON(w, http.StatusAccepted, map[string]string{"result": "pending", "id": item.ID})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReadYourWrites (X-Write-Token and X-Consistency)
+func TestReadYourWrites(t *testing.T) {
+	resetGlobalItems()
+	config.ReadYourWrites = true
+	defer func() { config.ReadYourWrites = false }()
+	router := newRouter()
+
+	get := func(id, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/items/"+id, nil)
+		req.Header.Set(consistencyHeader, token)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 1. A create returns a token, and reading with it sees the item
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"Fresh"}`)))
+	token := rr.Header().Get(writeTokenHeader)
+	if token == "" {
+		t.Fatal("create did not set X-Write-Token")
+	}
+	var created Item
+	json.NewDecoder(rr.Body).Decode(&created)
+	if rr := get(created.ID, token); rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+
+	// 2. So does an update
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("PUT", "/items/"+created.ID, strings.NewReader(`{"name":"Updated"}`)))
+	token = rr.Header().Get(writeTokenHeader)
+	if token == "" {
+		t.Fatal("update did not set X-Write-Token")
+	}
+	if rr := get(created.ID, token); rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	// Sub-test for "Write Not Visible Yet"
+	t.Run("Write Not Visible Yet", func(t *testing.T) {
+		rr := get("1", encodeWriteToken("1", time.Now().Add(time.Hour)))
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusAccepted)
+		}
+		if got := rr.Header().Get("Retry-After"); got != "0.1" {
+			t.Errorf("Retry-After: got %q want %q", got, "0.1")
+		}
+	})
+
+	// Sub-test for "Bad Tokens"
+	t.Run("Bad Tokens", func(t *testing.T) {
+		for _, token := range []string{"not a token", encodeWriteToken("2", time.Now())} {
+			if rr := get("1", token); rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", token, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	// Sub-test for "Delete"
+	t.Run("Delete", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/"+created.ID, nil))
+		if rr.Header().Get(writeTokenHeader) == "" {
+			t.Error("delete did not set X-Write-Token")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// robotsTxt keeps crawlers out of the admin endpoints.
+const robotsTxt = "User-agent: *\nDisallow: /admin\n"
+
+// sitemapNamespace is the XML namespace of the sitemaps.org protocol.
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapURLSet is the root element of sitemap.xml.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemapURL is one page in sitemap.xml.
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// serveRobots (GET /robots.txt)
+// This tells crawlers to stay out of /admin.
+func serveRobots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, robotsTxt)
+}
+
+// baseURL returns the scheme and host the request was made to.
+func baseURL(r *http.Request) url.URL {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return url.URL{Scheme: scheme, Host: r.Host}
+}
+
+// serveSitemap (GET /sitemap.xml)
+// This lists the URL of every item, built fresh from the current items on
+// each request. Sitemaps need absolute URLs, so they use the request's host.
+func serveSitemap(w http.ResponseWriter, r *http.Request) {
+	items, err := storeFromContext(r.Context()).GetAll(r.Context())
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+
+	base := baseURL(r)
+	set := sitemapURLSet{Xmlns: sitemapNamespace, URLs: make([]sitemapURL, len(items))}
+	for i, item := range items {
+		loc := base
+		loc.Path = "/items/" + item.ID
+		set.URLs[i] = sitemapURL{Loc: loc.String()}
+		if !item.UpdatedAt.IsZero() {
+			set.URLs[i].LastMod = item.UpdatedAt.UTC().Format("2006-01-02")
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	io.WriteString(w, xml.Header)
+	if err := xml.NewEncoder(w).Encode(set); err != nil {
+		log.Printf("failed to write sitemap: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRobots (GET /robots.txt)
+func TestRobots(t *testing.T) {
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/robots.txt", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("wrong Content-Type: %q", ct)
+	}
+	if body := rr.Body.String(); body != "User-agent: *\nDisallow: /admin\n" {
+		t.Errorf("wrong robots.txt: %q", body)
+	}
+}
+
+// TestSitemap (GET /sitemap.xml)
+func TestSitemap(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	sitemap := func() sitemapURLSet {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "http://api.example.com/sitemap.xml", nil))
+		if ct := rr.Header().Get("Content-Type"); ct != "text/xml; charset=utf-8" {
+			t.Errorf("wrong Content-Type: %q", ct)
+		}
+		var set sitemapURLSet
+		if err := xml.Unmarshal(rr.Body.Bytes(), &set); err != nil {
+			t.Fatalf("sitemap does not parse: %v\n%s", err, rr.Body)
+		}
+		return set
+	}
+
+	set := sitemap()
+	if len(set.URLs) != 2 || set.URLs[0].Loc != "http://api.example.com/items/1" {
+		t.Errorf("wrong sitemap URLs: %+v", set.URLs)
+	}
+	if set.Xmlns != sitemapNamespace {
+		t.Errorf("wrong namespace: %q", set.Xmlns)
+	}
+
+	// A new item shows up straight away
+	store.Create(t.Context(), Item{ID: "3", Name: "New"})
+	if set := sitemap(); len(set.URLs) != 3 {
+		t.Errorf("sitemap was not regenerated: got %d URLs want 3", len(set.URLs))
+	}
+}
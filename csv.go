@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader is the header row written by renderCSV.
+var csvHeader = []string{"id", "name", "description", "tags", "created_at", "updated_at", "version", "likes"}
+
+// csvVersionColumn is the index of the version column in csvHeader.
+// It is left out unless admin is set, like the Version field of JSON items.
+const csvVersionColumn = 6
+
+// csvSafe stops spreadsheet programs from running a cell as a formula.
+// Cells starting with one of = + - @ get a leading apostrophe, which they display as text.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// renderCSV writes items as CSV with a header row. Tags are joined with ";".
+// Without admin the version column is dropped, as isAdminView decides for JSON.
+func renderCSV(w io.Writer, items []Item, admin bool) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns(csvHeader, admin)); err != nil {
+		return err
+	}
+	for _, item := range items {
+		record := []string{
+			csvSafe(item.ID),
+			csvSafe(item.Name),
+			csvSafe(item.Description),
+			csvSafe(strings.Join(item.Tags, ";")),
+			item.CreatedAt.Format(time.RFC3339),
+			item.UpdatedAt.Format(time.RFC3339),
+			strconv.Itoa(item.Version),
+			strconv.Itoa(item.Likes),
+		}
+		if err := cw.Write(csvColumns(record, admin)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvColumns returns record without the version column unless admin is set.
+func csvColumns(record []string, admin bool) []string {
+	if admin {
+		return record
+	}
+	return slices.Concat(record[:csvVersionColumn], record[csvVersionColumn+1:])
+}
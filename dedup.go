@@ -0,0 +1,167 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// dedupNameHeader asks POST /items to return an existing item with the same name.
+	dedupNameHeader = "X-Dedup-Name"
+	// deduplicatedHeader marks a POST /items response that returned an existing item.
+	deduplicatedHeader = "X-Deduplicated"
+	// maxDuplicateDistance is the largest edit distance between two names
+	// that POST /items/{id}/duplicate-check still reports as similar.
+	maxDuplicateDistance = 3
+)
+
+// dedupCreateLock serialises deduplicated creates, so two retries of the same
+// create cannot both miss each other and create the item twice.
+var dedupCreateLock sync.Mutex
+
+// findByName returns the first item in namespace whose name matches name,
+// ignoring case. A NameIndex answers without a scan unless the indexed
+// item is in another namespace; dry runs always scan, to see their own items.
+func findByName(ctx context.Context, s Storage, namespace, name string) (Item, bool, error) {
+	if index, ok := findWrapper[*NameIndex](s); ok && !isDryRun(ctx) {
+		item, found := index.GetByName(strings.ToLower(name))
+		if !found {
+			return Item{}, false, nil
+		}
+		if item.Namespace == namespace {
+			return item, true, nil
+		}
+	}
+	items, err := s.GetAll(ctx)
+	if err != nil {
+		return Item{}, false, err
+	}
+	for _, item := range items {
+		if item.Namespace == namespace && strings.EqualFold(item.Name, name) {
+			return item, true, nil
+		}
+	}
+	return Item{}, false, nil
+}
+
+// levenshteinDistance returns how many single-rune insertions, deletions
+// or substitutions turn a into b.
+func levenshteinDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// prev and curr are the previous and current rows of the DP table
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// checkDuplicates (POST /items/{id}/duplicate-check)
+// This lists the other items in the item's namespace whose names are at most
+// maxDuplicateDistance edits away from its name, ignoring case, closest first
+// and then by ID:
+// {"items":[{"item":{...},"distance":1}]}.
+func checkDuplicates(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	s := storeFromContext(r.Context())
+	item, err := s.GetByID(r.Context(), id)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	items, err := s.GetAll(r.Context())
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+
+	type match struct {
+		item     Item
+		distance int
+	}
+	var matches []match
+	name := strings.ToLower(item.Name)
+	for _, other := range items {
+		if other.ID == item.ID || other.Namespace != item.Namespace {
+			continue
+		}
+		if d := levenshteinDistance(name, strings.ToLower(other.Name)); d <= maxDuplicateDistance {
+			matches = append(matches, match{other, d})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), strings.Compare(a.item.ID, b.item.ID))
+	})
+
+	results := make([]map[string]interface{}, len(matches))
+	for i, m := range matches {
+		results[i] = map[string]interface{}{"item": m.item, "distance": m.distance}
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"items": results})
+}
+
+// DuplicateGroup is a set of items in one namespace sharing a name, ignoring
+// case. Name is the spelling of the first of them.
+type DuplicateGroup struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Items     []Item `json:"items"`
+}
+
+// findDuplicateGroups groups items by namespace and case-folded name,
+// keeping the groups with more than one member. Groups and the items in
+// them keep the order of items.
+func findDuplicateGroups(items []Item) []DuplicateGroup {
+	type groupKey struct{ namespace, name string }
+	index := map[groupKey]int{}
+	var groups []DuplicateGroup
+	for _, item := range items {
+		key := groupKey{item.Namespace, strings.ToLower(item.Name)}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, DuplicateGroup{Name: item.Name, Namespace: item.Namespace})
+		}
+		groups[i].Items = append(groups[i].Items, item)
+	}
+	return slices.DeleteFunc(groups, func(g DuplicateGroup) bool { return len(g.Items) < 2 })
+}
+
+// getDuplicates (GET /items/duplicates)
+// This lists the groups of items sharing a name, for cleaning up accidental
+// duplicates. Only admins may call it, as it returns whole items.
+func getDuplicates(w http.ResponseWriter, r *http.Request) {
+	if !hasAdminToken(r) {
+		respondWithError(w, http.StatusForbidden, "duplicates are only available to admins")
+		return
+	}
+	items, err := storeFromContext(r.Context()).GetAll(r.Context())
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	groups := findDuplicateGroups(items)
+	if groups == nil {
+		groups = []DuplicateGroup{}
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"groups": groups})
+}
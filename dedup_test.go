@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestCreateItemDedupName (POST /items with X-Dedup-Name)
+func TestCreateItemDedupName(t *testing.T) {
+	store = NewMemoryStore()
+	defer resetGlobalItems()
+	router := newRouter()
+
+	create := func(body string, dedup bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(body))
+		if dedup {
+			req.Header.Set(dedupNameHeader, "true")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var first Item
+	json.NewDecoder(create(`{"name":"Retry Me"}`, false).Body).Decode(&first)
+
+	// Sub-test for "Retry Returns Existing"
+	t.Run("Retry Returns Existing", func(t *testing.T) {
+		rr := create(`{"name":"retry me"}`, true)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if rr.Header().Get(deduplicatedHeader) != "true" {
+			t.Errorf("missing %s header", deduplicatedHeader)
+		}
+		var got Item
+		json.NewDecoder(rr.Body).Decode(&got)
+		if got.ID != first.ID {
+			t.Errorf("returned a different item: got %s want %s", got.ID, first.ID)
+		}
+		if n := len(storedItems()); n != 1 {
+			t.Errorf("retry created a duplicate: %d items stored", n)
+		}
+	})
+
+	// Sub-test for "New Name Is Created"
+	t.Run("New Name Is Created", func(t *testing.T) {
+		rr := create(`{"name":"Something Else"}`, true)
+		if rr.Code != http.StatusCreated || rr.Header().Get(deduplicatedHeader) != "" {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+	})
+
+	// Sub-test for "Without Header"
+	t.Run("Without Header", func(t *testing.T) {
+		if rr := create(`{"name":"Retry Me"}`, false); rr.Code != http.StatusCreated {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		if n := len(storedItems()); n != 3 {
+			t.Errorf("wrong item count: got %d want 3", n)
+		}
+	})
+}
+
+// TestCheckDuplicates (POST /items/{id}/duplicate-check)
+func TestCheckDuplicates(t *testing.T) {
+	store = NewMemoryStore()
+	defer resetGlobalItems()
+	router := newRouter()
+	for i, name := range []string{"apple", "appel", "Apples", "apricot", "banana"} {
+		store.Create(t.Context(), Item{ID: strconv.Itoa(i + 1), Name: name})
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/1/duplicate-check", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var result struct {
+		Items []struct {
+			Item     Item `json:"item"`
+			Distance int  `json:"distance"`
+		} `json:"items"`
+	}
+	json.NewDecoder(rr.Body).Decode(&result)
+
+	// "Apples" is one insertion away, "appel" two substitutions; "apricot"
+	// (4) and "banana" (5) are too far
+	var got []string
+	for _, match := range result.Items {
+		got = append(got, match.Item.Name+"="+strconv.Itoa(match.Distance))
+	}
+	if want := "Apples=1,appel=2"; strings.Join(got, ",") != want {
+		t.Errorf("wrong duplicates: got %v want %v", got, want)
+	}
+
+	// Sub-test for "Unknown Item"
+	t.Run("Unknown Item", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/404/duplicate-check", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	// Sub-test for "Levenshtein Distance"
+	t.Run("Levenshtein Distance", func(t *testing.T) {
+		for _, tt := range []struct {
+			a, b string
+			want int
+		}{
+			{"", "", 0},
+			{"", "abc", 3},
+			{"kitten", "sitting", 3},
+			{"apple", "appel", 2},
+			{"café", "cafe", 1},
+		} {
+			if got := levenshteinDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("levenshteinDistance(%q, %q): got %d want %d", tt.a, tt.b, got, tt.want)
+			}
+		}
+	})
+}
+
+// TestGetDuplicates (GET /items/duplicates)
+func TestGetDuplicates(t *testing.T) {
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "Foo"},
+		Item{ID: "2", Name: "Bar"},
+		Item{ID: "3", Name: "foo"},
+		Item{ID: "4", Name: "BAR"},
+		Item{ID: "5", Name: "Baz"},
+		Item{ID: "6", Name: "Baz", Namespace: "other"},
+	)
+	config.AdminToken = "admin-secret"
+	defer func() {
+		config.AdminToken = ""
+		resetGlobalItems()
+	}()
+	router := newRouter()
+
+	// 1. As admin, the two pairs come back; "Baz" is in two namespaces
+	req := httptest.NewRequest("GET", "/items/duplicates", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var result struct {
+		Groups []DuplicateGroup `json:"groups"`
+	}
+	json.NewDecoder(rr.Body).Decode(&result)
+	got := map[string]string{}
+	for _, group := range result.Groups {
+		var ids []string
+		for _, item := range group.Items {
+			ids = append(ids, item.ID)
+		}
+		got[strings.ToLower(group.Name)] = strings.Join(ids, ",")
+	}
+	if len(got) != 2 || got["foo"] != "1,3" || got["bar"] != "2,4" {
+		t.Errorf("wrong groups: got %v want foo=1,3 and bar=2,4", got)
+	}
+
+	// 2. Without the admin token it is forbidden
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/duplicates", nil))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestDiffItems (POST /items/diff)
+func TestDiffItems(t *testing.T) {
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "Same", Description: "Twin", Tags: []string{"a"}},
+		Item{ID: "2", Name: "Same", Description: "Twin", Tags: []string{"a"}},
+		Item{ID: "3", Name: "Same", Description: "Twin", Tags: []string{}},
+		Item{ID: "4", Name: "Same", Description: "Twin"},
+	)
+	defer resetGlobalItems()
+	router := newRouter()
+
+	diff := func(body string) (map[string]*DiffField, int) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/diff", strings.NewReader(body)))
+		var response struct {
+			Fields map[string]*DiffField `json:"fields"`
+		}
+		json.NewDecoder(rr.Body).Decode(&response)
+		return response.Fields, rr.Code
+	}
+
+	// 1. Identical items only have null fields
+	fields, code := diff(`{"id_a":"1","id_b":"2"}`)
+	if code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", code, http.StatusOK)
+	}
+	for _, name := range []string{"name", "description", "tags", "likes"} {
+		field, ok := fields[name]
+		if !ok {
+			t.Errorf("diff is missing field %q: %+v", name, fields)
+		} else if field != nil {
+			t.Errorf("identical field %q reported as changed: %+v", name, field)
+		}
+	}
+
+	// 2. A changed field shows both values
+	req := httptest.NewRequest("PUT", "/items/2", strings.NewReader(`{"name":"Different","description":"Twin","tags":["a"]}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	fields, _ = diff(`{"id_a":"1","id_b":"2"}`)
+	if got := fields["name"]; got == nil || got.A != "Same" || got.B != "Different" {
+		t.Errorf("changed name not reported: got %+v", got)
+	}
+	if fields["description"] != nil {
+		t.Errorf("unchanged description reported as changed: %+v", fields["description"])
+	}
+
+	// 3. Nil and empty tags are the same
+	if fields, _ = diff(`{"id_a":"3","id_b":"4"}`); fields["tags"] != nil {
+		t.Errorf("nil and empty tags reported as changed: %+v", fields["tags"])
+	}
+
+	// 4. Unknown IDs are a 404
+	if _, code := diff(`{"id_a":"1","id_b":"999"}`); code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", code, http.StatusNotFound)
+	}
+}
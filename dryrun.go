@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// dryRunHeader marks responses to requests made with ?dry_run=true.
+const dryRunHeader = "X-Dry-Run"
+
+// dryRunKey is the context key marking a request as a dry run.
+type dryRunKey struct{}
+
+// isDryRun reports whether ctx belongs to a dry-run request.
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// DryRunStorage wraps a Storage and discards every write.
+// Reads go to the wrapped store, and writes are checked against it so that a
+// dry run fails exactly where the real request would.
+type DryRunStorage struct {
+	Storage
+}
+
+// Unwrap returns the wrapped Storage.
+func (d DryRunStorage) Unwrap() Storage {
+	return d.Storage
+}
+
+// Create reports what would be stored without storing it.
+func (d DryRunStorage) Create(ctx context.Context, item Item) (Item, error) {
+	if _, err := d.Storage.GetByID(ctx, item.ID); err == nil {
+		return Item{}, errDuplicateID
+	} else if !errors.Is(err, errItemNotFound) {
+		return Item{}, err
+	}
+	stampCreated(&item)
+	return item, nil
+}
+
+// Update applies fn to a copy of the stored item and returns the result.
+func (d DryRunStorage) Update(ctx context.Context, id string, fn func(*Item) error) (Item, error) {
+	item, err := d.Storage.GetByID(ctx, id)
+	if err != nil {
+		return Item{}, err
+	}
+	if err := fn(&item); err != nil {
+		return Item{}, err
+	}
+	stampUpdated(ctx, &item)
+	return item, nil
+}
+
+// CreateBatch reports what would be stored without storing anything.
+func (d DryRunStorage) CreateBatch(ctx context.Context, items []Item) ([]Item, error) {
+	seen := make(map[string]bool, len(items))
+	created := make([]Item, len(items))
+	for i, item := range items {
+		if seen[item.ID] {
+			return nil, errDuplicateID
+		}
+		seen[item.ID] = true
+		var err error
+		if created[i], err = d.Create(ctx, item); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// UpdateBatch applies fn to copies of the stored items and returns the results.
+func (d DryRunStorage) UpdateBatch(ctx context.Context, ids []string, fn func(*Item) error) ([]Item, error) {
+	updated := make([]Item, len(ids))
+	for i, id := range ids {
+		var err error
+		if updated[i], err = d.Update(ctx, id, fn); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+// Delete only checks that the item exists.
+func (d DryRunStorage) Delete(ctx context.Context, id string) error {
+	_, err := d.Storage.GetByID(ctx, id)
+	return err
+}
+
+// dryRunMethods are the methods that ?dry_run=true applies to.
+var dryRunMethods = map[string]bool{"POST": true, "PUT": true, "PATCH": true, "DELETE": true, "LINK": true, "UNLINK": true}
+
+// dryRunMiddleware lets clients validate a write with ?dry_run=true.
+// The request runs against a DryRunStorage, so the response is what the real
+// request would return, but nothing is stored and no events are published.
+// Only item endpoints are covered, because only they go through Storage.
+func dryRunMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !dryRunMethods[r.Method] || r.URL.Query().Get("dry_run") != "true" ||
+			!strings.HasPrefix(r.URL.Path, "/items") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set(dryRunHeader, "true")
+		ctx := withStore(r.Context(), DryRunStorage{storeFromContext(r.Context())})
+		ctx = context.WithValue(ctx, dryRunKey{}, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
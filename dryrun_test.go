@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDryRun checks that ?dry_run=true answers like the real request but stores nothing.
+func TestDryRun(t *testing.T) {
+	router := newRouter()
+
+	// createWithSeed runs POST /items with a seeded random source and a fixed
+	// request ID, so that a dry run and a real create build the same item.
+	createWithSeed := func(target string) *httptest.ResponseRecorder {
+		payload := []byte(`{"name":"Dry Item","description":"<b>checked</b> only"}`)
+		req := httptest.NewRequest("POST", target, bytes.NewBuffer(payload))
+		req = req.WithContext(withRand(req.Context(), rand.New(rand.NewSource(42))))
+		req.Header.Set(requestIDHeader, "dry-run-test")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Sub-test for "Create"
+	t.Run("Create", func(t *testing.T) {
+		resetGlobalItems()
+
+		dry := createWithSeed("/items?dry_run=true")
+		if dry.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", dry.Code, http.StatusCreated)
+		}
+		if got := dry.Header().Get(dryRunHeader); got != "true" {
+			t.Errorf("%s header: got %q want %q", dryRunHeader, got, "true")
+		}
+		if len(storedItems()) != 2 {
+			t.Fatalf("dry run stored an item: %+v", storedItems())
+		}
+
+		real := createWithSeed("/items")
+		if real.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", real.Code, http.StatusCreated)
+		}
+		if real.Header().Get(dryRunHeader) != "" {
+			t.Errorf("real create was marked as a dry run")
+		}
+
+		// The responses only differ in the timestamps
+		var dryItem, realItem Item
+		json.Unmarshal(dry.Body.Bytes(), &dryItem)
+		json.Unmarshal(real.Body.Bytes(), &realItem)
+		if dryItem.CreatedAt.IsZero() {
+			t.Error("dry run response has no created_at")
+		}
+		if dryItem.Version != 1 {
+			t.Errorf("dry run response has wrong version: got %d want %d", dryItem.Version, 1)
+		}
+		dryItem.CreatedAt, realItem.CreatedAt = time.Time{}, time.Time{}
+		dryItem.UpdatedAt, realItem.UpdatedAt = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(dryItem, realItem) {
+			t.Errorf("dry run response differs from real create: got %+v want %+v", dryItem, realItem)
+		}
+	})
+
+	// Sub-test for "Update"
+	t.Run("Update", func(t *testing.T) {
+		resetGlobalItems()
+
+		payload := []byte(`{"name":"Renamed","description":"changed"}`)
+		req := httptest.NewRequest("PUT", "/items/1?dry_run=true", bytes.NewBuffer(payload))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var item Item
+		json.Unmarshal(rr.Body.Bytes(), &item)
+		if item.Name != "Renamed" {
+			t.Errorf("dry run did not return the updated item: got %+v", item)
+		}
+		if stored := storedItems()[0]; stored.Name != "Mock Item 1" {
+			t.Errorf("dry run changed the stored item: got %+v", stored)
+		}
+	})
+
+	// Sub-test for "Delete"
+	t.Run("Delete", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/1?dry_run=true", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if len(storedItems()) != 2 {
+			t.Errorf("dry run deleted an item: %+v", storedItems())
+		}
+
+		// Errors are reported just like a real delete would report them
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/999?dry_run=true", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+// Item lifecycle event types.
+const (
+	eventItemCreated = "item.created"
+	eventItemUpdated = "item.updated"
+	eventItemDeleted = "item.deleted"
+)
+
+// ItemEvent describes a successful change to an item.
+// Deleted events only carry the ID of the removed item.
+type ItemEvent struct {
+	Type string `json:"type"`
+	Item Item   `json:"item"`
+}
+
+// publishItemEvent announces an item change to every event subscriber.
+// It never blocks: events go through the message queue when one is running.
+// The change feed gets every event directly, so its cursors follow write order.
+func publishItemEvent(eventType string, item Item) {
+	event := ItemEvent{Type: eventType, Item: item}
+	feed.Append(event)
+	if events == nil {
+		hub.Publish(event)
+		return
+	}
+	events.Publish(Message{Event: event, PublishedAt: time.Now()})
+}
+
+// recordItemChange bumps Last-Modified, publishes an event and updates the
+// name length metric and items_total after a successful write.
+// Dry runs change nothing, so they record nothing either.
+func recordItemChange(ctx context.Context, eventType string, item Item) {
+	if isDryRun(ctx) {
+		return
+	}
+	touchLastModified()
+	if eventType != eventItemDeleted {
+		metrics.nameLength.observe(int64(utf8.RuneCountInString(item.Name)))
+	}
+	if eventType == eventItemCreated {
+		itemsTotal.Add(1)
+	}
+	publishItemEvent(eventType, item)
+}
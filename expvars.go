@@ -0,0 +1,26 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// Counters published through expvar at GET /debug/vars when ENABLE_DEBUG=true.
+// They count from process start, and tests may reset them with Set(0).
+var (
+	// itemsTotal counts the items created, one by one, in bulk or by import.
+	itemsTotal = expvar.NewInt("items_total")
+	// requestsTotal counts the requests that matched a route.
+	requestsTotal = expvar.NewInt("requests_total")
+	// cacheHits and cacheMisses count CachedStore.GetByID lookups.
+	cacheHits   = expvar.NewInt("cache_hits")
+	cacheMisses = expvar.NewInt("cache_misses")
+)
+
+// requestCountMiddleware adds every request to requests_total.
+func requestCountMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsTotal.Add(1)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestExpvarCounters (GET /debug/vars with ENABLE_DEBUG=true)
+func TestExpvarCounters(t *testing.T) {
+	resetGlobalItems()
+	store = NewCachedStore(store, 10)
+	config.EnableDebug = true
+	defer func() { config.EnableDebug = false }()
+	for _, v := range []interface{ Set(int64) }{itemsTotal, requestsTotal, cacheHits, cacheMisses} {
+		v.Set(0)
+	}
+	router := newRouter()
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+	for _, name := range []string{"One", "Two"} {
+		if rr := serve("POST", "/items", `{"name":"`+name+`"}`); rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+	}
+	// The first read of item 1 misses the cache, the second hits it
+	serve("GET", "/items/1", "")
+	serve("GET", "/items/1", "")
+
+	rr := serve("GET", "/debug/vars", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var vars map[string]interface{}
+	if err := json.NewDecoder(rr.Body).Decode(&vars); err != nil {
+		t.Fatalf("/debug/vars is not JSON: %v", err)
+	}
+	want := map[string]float64{"items_total": 2, "requests_total": 5, "cache_hits": 1, "cache_misses": 1}
+	for name, value := range want {
+		if vars[name] != value {
+			t.Errorf("wrong %s: got %v want %v", name, vars[name], value)
+		}
+	}
+
+	// Sub-test for "Disabled By Default"
+	t.Run("Disabled By Default", func(t *testing.T) {
+		config.EnableDebug = false
+		rr := httptest.NewRecorder()
+		newRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/debug/vars", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// FeaturedList is the curated, ordered list of item IDs GET /items/featured serves.
+type FeaturedList struct {
+	mu  sync.RWMutex
+	ids []string
+}
+
+// featured holds the featured items used by the handlers.
+var featured = &FeaturedList{}
+
+// IDs returns the featured item IDs in order.
+func (f *FeaturedList) IDs() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.Clone(f.ids)
+}
+
+// Set replaces the featured list.
+func (f *FeaturedList) Set(ids []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ids = slices.Clone(ids)
+}
+
+// Remove takes id off the featured list and reports whether it was on it.
+func (f *FeaturedList) Remove(id string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.Index(f.ids, id)
+	if i < 0 {
+		return false
+	}
+	f.ids = slices.Delete(f.ids, i, i+1)
+	return true
+}
+
+// getFeaturedItems (GET /items/featured)
+// This returns the featured items in the order they were listed. IDs of
+// items that have since been deleted are skipped.
+func getFeaturedItems(w http.ResponseWriter, r *http.Request) {
+	s := storeFromContext(r.Context())
+	items := []Item{}
+	for _, id := range featured.IDs() {
+		item, err := s.GetByID(r.Context(), id)
+		if errors.Is(err, errItemNotFound) {
+			continue
+		}
+		if err != nil {
+			respondWithStorageError(w, err)
+			return
+		}
+		items = append(items, item)
+	}
+	respondWithJSONP(w, r, http.StatusOK, items)
+}
+
+// setFeaturedItems (POST /admin/featured)
+// This replaces the featured list with {"ids": ["id1", "id2"]}. The IDs
+// are not checked; unknown ones are skipped when the list is served.
+func setFeaturedItems(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.IDs == nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	var ids []string
+	for _, id := range request.IDs {
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	featured.Set(ids)
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"ids": featured.IDs()})
+}
+
+// unfeatureItem (DELETE /admin/featured/{id})
+// This takes an item off the featured list.
+func unfeatureItem(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !featured.Remove(id) {
+		respondWithError(w, http.StatusNotFound, "Item is not featured")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"ids": featured.IDs()})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestFeaturedItems (POST /admin/featured, GET /items/featured, DELETE /admin/featured/{id})
+func TestFeaturedItems(t *testing.T) {
+	resetGlobalItems()
+	featured = &FeaturedList{}
+	config.AdminToken = "admin-secret"
+	defer func() { config.AdminToken = "" }()
+	store.Create(t.Context(), Item{ID: "3", Name: "Third"})
+	router := newRouter()
+
+	admin := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	getFeatured := func() []Item {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/featured", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		return items
+	}
+	ids := func(items []Item) string {
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	// Sub-test for "Declared Order"
+	t.Run("Declared Order", func(t *testing.T) {
+		if rr := admin("POST", "/admin/featured", `{"ids":["3","1","2"]}`); rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if got := ids(getFeatured()); got != "3,1,2" {
+			t.Errorf("wrong featured items: got %s want 3,1,2", got)
+		}
+	})
+
+	// Sub-test for "Deleted Item Skipped"
+	t.Run("Deleted Item Skipped", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/1", nil))
+		if got := ids(getFeatured()); got != "3,2" {
+			t.Errorf("wrong featured items: got %s want 3,2", got)
+		}
+	})
+
+	// Sub-test for "Unfeature"
+	t.Run("Unfeature", func(t *testing.T) {
+		if rr := admin("DELETE", "/admin/featured/3", ""); rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if rr := admin("DELETE", "/admin/featured/3", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+		if got := ids(getFeatured()); got != "2" {
+			t.Errorf("wrong featured items: got %s want 2", got)
+		}
+	})
+
+	// Sub-test for "Requires Admin Token"
+	t.Run("Requires Admin Token", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/admin/featured", strings.NewReader(`{"ids":[]}`)))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnauthorized)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// feedCapacity is how many recent events the change feed keeps for pollers.
+	feedCapacity = 1000
+	// defaultFeedTimeout and maxFeedTimeout bound how long GET /feed waits.
+	defaultFeedTimeout = 30 * time.Second
+	maxFeedTimeout     = 60 * time.Second
+)
+
+// FeedEvent is an item event with its position in the change feed.
+type FeedEvent struct {
+	Cursor int64 `json:"cursor"`
+	ItemEvent
+}
+
+// ChangeFeed keeps the most recent item events for long-polling clients.
+// Every event gets the next cursor, so cursors only ever go up and a gap
+// between them tells a client that it fell behind and missed events.
+type ChangeFeed struct {
+	mu      sync.Mutex
+	events  []FeedEvent   // oldest first, at most feedCapacity
+	last    int64         // cursor of the newest event
+	changed chan struct{} // closed and replaced whenever an event is added
+}
+
+// NewChangeFeed returns an empty ChangeFeed.
+func NewChangeFeed() *ChangeFeed {
+	return &ChangeFeed{changed: make(chan struct{})}
+}
+
+// feed is the ChangeFeed that item events are appended to and /feed serves.
+var feed = NewChangeFeed()
+
+// Append adds an event and wakes every waiting poller.
+func (f *ChangeFeed) Append(event ItemEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last++
+	f.events = append(f.events, FeedEvent{Cursor: f.last, ItemEvent: event})
+	if len(f.events) > feedCapacity {
+		f.events = append([]FeedEvent(nil), f.events[len(f.events)-feedCapacity:]...)
+	}
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Cursor returns the cursor of the newest event, 0 before the first one.
+func (f *ChangeFeed) Cursor() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last
+}
+
+// since returns the kept events after cursor, plus a channel that is closed
+// when the next event arrives.
+func (f *ChangeFeed) since(cursor int64) ([]FeedEvent, <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var events []FeedEvent
+	for i, event := range f.events {
+		if event.Cursor > cursor {
+			events = append(events, f.events[i:]...)
+			break
+		}
+	}
+	return events, f.changed
+}
+
+// Wait returns the events after cursor, waiting up to timeout for one to
+// arrive if there are none yet. done ends the wait early.
+func (f *ChangeFeed) Wait(cursor int64, timeout time.Duration, done <-chan struct{}) []FeedEvent {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		events, changed := f.since(cursor)
+		if len(events) > 0 {
+			return events
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return []FeedEvent{}
+		case <-done:
+			return []FeedEvent{}
+		}
+	}
+}
+
+// getFeed (GET /feed?since_cursor=N&timeout=30)
+// This long-polls for item events after since_cursor, answering as soon as
+// there is one or with no events after timeout seconds (at most 60).
+// Without since_cursor only events from now on are returned.
+// Clients pass next_cursor back as since_cursor on their next poll.
+func getFeed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cursor := feed.Cursor()
+	if value := query.Get("since_cursor"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			respondWithError(w, http.StatusBadRequest, "since_cursor must be a non-negative integer")
+			return
+		}
+		// A cursor from before a restart can be ahead of the feed; start from now
+		cursor = min(n, cursor)
+	}
+	timeout := defaultFeedTimeout
+	if value := query.Get("timeout"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			respondWithError(w, http.StatusBadRequest, "timeout must be a non-negative number of seconds")
+			return
+		}
+		timeout = min(time.Duration(n)*time.Second, maxFeedTimeout)
+	}
+
+	events := feed.Wait(cursor, timeout, r.Context().Done())
+	next := cursor
+	if len(events) > 0 {
+		next = events[len(events)-1].Cursor
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"events": events, "next_cursor": next})
+}
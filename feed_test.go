@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// feedResponse is the body of GET /feed.
+type feedResponse struct {
+	Events     []FeedEvent `json:"events"`
+	NextCursor int64       `json:"next_cursor"`
+}
+
+// TestFeedLongPoll checks that a waiting poll returns as soon as an item is created.
+func TestFeedLongPoll(t *testing.T) {
+	resetGlobalItems()
+	feed = NewChangeFeed()
+	router := newRouter()
+
+	done := make(chan feedResponse)
+	go func() {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/feed?since_cursor=0&timeout=30", nil))
+		var body feedResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		done <- body
+	}()
+
+	time.Sleep(50 * time.Millisecond) // let the poll start waiting
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", bytes.NewBufferString(`{"name":"Fed"}`)))
+
+	var body feedResponse
+	select {
+	case body = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("long poll did not return within 1s of the item being created")
+	}
+	if len(body.Events) != 1 || body.Events[0].Type != eventItemCreated || body.Events[0].Item.Name != "Fed" {
+		t.Fatalf("wrong events: %+v", body.Events)
+	}
+	if body.NextCursor != body.Events[0].Cursor {
+		t.Errorf("next_cursor %d does not match the last event's cursor %d", body.NextCursor, body.Events[0].Cursor)
+	}
+
+	// Polling again from next_cursor times out with no events
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/feed?timeout=0&since_cursor="+strconv.FormatInt(body.NextCursor, 10), nil))
+	var again feedResponse
+	json.NewDecoder(rr.Body).Decode(&again)
+	if again.Events == nil || len(again.Events) != 0 || again.NextCursor != body.NextCursor {
+		t.Errorf("wrong response after a timeout: %+v", again)
+	}
+}
+
+// TestChangeFeedCapacity checks that only the newest events are kept.
+func TestChangeFeedCapacity(t *testing.T) {
+	f := NewChangeFeed()
+	for i := 0; i < feedCapacity+5; i++ {
+		f.Append(ItemEvent{Type: eventItemUpdated})
+	}
+	events := f.Wait(0, 0, nil)
+	if len(events) != feedCapacity || events[0].Cursor != 6 {
+		t.Errorf("wrong events kept: %d events starting at cursor %d", len(events), events[0].Cursor)
+	}
+}
+
+// TestFeedBadParams checks that invalid query parameters are rejected.
+func TestFeedBadParams(t *testing.T) {
+	for _, target := range []string{"/feed?timeout=soon", "/feed?since_cursor=-1"} {
+		rr := httptest.NewRecorder()
+		getFeed(rr, httptest.NewRequest("GET", target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", target, rr.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// purger is a Storage that soft-deletes items and can remove them for good.
+type purger interface {
+	Storage
+	// PurgeDeleted removes every soft-deleted item and returns how many there were.
+	PurgeDeleted(ctx context.Context) (int, error)
+}
+
+// collectGarbage (POST /admin/gc)
+// This removes soft-deleted items from the backend for good, whatever their
+// age. The SQL backends only mark deleted rows; the memory backend drops
+// items right away, so there is never anything to purge from it.
+func collectGarbage(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	// Admin requests are not pinned to a store, so keep a migration from swapping it mid-purge
+	storeWriteLock.RLock()
+	defer storeWriteLock.RUnlock()
+	store := currentStore()
+
+	purged := 0
+	if p, ok := findWrapper[purger](store); ok {
+		n, err := p.PurgeDeleted(r.Context())
+		if err != nil {
+			respondWithStorageError(w, err)
+			return
+		}
+		purged = n
+	}
+	items, err := store.GetAll(r.Context())
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("Purged %d deleted items", purged)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"purged":     purged,
+		"remaining":  len(items),
+		"elapsed_ms": time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// TestCollectGarbage (POST /admin/gc)
+func TestCollectGarbage(t *testing.T) {
+	config.AdminToken = "admin-secret"
+	defer func() {
+		config.AdminToken = ""
+		resetGlobalItems()
+	}()
+	sqlite, err := NewSQLiteStore(filepath.Join(t.TempDir(), "items.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close()
+	store = NewHistoryStore(NewTracedStore(sqlite))
+	router := newRouter()
+
+	gc := func() map[string]float64 {
+		req := httptest.NewRequest("POST", "/admin/gc", nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		var result map[string]float64
+		json.NewDecoder(rr.Body).Decode(&result)
+		return result
+	}
+	storedRows := func() int {
+		var n int
+		sqlite.db.QueryRow("SELECT COUNT(*) FROM items").Scan(&n)
+		return n
+	}
+
+	// 1. Create 10 items and soft-delete 3 of them
+	for i := 1; i <= 10; i++ {
+		store.Create(t.Context(), Item{ID: strconv.Itoa(i), Name: "Item " + strconv.Itoa(i)})
+	}
+	for _, id := range []string{"2", "5", "7"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/"+id, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	}
+	if n := storedRows(); n != 10 {
+		t.Fatalf("soft-deleted rows should still be stored: got %d rows want 10", n)
+	}
+
+	// 2. Purge them
+	if result := gc(); result["purged"] != 3 || result["remaining"] != 7 {
+		t.Errorf("wrong gc result: got %v want purged 3 and remaining 7", result)
+	}
+	if n := storedRows(); n != 7 {
+		t.Errorf("deleted rows were not purged: got %d rows want 7", n)
+	}
+	if _, err := store.Create(t.Context(), Item{ID: "5", Name: "Reused"}); err != nil {
+		t.Errorf("a purged ID should be free again: %v", err)
+	}
+
+	// 3. A second run finds nothing left to purge
+	if result := gc(); result["purged"] != 0 || result["remaining"] != 8 {
+		t.Errorf("wrong second gc result: got %v want purged 0 and remaining 8", result)
+	}
+
+	// Sub-test for "Memory Store"
+	t.Run("Memory Store", func(t *testing.T) {
+		resetGlobalItems()
+		if result := gc(); result["purged"] != 0 || result["remaining"] != 2 {
+			t.Errorf("wrong gc result: got %v want purged 0 and remaining 2", result)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultGraphDepth is how many hops GET /items/{id}/graph follows without ?depth=.
+	defaultGraphDepth = 1
+	// maxGraphDepth caps ?depth= so one request cannot walk a huge graph.
+	maxGraphDepth = 10
+)
+
+// GraphResponse is the part of the link graph reachable from an item.
+type GraphResponse struct {
+	Nodes []Item     `json:"nodes"`
+	Edges []ItemLink `json:"edges"`
+}
+
+// buildGraph walks the links breadth-first from startID for up to maxDepth
+// hops. Each item is visited once, so cycles end the walk instead of looping.
+// Links to items missing from s are left out. The start item is always the
+// first node; it returns errItemNotFound if that item does not exist.
+func buildGraph(ctx context.Context, startID string, maxDepth int, s Storage, ls *LinkStore) (GraphResponse, error) {
+	start, err := s.GetByID(ctx, startID)
+	if err != nil {
+		return GraphResponse{}, err
+	}
+	graph := GraphResponse{Nodes: []Item{start}, Edges: []ItemLink{}}
+	visited := map[string]bool{startID: true}
+	frontier := []string{startID}
+	for depth := 0; depth < maxDepth && len(frontier) > 0; depth++ {
+		var next []string
+		for _, id := range frontier {
+			for _, l := range ls.List(id, "") {
+				if !visited[l.TargetID] {
+					target, err := s.GetByID(ctx, l.TargetID)
+					if errors.Is(err, errItemNotFound) {
+						continue
+					}
+					if err != nil {
+						return GraphResponse{}, err
+					}
+					visited[l.TargetID] = true
+					graph.Nodes = append(graph.Nodes, target)
+					next = append(next, l.TargetID)
+				}
+				graph.Edges = append(graph.Edges, l)
+			}
+		}
+		frontier = next
+	}
+	return graph, nil
+}
+
+// getItemGraph (GET /items/{id}/graph)
+// This returns the items reachable from the item over its links, up to
+// ?depth= hops away (default 1, at most 10), and the links between them.
+func getItemGraph(w http.ResponseWriter, r *http.Request) {
+	depth := defaultGraphDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxGraphDepth {
+			respondWithError(w, http.StatusBadRequest, "depth must be between 1 and 10")
+			return
+		}
+		depth = n
+	}
+	graph, err := buildGraph(r.Context(), mux.Vars(r)["id"], depth, storeFromContext(r.Context()), links)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	withViewCounts(graph.Nodes)
+	withScores(graph.Nodes)
+	respondWithJSON(w, http.StatusOK, graph)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestItemGraph (GET /items/{id}/graph)
+func TestItemGraph(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+	for _, item := range []Item{{ID: "A", Name: "A"}, {ID: "B", Name: "B"}, {ID: "C", Name: "C"}} {
+		store.Create(t.Context(), item)
+	}
+
+	nodeIDs := func(path string) (string, int) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var graph GraphResponse
+		json.NewDecoder(rr.Body).Decode(&graph)
+		var ids []string
+		for _, node := range graph.Nodes {
+			ids = append(ids, node.ID)
+		}
+		return strings.Join(ids, ","), len(graph.Edges)
+	}
+
+	// Sub-test for "Chain"
+	t.Run("Chain", func(t *testing.T) {
+		links = NewLinkStore()
+		links.Add(ItemLink{SourceID: "A", TargetID: "B", RelationType: "next"})
+		links.Add(ItemLink{SourceID: "B", TargetID: "C", RelationType: "next"})
+
+		if got, edges := nodeIDs("/items/A/graph?depth=1"); got != "A,B" || edges != 1 {
+			t.Errorf("wrong graph at depth 1: got nodes %s and %d edges want A,B and 1", got, edges)
+		}
+		if got, edges := nodeIDs("/items/A/graph?depth=2"); got != "A,B,C" || edges != 2 {
+			t.Errorf("wrong graph at depth 2: got nodes %s and %d edges want A,B,C and 2", got, edges)
+		}
+	})
+
+	// Sub-test for "Cycle"
+	t.Run("Cycle", func(t *testing.T) {
+		links = NewLinkStore()
+		links.Add(ItemLink{SourceID: "A", TargetID: "B", RelationType: "next"})
+		links.Add(ItemLink{SourceID: "B", TargetID: "A", RelationType: "next"})
+
+		if got, edges := nodeIDs("/items/A/graph?depth=5"); got != "A,B" || edges != 2 {
+			t.Errorf("wrong graph for a cycle: got nodes %s and %d edges want A,B and 2", got, edges)
+		}
+	})
+
+	// Sub-test for "Invalid Depth"
+	t.Run("Invalid Depth", func(t *testing.T) {
+		for _, depth := range []string{"0", "11", "x"} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/A/graph?depth="+depth, nil))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("depth=%s: handler returned wrong status code: got %v want %v", depth, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds how long GET /health waits for all of its checks.
+const healthCheckTimeout = 3 * time.Second
+
+// Health statuses, from best to worst.
+const (
+	healthOK        = "ok"
+	healthDegraded  = "degraded"
+	healthUnhealthy = "unhealthy"
+)
+
+// healthRank orders the statuses so the overall status can be the worst one.
+var healthRank = map[string]int{healthOK: 0, healthDegraded: 1, healthUnhealthy: 2}
+
+// HealthResult is the outcome of checking a single component.
+type HealthResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthChecker is implemented by components that GET /health should report on.
+// Check must return once ctx is done.
+type HealthChecker interface {
+	Check(ctx context.Context) HealthResult
+}
+
+// healthCheckFunc adapts a plain function to a HealthChecker.
+type healthCheckFunc func(ctx context.Context) HealthResult
+
+func (f healthCheckFunc) Check(ctx context.Context) HealthResult {
+	return f(ctx)
+}
+
+// healthChecks holds the components reported by GET /health next to storage,
+// keyed by the name they appear under. Register them before serving.
+var healthChecks = map[string]HealthChecker{}
+
+// pinger is implemented by storage backends that can check their connection.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// checkStorage reports on the storage backend the request was pinned to.
+// Without storage nothing can be served, so a failure is unhealthy.
+func checkStorage(ctx context.Context) HealthResult {
+	if p, ok := storeFromContext(ctx).(pinger); ok {
+		if err := p.Ping(ctx); err != nil {
+			log.Printf("health check failed: %v", err)
+			return HealthResult{Status: healthUnhealthy, Error: "storage unavailable"}
+		}
+	}
+	return HealthResult{Status: healthOK}
+}
+
+// healthResponse is the body of GET /health.
+type healthResponse struct {
+	Status string                  `json:"status"`
+	Checks map[string]HealthResult `json:"checks"`
+}
+
+// healthCheck (GET /health)
+// This runs every component check in parallel and reports each result.
+// The overall status is the worst of them; only "unhealthy" answers 503.
+func healthCheck(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	checkers := map[string]HealthChecker{"storage": healthCheckFunc(checkStorage)}
+	for name, checker := range healthChecks {
+		checkers[name] = checker
+	}
+
+	response := healthResponse{Status: healthOK, Checks: make(map[string]HealthResult, len(checkers))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker HealthChecker) {
+			defer wg.Done()
+			result := checker.Check(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			response.Checks[name] = result
+			if healthRank[result.Status] > healthRank[response.Status] {
+				response.Status = result.Status
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if response.Status == healthUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, code, response)
+}
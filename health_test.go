@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// failingPingStore is a MemoryStore whose Ping always fails.
+type failingPingStore struct {
+	*MemoryStore
+}
+
+func (failingPingStore) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+// TestHealthCheck (GET /health)
+func TestHealthCheck(t *testing.T) {
+	// Sub-test for "Healthy"
+	t.Run("Healthy", func(t *testing.T) {
+		store = newTestSQLiteStore(t)
+
+		rr := httptest.NewRecorder()
+		healthCheck(rr, httptest.NewRequest("GET", "/health", nil))
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusOK)
+		}
+		var body healthResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.Status != healthOK {
+			t.Errorf("handler returned wrong status: got %q want %q", body.Status, healthOK)
+		}
+		if body.Checks["storage"].Status != healthOK {
+			t.Errorf("storage check: got %+v", body.Checks["storage"])
+		}
+	})
+
+	// Sub-test for "Ping Fails"
+	t.Run("Ping Fails", func(t *testing.T) {
+		store = failingPingStore{NewMemoryStore()}
+
+		rr := httptest.NewRecorder()
+		healthCheck(rr, httptest.NewRequest("GET", "/health", nil))
+
+		if status := rr.Code; status != http.StatusServiceUnavailable {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusServiceUnavailable)
+		}
+		var body healthResponse
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.Status != healthUnhealthy {
+			t.Errorf("handler returned wrong status: got %q want %q", body.Status, healthUnhealthy)
+		}
+		if body.Checks["storage"].Error != "storage unavailable" {
+			t.Errorf("storage check: got %+v", body.Checks["storage"])
+		}
+	})
+}
+
+// TestHealthCheckComponents checks that a failing component degrades the overall status.
+func TestHealthCheckComponents(t *testing.T) {
+	resetGlobalItems()
+	healthChecks["cache"] = healthCheckFunc(func(ctx context.Context) HealthResult {
+		return HealthResult{Status: healthDegraded, Error: "redis timeout"}
+	})
+	defer delete(healthChecks, "cache")
+
+	rr := httptest.NewRecorder()
+	healthCheck(rr, httptest.NewRequest("GET", "/health", nil))
+
+	// A degraded API is still serving requests
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+	var body healthResponse
+	json.NewDecoder(rr.Body).Decode(&body)
+	if body.Status != healthDegraded {
+		t.Errorf("handler returned wrong status: got %q want %q", body.Status, healthDegraded)
+	}
+	if got := body.Checks["cache"]; got.Status != healthDegraded || got.Error != "redis timeout" {
+		t.Errorf("cache check: got %+v", got)
+	}
+	if got := body.Checks["storage"]; got.Status != healthOK {
+		t.Errorf("storage check: got %+v", got)
+	}
+}
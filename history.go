@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxHistoryEntries is how many previous versions are kept per item.
+const maxHistoryEntries = 20
+
+// HistoricalItem is a snapshot of an item as it was before an update.
+type HistoricalItem struct {
+	Item
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// HistoryStore wraps a Storage and remembers the previous versions of every
+// item it updates. History is kept in memory whatever the wrapped backend,
+// so it starts empty after a restart.
+type HistoryStore struct {
+	Storage
+
+	mu      sync.Mutex
+	history map[string][]HistoricalItem // oldest first, at most maxHistoryEntries
+}
+
+// NewHistoryStore returns s with version history enabled.
+func NewHistoryStore(s Storage) *HistoryStore {
+	return &HistoryStore{Storage: s, history: make(map[string][]HistoricalItem)}
+}
+
+// unwrapper is implemented by Storage wrappers that can hand out the store they wrap.
+type unwrapper interface {
+	Unwrap() Storage
+}
+
+// findWrapper finds the first Storage of type T in a chain of Storage wrappers.
+func findWrapper[T Storage](s Storage) (T, bool) {
+	for {
+		if found, ok := s.(T); ok {
+			return found, true
+		}
+		u, ok := s.(unwrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		s = u.Unwrap()
+	}
+}
+
+// historyOf finds the HistoryStore in a chain of Storage wrappers.
+func historyOf(s Storage) (*HistoryStore, bool) {
+	return findWrapper[*HistoryStore](s)
+}
+
+// Unwrap returns the wrapped Storage.
+func (h *HistoryStore) Unwrap() Storage {
+	return h.Storage
+}
+
+// Ping checks the wrapped backend, if it can be checked.
+func (h *HistoryStore) Ping(ctx context.Context) error {
+	if p, ok := h.Storage.(pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// record keeps a snapshot, dropping the oldest one beyond maxHistoryEntries.
+func (h *HistoryStore) record(before Item) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := append(h.history[before.ID], HistoricalItem{Item: before, ReplacedAt: time.Now().UTC()})
+	if len(entries) > maxHistoryEntries {
+		entries = slices.Clone(entries[len(entries)-maxHistoryEntries:])
+	}
+	h.history[before.ID] = entries
+}
+
+// snapshot wraps fn so that the item is copied just before fn changes it.
+func snapshot(fn func(*Item) error, before map[string]Item) func(*Item) error {
+	return func(item *Item) error {
+		copied := *item
+		copied.Tags = slices.Clone(item.Tags)
+		before[item.ID] = copied
+		return fn(item)
+	}
+}
+
+// Update updates the item and records the version it replaced. Counter
+// updates are not new versions, so they leave no history entry.
+func (h *HistoryStore) Update(ctx context.Context, id string, fn func(*Item) error) (Item, error) {
+	before := make(map[string]Item, 1)
+	item, err := h.Storage.Update(ctx, id, snapshot(fn, before))
+	if err != nil {
+		return Item{}, err
+	}
+	if !isCounterUpdate(ctx) {
+		h.record(before[id])
+	}
+	return item, nil
+}
+
+// UpdateBatch updates the items and records the versions they replaced.
+func (h *HistoryStore) UpdateBatch(ctx context.Context, ids []string, fn func(*Item) error) ([]Item, error) {
+	before := make(map[string]Item, len(ids))
+	items, err := h.Storage.UpdateBatch(ctx, ids, snapshot(fn, before))
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		h.record(before[id])
+	}
+	return items, nil
+}
+
+// Delete removes the item together with its history.
+func (h *HistoryStore) Delete(ctx context.Context, id string) error {
+	if err := h.Storage.Delete(ctx, id); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.history, id)
+	return nil
+}
+
+// History returns the previous versions of an item, newest first.
+func (h *HistoryStore) History(ctx context.Context, id string) ([]HistoricalItem, error) {
+	if _, err := h.Storage.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := slices.Clone(h.history[id])
+	slices.Reverse(entries)
+	if entries == nil {
+		entries = []HistoricalItem{}
+	}
+	return entries, nil
+}
+
+// errVersionNotFound is returned when a rollback names a version that is not in the history.
+var errVersionNotFound = errors.New("version not found in history")
+
+// itemHistory returns the HistoryStore behind the request, answering 501 if there is none.
+func itemHistory(w http.ResponseWriter, r *http.Request) (*HistoryStore, bool) {
+	h, ok := historyOf(storeFromContext(r.Context()))
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "item history is not enabled")
+	}
+	return h, ok
+}
+
+// getItemHistory (GET /items/{id}/history)
+// This lists the previous versions of an item, newest first.
+func getItemHistory(w http.ResponseWriter, r *http.Request) {
+	h, ok := itemHistory(w, r)
+	if !ok {
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+
+	entries, err := h.History(r.Context(), id)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, entries)
+}
+
+// rollbackItem (POST /items/{id}/history/rollback?version=N)
+// This restores the writable fields of a previous version. The rollback is
+// itself an update, so it gets a new version number and can be undone.
+func rollbackItem(w http.ResponseWriter, r *http.Request) {
+	h, ok := itemHistory(w, r)
+	if !ok {
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+
+	version, err := strconv.Atoi(r.URL.Query().Get("version"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "version must be an integer")
+		return
+	}
+	entries, err := h.History(r.Context(), id)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	i := slices.IndexFunc(entries, func(entry HistoricalItem) bool { return entry.Version == version })
+	if i < 0 {
+		respondWithError(w, http.StatusNotFound, errVersionNotFound.Error())
+		return
+	}
+	old := entries[i].Item
+
+	// Go through the request's store so that dry runs stay dry
+	item, err := storeFromContext(r.Context()).Update(r.Context(), id, func(item *Item) error {
+		item.Name = old.Name
+		item.Description = old.Description
+		item.Tags = old.Tags
+		return nil
+	})
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemUpdated, item)
+
+	respondWithJSON(w, http.StatusOK, item)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestItemHistory covers GET /items/{id}/history and rollback.
+func TestItemHistory(t *testing.T) {
+	router := newRouter()
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, bytes.NewBufferString(body)))
+		return rr
+	}
+	withHistory := func() {
+		resetGlobalItems()
+		store = NewHistoryStore(store)
+	}
+
+	// Sub-test for "History And Rollback"
+	t.Run("History And Rollback", func(t *testing.T) {
+		withHistory()
+		for i := 1; i <= 3; i++ {
+			body := fmt.Sprintf(`{"name":"Name %d","description":"Description %d"}`, i, i)
+			if rr := do("PUT", "/items/1", body); rr.Code != http.StatusOK {
+				t.Fatalf("update %d failed: %v", i, rr.Code)
+			}
+		}
+
+		rr := do("GET", "/items/1/history", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var entries []HistoricalItem
+		json.NewDecoder(rr.Body).Decode(&entries)
+		if len(entries) != 3 {
+			t.Fatalf("history has wrong number of entries: got %d want %d", len(entries), 3)
+		}
+		// Newest first: the version replaced by the last update comes first
+		if entries[0].Version != 3 || entries[2].Version != 1 || entries[2].Name != "Mock Item 1" {
+			t.Errorf("history is in the wrong order: %+v", entries)
+		}
+
+		rr = do("POST", "/items/1/history/rollback?version=1", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		current := storedItems()[0]
+		if current.Name != "Mock Item 1" || current.Description != "First mock item" {
+			t.Errorf("rollback did not restore version 1: got %+v", current)
+		}
+		if current.Version != 5 {
+			t.Errorf("rollback should be a new version: got %d want %d", current.Version, 5)
+		}
+	})
+
+	// Sub-test for "History Is Capped"
+	t.Run("History Is Capped", func(t *testing.T) {
+		withHistory()
+		for i := 0; i < maxHistoryEntries+5; i++ {
+			do("PUT", "/items/1", fmt.Sprintf(`{"name":"Name %d"}`, i))
+		}
+
+		var entries []HistoricalItem
+		json.NewDecoder(do("GET", "/items/1/history", "").Body).Decode(&entries)
+		if len(entries) != maxHistoryEntries {
+			t.Fatalf("history was not capped: got %d entries want %d", len(entries), maxHistoryEntries)
+		}
+		if oldest := entries[len(entries)-1]; oldest.Version != 6 {
+			t.Errorf("oldest kept entry has wrong version: got %d want %d", oldest.Version, 6)
+		}
+		if rr := do("POST", "/items/1/history/rollback?version=1", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("rollback to a dropped version: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	// Sub-test for "Unknown Item"
+	t.Run("Unknown Item", func(t *testing.T) {
+		withHistory()
+		if rr := do("GET", "/items/999/history", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	// Sub-test for "History Disabled"
+	t.Run("History Disabled", func(t *testing.T) {
+		resetGlobalItems()
+		if rr := do("GET", "/items/1/history", ""); rr.Code != http.StatusNotImplemented {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotImplemented)
+		}
+	})
+}
+
+// TestHistoryStore checks that the wrapper still behaves like any other Storage.
+func TestHistoryStore(t *testing.T) {
+	runStorageTests(t, func(t *testing.T) Storage {
+		return NewHistoryStore(NewMemoryStore())
+	})
+}
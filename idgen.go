@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator creates the IDs of new items.
+// Implementations must be safe for concurrent use.
+type IDGenerator interface {
+	GenerateID() string
+}
+
+// idGenerator creates new item IDs, like store it is set up once by main.
+// When nil, IDs are random numbers drawn from the request's random source,
+// so tests can seed it with withRand.
+var idGenerator IDGenerator
+
+// idGeneratorKey is the context key under which a request-specific IDGenerator is stored.
+type idGeneratorKey struct{}
+
+// withIDGenerator returns a copy of ctx whose new items take their IDs from g
+// instead of the global idGenerator. Tests use this rather than replacing the
+// global, so they do not share a generator.
+func withIDGenerator(ctx context.Context, g IDGenerator) context.Context {
+	return context.WithValue(ctx, idGeneratorKey{}, g)
+}
+
+// idGeneratorFromContext returns the IDGenerator stored in ctx, falling back
+// to the global one.
+func idGeneratorFromContext(ctx context.Context) IDGenerator {
+	if g, ok := ctx.Value(idGeneratorKey{}).(IDGenerator); ok {
+		return g
+	}
+	return idGenerator
+}
+
+// newItemID returns an ID for a new item.
+func newItemID(ctx context.Context) string {
+	if g := idGeneratorFromContext(ctx); g != nil {
+		return g.GenerateID()
+	}
+	return strconv.Itoa(randFromContext(ctx).Intn(1000000))
+}
+
+// UUIDGenerator creates random version 4 UUIDs.
+type UUIDGenerator struct{}
+
+// GenerateID returns a new UUID.
+func (UUIDGenerator) GenerateID() string {
+	return uuid.NewString()
+}
+
+// SequentialGenerator counts up from the last ID it was given: 1, 2, 3, ...
+type SequentialGenerator struct {
+	last atomic.Int64
+}
+
+// NewSequentialGenerator returns a generator whose first ID is last+1.
+func NewSequentialGenerator(last int64) *SequentialGenerator {
+	g := &SequentialGenerator{}
+	g.last.Store(last)
+	return g
+}
+
+// GenerateID returns the next number in the sequence.
+func (g *SequentialGenerator) GenerateID() string {
+	return strconv.FormatInt(g.last.Add(1), 10)
+}
+
+// PrefixedGenerator puts Prefix in front of every ID made by Generator, e.g. "item-42".
+type PrefixedGenerator struct {
+	Prefix    string
+	Generator IDGenerator
+}
+
+// GenerateID returns the wrapped generator's next ID with the prefix.
+func (g PrefixedGenerator) GenerateID() string {
+	return g.Prefix + g.Generator.GenerateID()
+}
+
+// maxNumericID returns the largest ID among items that is a plain number,
+// so a SequentialGenerator can continue after the existing items.
+func maxNumericID(items []Item) int64 {
+	var highest int64
+	for _, item := range items {
+		if n, err := strconv.ParseInt(item.ID, 10, 64); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest
+}
+
+// newIDGenerator builds the generator selected by cfg. A sequential
+// generator continues after the numeric IDs already in s.
+// A nil generator with a nil error means the default random IDs.
+func newIDGenerator(ctx context.Context, cfg ServerConfig, s Storage) (IDGenerator, error) {
+	var g IDGenerator
+	switch cfg.IDGenerator {
+	case "", "random":
+	case "uuid":
+		g = UUIDGenerator{}
+	case "sequential":
+		existing, err := s.GetAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		g = NewSequentialGenerator(maxNumericID(existing))
+	default:
+		return nil, fmt.Errorf("unknown ID_GENERATOR %q: must be random, uuid or sequential", cfg.IDGenerator)
+	}
+	if cfg.IDPrefix == "" {
+		return g, nil
+	}
+	if g == nil {
+		g = randomGenerator{}
+	}
+	return PrefixedGenerator{Prefix: cfg.IDPrefix, Generator: g}, nil
+}
+
+// randomGenerator makes the default random IDs, for wrapping in a PrefixedGenerator.
+type randomGenerator struct{}
+
+// GenerateID returns a random number.
+func (randomGenerator) GenerateID() string {
+	return strconv.Itoa(globalRand.Intn(1000000))
+}
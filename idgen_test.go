@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestSequentialIDs checks that createItem takes its IDs from the request's
+// IDGenerator. The store and generator travel in the request context, so the
+// test shares no state with others.
+func TestSequentialIDs(t *testing.T) {
+	ctx := withStore(context.Background(), NewMemoryStore())
+	ctx = withIDGenerator(ctx, NewSequentialGenerator(0))
+
+	for _, want := range []string{"1", "2", "3"} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/items", bytes.NewBufferString(`{"name":"Counted"}`))
+		createItem(rr, req.WithContext(ctx))
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		if item.ID != want {
+			t.Errorf("created item has wrong ID: got %q want %q", item.ID, want)
+		}
+	}
+}
+
+// TestIDGenerators checks the bundled generators and how config selects them.
+func TestIDGenerators(t *testing.T) {
+	if id := (UUIDGenerator{}).GenerateID(); uuid.Validate(id) != nil {
+		t.Errorf("UUIDGenerator made an invalid UUID: %q", id)
+	}
+	prefixed := PrefixedGenerator{Prefix: "item-", Generator: NewSequentialGenerator(41)}
+	if id := prefixed.GenerateID(); id != "item-42" {
+		t.Errorf("PrefixedGenerator: got %q want %q", id, "item-42")
+	}
+
+	// A sequential generator continues after the stored numeric IDs
+	s := NewMemoryStore(Item{ID: "7"}, Item{ID: "abc"}, Item{ID: "12"})
+	g, err := newIDGenerator(context.Background(), ServerConfig{IDGenerator: "sequential"}, s)
+	if err != nil {
+		t.Fatalf("newIDGenerator failed: %v", err)
+	}
+	if id := g.GenerateID(); id != "13" {
+		t.Errorf("sequential generator did not continue after the stored IDs: got %q want %q", id, "13")
+	}
+
+	if g, _ := newIDGenerator(context.Background(), ServerConfig{}, s); g != nil {
+		t.Errorf("default generator should be nil (random IDs), got %T", g)
+	}
+	if g, _ := newIDGenerator(context.Background(), ServerConfig{IDPrefix: "x-"}, s); g == nil || !strings.HasPrefix(g.GenerateID(), "x-") {
+		t.Error("ID_PREFIX alone did not prefix the random IDs")
+	}
+	if _, err := newIDGenerator(context.Background(), ServerConfig{IDGenerator: "snowflake"}, s); err == nil {
+		t.Error("unknown ID_GENERATOR was accepted")
+	}
+}
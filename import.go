@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxImportLineSize is the longest line POST /items/import/jsonl accepts.
+const maxImportLineSize = 1 << 20
+
+// maxCSVImportSize is the largest file POST /items/import/csv accepts.
+const maxCSVImportSize = 10 << 20
+
+// csvImportColumns are the header columns a CSV import must have.
+var csvImportColumns = []string{"id", "name", "description"}
+
+// respondWithImportResult reports how many items an import stored, with
+// the error that stopped it early if there was one.
+func respondWithImportResult(w http.ResponseWriter, code, imported int, message string) {
+	result := map[string]interface{}{"imported": imported}
+	if message != "" {
+		result["error"] = message
+	}
+	respondWithJSON(w, code, result)
+}
+
+// importItemsJSONL (POST /items/import/jsonl)
+// This creates one item per line of a newline-delimited JSON body. Lines are
+// read and stored one at a time, so memory use does not grow with the body.
+// Items get new IDs like POST /items. The first bad line stops the import;
+// the lines before it stay imported and are counted in the response.
+func importItemsJSONL(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+
+	imported, line := 0, 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if config.MaxJSONDepth > 0 {
+			// jsonDepthLimitMiddleware leaves this route alone, so check each line here
+			if ok, err := checkJSONDepth(bytes.NewReader(scanner.Bytes()), config.MaxJSONDepth); !ok && err == nil {
+				respondWithImportResult(w, http.StatusBadRequest, imported, fmt.Sprintf("line %d: JSON is nested more than %d levels deep", line, config.MaxJSONDepth))
+				return
+			}
+		}
+		var item Item
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			respondWithImportResult(w, http.StatusBadRequest, imported, fmt.Sprintf("line %d: invalid JSON: %v", line, err))
+			return
+		}
+		if err := validateItem(item); err != nil {
+			respondWithImportResult(w, http.StatusUnprocessableEntity, imported, fmt.Sprintf("line %d: %v", line, err))
+			return
+		}
+		sanitizeItem(&item)
+		item.CreatedAt = time.Now().UTC()
+		item.LastRequestID = GetRequestID(r.Context())
+
+		created, err := createWithNewID(r.Context(), item)
+		if err != nil {
+			log.Printf("import stopped at line %d: %v", line, err)
+			respondWithImportResult(w, http.StatusInternalServerError, imported, fmt.Sprintf("line %d: failed to store item", line))
+			return
+		}
+		recordItemChange(r.Context(), eventItemCreated, created)
+		imported++
+	}
+	if err := scanner.Err(); err != nil {
+		respondWithImportResult(w, http.StatusBadRequest, imported, fmt.Sprintf("line %d: %v", line+1, err))
+		return
+	}
+	respondWithImportResult(w, http.StatusOK, imported, "")
+}
+
+// utf8BOM is the byte order mark some spreadsheet programs put before UTF-8 CSV.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// itemFromCSV builds an item from a CSV record. header holds the lowercased
+// column names. Tags are split on ";", as renderCSV writes them, and columns
+// Item has no field for end up in Metadata.
+func itemFromCSV(header, record []string) Item {
+	var item Item
+	for i, column := range header {
+		value := record[i]
+		switch column {
+		case "id":
+			item.ID = strings.TrimSpace(value)
+		case "name":
+			item.Name = value
+		case "description":
+			item.Description = value
+		case "tags":
+			if value != "" {
+				item.Tags = strings.Split(value, ";")
+			}
+		default:
+			if item.Metadata == nil {
+				item.Metadata = make(map[string]string)
+			}
+			item.Metadata[column] = value
+		}
+	}
+	return item
+}
+
+// importItemsCSV (POST /items/import/csv)
+// This creates one item per row of the multipart form file "file", a UTF-8
+// CSV file, optionally with a byte order mark. The header row must have id,
+// name and description columns; a tags column is read too, and any other
+// column is kept in the item's Metadata. Rows with an empty id get a new ID.
+// Rows with an empty name are skipped; other bad rows are listed in "errors"
+// and the import carries on with the next row.
+func importItemsCSV(w http.ResponseWriter, r *http.Request) {
+	// Leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxCSVImportSize+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "CSV file is larger than 10 MB")
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "expected a multipart form with a 'file' field")
+		return
+	}
+	defer file.Close()
+
+	body := bufio.NewReader(file)
+	if start, _ := body.Peek(3); bytes.Equal(start, utf8BOM) {
+		body.Discard(len(utf8BOM))
+	} else if len(start) >= 2 && (start[0] == 0xFF && start[1] == 0xFE || start[0] == 0xFE && start[1] == 0xFF) {
+		respondWithError(w, http.StatusBadRequest, "CSV file is UTF-16; it must be UTF-8")
+		return
+	}
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1 // checked per row, so one short row does not stop the import
+	header, err := reader.Read()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "CSV file has no header row")
+		return
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	var missing []string
+	for _, column := range csvImportColumns {
+		if !slices.Contains(header, column) {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		respondWithError(w, http.StatusBadRequest, "CSV header is missing columns: "+strings.Join(missing, ", "))
+		return
+	}
+
+	imported, skipped, problems := 0, 0, []string{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The reader cannot find the next row after a quoting error, so stop here
+			problems = append(problems, err.Error())
+			break
+		}
+		if len(record) != len(header) {
+			problems = append(problems, fmt.Sprintf("row %d: has %d fields, the header has %d", row, len(record), len(header)))
+			continue
+		}
+		if !utf8.ValidString(strings.Join(record, "")) {
+			problems = append(problems, fmt.Sprintf("row %d: not valid UTF-8", row))
+			continue
+		}
+		item := itemFromCSV(header, record)
+		if strings.TrimSpace(item.Name) == "" {
+			log.Printf("WARNING: CSV import skipped row %d without a name (request %s)", row, GetRequestID(r.Context()))
+			skipped++
+			continue
+		}
+		if err := validateItem(item); err != nil {
+			problems = append(problems, fmt.Sprintf("row %d: %v", row, err))
+			continue
+		}
+		sanitizeItem(&item)
+		item.CreatedAt = time.Now().UTC()
+		item.LastRequestID = GetRequestID(r.Context())
+
+		var created Item
+		if item.ID == "" {
+			created, err = createWithNewID(r.Context(), item)
+		} else {
+			created, err = storeFromContext(r.Context()).Create(r.Context(), item)
+		}
+		if errors.Is(err, errDuplicateID) {
+			problems = append(problems, fmt.Sprintf("row %d: item ID '%s' already exists", row, item.ID))
+			continue
+		}
+		if err != nil {
+			log.Printf("CSV import stopped at row %d: %v", row, err)
+			respondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"imported": imported, "skipped": skipped, "errors": append(problems, fmt.Sprintf("row %d: failed to store item", row)),
+			})
+			return
+		}
+		recordItemChange(r.Context(), eventItemCreated, created)
+		imported++
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"imported": imported, "skipped": skipped, "errors": problems})
+}
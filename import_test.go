@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// importJSONL posts body to POST /items/import/jsonl.
+func importJSONL(body *bytes.Buffer) (map[string]interface{}, int) {
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest("POST", "/items/import/jsonl", body))
+	var result map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&result)
+	return result, rr.Code
+}
+
+// TestImportJSONL (POST /items/import/jsonl)
+func TestImportJSONL(t *testing.T) {
+	// Sub-test for "1000 Items"
+	t.Run("1000 Items", func(t *testing.T) {
+		store = NewMemoryStore()
+		defer resetGlobalItems()
+
+		var body bytes.Buffer
+		enc := json.NewEncoder(&body) // Encode ends every item with a newline
+		for i := 0; i < 1000; i++ {
+			enc.Encode(Item{Name: fmt.Sprintf("Imported %d", i)})
+		}
+		result, code := importJSONL(&body)
+		if code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %v", code, http.StatusOK, result)
+		}
+		if result["imported"] != 1000.0 {
+			t.Errorf("wrong import count: got %v want %v", result["imported"], 1000)
+		}
+
+		rr := httptest.NewRecorder()
+		getItems(rr, httptest.NewRequest("GET", "/items", nil))
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		if len(items) != 1000 {
+			t.Errorf("GET /items returned %d items, want %d", len(items), 1000)
+		}
+	})
+
+	// Sub-test for "Malformed Line"
+	t.Run("Malformed Line", func(t *testing.T) {
+		store = NewMemoryStore()
+		defer resetGlobalItems()
+
+		body := bytes.NewBufferString("{\"name\":\"one\"}\n\n{\"name\":\"two\"}\n{not json\n{\"name\":\"four\"}\n")
+		result, code := importJSONL(body)
+		if code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", code, http.StatusBadRequest)
+		}
+		if result["imported"] != 2.0 || result["error"] == nil {
+			t.Errorf("wrong partial import result: %v", result)
+		}
+		if len(storedItems()) != 2 {
+			t.Errorf("lines after the malformed one were imported: %+v", storedItems())
+		}
+	})
+}
+
+// importCSV uploads content as the file of a POST /items/import/csv request.
+func importCSV(content string) (map[string]interface{}, int) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "items.csv")
+	part.Write([]byte(content))
+	mw.Close()
+	req := httptest.NewRequest("POST", "/items/import/csv", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, req)
+	var result map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&result)
+	return result, rr.Code
+}
+
+// TestImportCSV (POST /items/import/csv)
+func TestImportCSV(t *testing.T) {
+	// Sub-test for "Valid File"
+	t.Run("Valid File", func(t *testing.T) {
+		store = NewMemoryStore()
+		defer resetGlobalItems()
+
+		// Spreadsheet exports often start with a byte order mark
+		content := "\ufeffid,name,description,tags,color\n" +
+			"a1,Apple,\"Red, round\",fruit;red,red\n" +
+			",Banana,Yellow,,yellow\n" +
+			"c1,Cherry,Small,,\n"
+		result, code := importCSV(content)
+		if code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %v", code, http.StatusOK, result)
+		}
+		if result["imported"] != 3.0 || result["skipped"] != 0.0 || len(result["errors"].([]interface{})) != 0 {
+			t.Errorf("wrong import result: %v", result)
+		}
+
+		apple, err := store.GetByID(t.Context(), "a1")
+		if err != nil {
+			t.Fatalf("row with an id was not stored under it: %v", err)
+		}
+		if apple.Description != "Red, round" || strings.Join(apple.Tags, ",") != "fruit,red" || apple.Metadata["color"] != "red" {
+			t.Errorf("wrong imported item: %+v", apple)
+		}
+		items, _ := store.GetAll(t.Context())
+		if len(items) != 3 || items[1].Name != "Banana" || items[1].ID == "" {
+			t.Errorf("wrong items after import: %+v", items)
+		}
+	})
+
+	// Sub-test for "Missing Name Column"
+	t.Run("Missing Name Column", func(t *testing.T) {
+		resetGlobalItems()
+		result, code := importCSV("id,description\n1,First\n")
+		if code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", code, http.StatusBadRequest)
+		}
+		if !strings.Contains(fmt.Sprint(result["error"]), "name") {
+			t.Errorf("error does not name the missing column: %v", result)
+		}
+	})
+
+	// Sub-test for "Empty Names And Bad Rows"
+	t.Run("Empty Names And Bad Rows", func(t *testing.T) {
+		store = NewMemoryStore()
+		defer resetGlobalItems()
+
+		content := "id,name,description\n" +
+			",Kept,\n" +
+			",,no name\n" +
+			",  ,blank name\n" +
+			",Short\n"
+		result, code := importCSV(content)
+		if code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %v", code, http.StatusOK, result)
+		}
+		if result["imported"] != 1.0 || result["skipped"] != 2.0 || len(result["errors"].([]interface{})) != 1 {
+			t.Errorf("wrong import result: %v", result)
+		}
+	})
+
+	// Sub-test for "UTF-16"
+	t.Run("UTF-16", func(t *testing.T) {
+		resetGlobalItems()
+		if _, code := importCSV("\xff\xfei\x00d\x00"); code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", code, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RawMessageItem is an Item whose description has already been JSON-encoded,
+// so it can be written out as-is instead of being escaped again on every response.
+// It marshals to exactly the same JSON as the Item it was built from, so it
+// must list the same fields in the same order.
+//
+// It only exists for the benchmarks below. encoding/json validates and
+// compacts every json.RawMessage it writes, which costs more than escaping
+// the string again: on 1000 items of 1 KB each it made GET /items about 50%
+// slower with more allocations, so handlers keep marshalling plain Items.
+type RawMessageItem struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description json.RawMessage `json:"description"`
+	Tags        []string        `json:"tags,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Version     int             `json:"version"`
+	Likes       int             `json:"likes"`
+	// LastRequestID mirrors Item.LastRequestID.
+	LastRequestID string `json:"last_request_id,omitempty"`
+	// Namespace mirrors Item.Namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// Pinned mirrors Item.Pinned.
+	Pinned bool `json:"pinned"`
+	// ViewCount mirrors Item.ViewCount.
+	ViewCount int64 `json:"view_count"`
+	// Score mirrors Item.Score.
+	Score float64 `json:"score,omitempty"`
+	// CloneCount mirrors Item.CloneCount.
+	CloneCount int `json:"clone_count"`
+	// Metadata mirrors Item.Metadata.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// newRawMessageItem pre-encodes item's description.
+func newRawMessageItem(item Item) RawMessageItem {
+	description, _ := json.Marshal(item.Description) // a string always marshals
+	return RawMessageItem{
+		ID:          item.ID,
+		Name:        item.Name,
+		Description: description,
+		Tags:        item.Tags,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		Version:     item.Version,
+		Likes:       item.Likes,
+
+		LastRequestID: item.LastRequestID,
+		Namespace:     item.Namespace,
+		Pinned:        item.Pinned,
+		ViewCount:     item.ViewCount,
+		Score:         item.Score,
+		CloneCount:    item.CloneCount,
+		Metadata:      item.Metadata,
+	}
+}
+
+// largeItems returns n items with 1 KB descriptions that need escaping.
+func largeItems(n int) []Item {
+	description := strings.Repeat(`<p>"quoted" & long</p> `, 1024/23+1)[:1024]
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	items := make([]Item, n)
+	for i := range items {
+		items[i] = Item{
+			ID:          strconv.Itoa(i),
+			Name:        "Item " + strconv.Itoa(i),
+			Description: description,
+			Tags:        []string{"bench"},
+			CreatedAt:   created,
+			UpdatedAt:   created,
+			Version:     1,
+		}
+	}
+	return items
+}
+
+// rawMessageItems pre-encodes every item, as a store would do on write.
+func rawMessageItems(items []Item) []RawMessageItem {
+	raw := make([]RawMessageItem, len(items))
+	for i, item := range items {
+		raw[i] = newRawMessageItem(item)
+	}
+	return raw
+}
+
+// TestRawMessageItemJSON checks that the pre-encoded form is invisible to clients.
+func TestRawMessageItemJSON(t *testing.T) {
+	items := append(largeItems(3), Item{ID: "empty"}, Item{ID: "unicode", Description: "café   \x00"})
+	want, err := json.Marshal(items)
+	if err != nil {
+		t.Fatalf("failed to marshal items: %v", err)
+	}
+	got, err := json.Marshal(rawMessageItems(items))
+	if err != nil {
+		t.Fatalf("failed to marshal raw message items: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("RawMessageItem JSON differs:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+// BenchmarkMarshalItems and BenchmarkMarshalRawMessageItems compare the cost
+// of encoding GET /items with 1000 items of 1 KB each.
+func BenchmarkMarshalItems(b *testing.B) {
+	items := largeItems(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(items)
+	}
+}
+
+func BenchmarkMarshalRawMessageItems(b *testing.B) {
+	items := rawMessageItems(largeItems(1000))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(items)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// changeLikes applies delta to an item's like count, never going below zero.
+func changeLikes(w http.ResponseWriter, r *http.Request, delta int) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	// Likes are a counter, not a revision: the version and history stay as they are
+	item, err := storeFromContext(r.Context()).Update(withCounterUpdate(r.Context()), id, func(item *Item) error {
+		item.Likes = max(item.Likes+delta, 0)
+		return nil
+	})
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemUpdated, item)
+
+	respondWithJSON(w, http.StatusOK, item)
+}
+
+// likeItem (POST /items/{id}/like)
+// This adds one like to an item and returns the updated item.
+func likeItem(w http.ResponseWriter, r *http.Request) {
+	changeLikes(w, r, 1)
+}
+
+// unlikeItem (POST /items/{id}/unlike)
+// This takes one like away from an item; the count never goes below zero.
+func unlikeItem(w http.ResponseWriter, r *http.Request) {
+	changeLikes(w, r, -1)
+}
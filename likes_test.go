@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestLikes (POST /items/{id}/like and /unlike)
+func TestLikes(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+	do := func(action string) Item {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/1/"+action, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s returned wrong status code: got %v want %v", action, rr.Code, http.StatusOK)
+		}
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		return item
+	}
+
+	for i := 0; i < 3; i++ {
+		do("like")
+	}
+	if likes := storedItems()[0].Likes; likes != 3 {
+		t.Errorf("wrong likes after 3 likes: got %d want %d", likes, 3)
+	}
+	if item := do("unlike"); item.Likes != 2 {
+		t.Errorf("wrong likes after an unlike: got %d want %d", item.Likes, 2)
+	}
+	for i := 0; i < 5; i++ {
+		do("unlike")
+	}
+	if likes := storedItems()[0].Likes; likes != 0 {
+		t.Errorf("likes went below zero: got %d", likes)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/999/like", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("like of a missing item: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+// TestLikesKeepVersion checks that likes are not recorded as new revisions.
+func TestLikesKeepVersion(t *testing.T) {
+	resetGlobalItems()
+	store = NewHistoryStore(store)
+	defer resetGlobalItems()
+	router := newRouter()
+	before, _ := store.GetByID(t.Context(), "1")
+
+	for _, action := range []string{"like", "like", "unlike"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/1/"+action, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s returned wrong status code: got %v want %v", action, rr.Code, http.StatusOK)
+		}
+	}
+	after, _ := store.GetByID(t.Context(), "1")
+	if after.Likes != 1 || after.Version != before.Version || !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("likes changed the bookkeeping: got %+v want version %d", after, before.Version)
+	}
+	history, _ := store.(*HistoryStore).History(t.Context(), "1")
+	if len(history) != 0 {
+		t.Errorf("likes were recorded in the history: %+v", history)
+	}
+}
+
+// TestGetItemsSort checks ?sort= and ?order= on GET /items.
+func TestGetItemsSort(t *testing.T) {
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "banana", Likes: 5},
+		Item{ID: "2", Name: "Apple", Likes: 9},
+		Item{ID: "3", Name: "cherry", Likes: 5},
+	)
+	ids := func(target string) (string, int) {
+		rr := httptest.NewRecorder()
+		getItems(rr, httptest.NewRequest("GET", target, nil))
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		var got string
+		for _, item := range items {
+			got += item.ID
+		}
+		return got, rr.Code
+	}
+
+	tests := map[string]string{
+		"/items":                       "123",
+		"/items?sort=name":             "213",
+		"/items?sort=likes&order=desc": "213", // ties keep insertion order
+		"/items?sort=likes":            "132",
+	}
+	for target, want := range tests {
+		if got, _ := ids(target); got != want {
+			t.Errorf("%s: got order %s want %s", target, got, want)
+		}
+	}
+	for _, target := range []string{"/items?sort=colour", "/items?sort=name&order=up"} {
+		if _, code := ids(target); code != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", target, code, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestGetItemsDefaultSort checks that DEFAULT_SORT and DEFAULT_ORDER apply
+// to GET /items without ?sort=, and that query parameters still win.
+func TestGetItemsDefaultSort(t *testing.T) {
+	t.Setenv("DEFAULT_SORT", "name")
+	t.Setenv("DEFAULT_ORDER", "asc")
+	saved := config
+	config = loadConfig()
+	defer func() { config = saved }()
+
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "cherry", Likes: 1},
+		Item{ID: "2", Name: "apple", Likes: 3},
+		Item{ID: "3", Name: "banana", Likes: 2},
+	)
+	defer resetGlobalItems()
+
+	tests := map[string]string{
+		"/items":            "apple,banana,cherry",
+		"/items?order=desc": "cherry,banana,apple",
+		"/items?sort=likes": "cherry,banana,apple",
+		"/items?sort=id":    "cherry,apple,banana",
+	}
+	for target, want := range tests {
+		rr := httptest.NewRecorder()
+		getItems(rr, httptest.NewRequest("GET", target, nil))
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		if got := strings.Join(names, ","); got != want {
+			t.Errorf("%s: got order %s want %s", target, got, want)
+		}
+	}
+
+	// An invalid default is ignored rather than breaking every request
+	t.Setenv("DEFAULT_SORT", "colour")
+	if c := loadConfig(); c.DefaultSort != "" || c.DefaultOrder != "" {
+		t.Errorf("invalid default sort was kept: %q %q", c.DefaultSort, c.DefaultOrder)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// ItemLink is a directed relationship from one item to another, such as
+// {"source_id": "1", "target_id": "2", "relation_type": "related"}.
+type ItemLink struct {
+	SourceID     string `json:"source_id"`
+	TargetID     string `json:"target_id"`
+	RelationType string `json:"relation_type"`
+}
+
+// linkKey identifies a link; two items can be linked once per relation type.
+type linkKey struct {
+	source, target, relation string
+}
+
+// LinkStore keeps the links between items in memory, apart from the items.
+type LinkStore struct {
+	mu    sync.RWMutex
+	links map[linkKey]ItemLink
+}
+
+// NewLinkStore returns an empty LinkStore.
+func NewLinkStore() *LinkStore {
+	return &LinkStore{links: make(map[linkKey]ItemLink)}
+}
+
+// links holds the item links used by the handlers.
+var links = NewLinkStore()
+
+func (l ItemLink) key() linkKey {
+	return linkKey{l.SourceID, l.TargetID, l.RelationType}
+}
+
+// Add stores a link and reports false if it already existed.
+func (s *LinkStore) Add(l ItemLink) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.links[l.key()]; ok {
+		return false
+	}
+	s.links[l.key()] = l
+	return true
+}
+
+// Has reports whether a link exists.
+func (s *LinkStore) Has(l ItemLink) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.links[l.key()]
+	return ok
+}
+
+// List returns the outgoing links of an item, sorted by target and relation.
+// A non-empty relationType keeps only the links of that type.
+func (s *LinkStore) List(sourceID, relationType string) []ItemLink {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := []ItemLink{}
+	for _, l := range s.links {
+		if l.SourceID == sourceID && (relationType == "" || l.RelationType == relationType) {
+			list = append(list, l)
+		}
+	}
+	slices.SortFunc(list, func(a, b ItemLink) int {
+		return cmp.Or(cmp.Compare(a.TargetID, b.TargetID), cmp.Compare(a.RelationType, b.RelationType))
+	})
+	return list
+}
+
+// Delete removes a link and reports whether it existed.
+func (s *LinkStore) Delete(l ItemLink) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.links[l.key()]; !ok {
+		return false
+	}
+	delete(s.links, l.key())
+	return true
+}
+
+// DeleteItem removes every link from or to an item.
+func (s *LinkStore) DeleteItem(itemID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.links {
+		if key.source == itemID || key.target == itemID {
+			delete(s.links, key)
+		}
+	}
+}
+
+// addLink (POST /items/{id}/links)
+// This links the item to another, {"target_id": "2", "relation_type": "related"}.
+func addLink(w http.ResponseWriter, r *http.Request) {
+	var l ItemLink
+	if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	createLink(w, r, ItemLink{SourceID: mux.Vars(r)["id"], TargetID: l.TargetID, RelationType: l.RelationType})
+}
+
+// createLink checks and stores a new link for addLink and linkItem.
+func createLink(w http.ResponseWriter, r *http.Request, l ItemLink) {
+	s := storeFromContext(r.Context())
+	if _, err := s.GetByID(r.Context(), l.SourceID); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	l.RelationType = strings.TrimSpace(l.RelationType)
+	switch {
+	case l.TargetID == "":
+		respondWithError(w, http.StatusBadRequest, "target_id is required")
+		return
+	case l.RelationType == "" || strings.Contains(l.RelationType, "/"):
+		respondWithError(w, http.StatusBadRequest, "relation_type is required and may not contain '/'")
+		return
+	case l.TargetID == l.SourceID:
+		respondWithError(w, http.StatusBadRequest, "an item cannot link to itself")
+		return
+	}
+	if _, err := s.GetByID(r.Context(), l.TargetID); err != nil {
+		if errors.Is(err, errItemNotFound) {
+			respondWithError(w, http.StatusUnprocessableEntity, "target item not found")
+			return
+		}
+		respondWithStorageError(w, err)
+		return
+	}
+
+	added := !links.Has(l)
+	if added && !isDryRun(r.Context()) {
+		added = links.Add(l)
+	}
+	if !added {
+		respondWithError(w, http.StatusConflict, "link already exists")
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, l)
+}
+
+// listLinks (GET /items/{id}/links)
+// This returns the item's outgoing links, only those of one type with ?relation_type=.
+func listLinks(w http.ResponseWriter, r *http.Request) {
+	source := mux.Vars(r)["id"]
+	if _, err := storeFromContext(r.Context()).GetByID(r.Context(), source); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, links.List(source, r.URL.Query().Get("relation_type")))
+}
+
+// deleteLink (DELETE /items/{id}/links/{target_id}/{relation_type})
+// This removes one link from the item.
+func deleteLink(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	removeLink(w, r, ItemLink{SourceID: params["id"], TargetID: params["target_id"], RelationType: params["relation_type"]})
+}
+
+// removeLink removes a link for deleteLink and unlinkItem.
+func removeLink(w http.ResponseWriter, r *http.Request, l ItemLink) {
+	found := false
+	if isDryRun(r.Context()) {
+		found = links.Has(l)
+	} else {
+		found = links.Delete(l)
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Link not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "link_deleted": l.TargetID + "/" + l.RelationType})
+}
+
+// parseLinkHeader reads the target item and relation type from a Link
+// header holding a single link, such as `</items/2>; rel="related"`.
+// The target may also be an absolute URL of an item.
+func parseLinkHeader(header string) (targetID, rel string, err error) {
+	header = strings.TrimSpace(header)
+	end := strings.IndexByte(header, '>')
+	if !strings.HasPrefix(header, "<") || end < 0 {
+		return "", "", errors.New(`Link header must look like </items/{id}>; rel="related"`)
+	}
+	target, params := header[1:end], header[end+1:]
+	if strings.Contains(params, ",") {
+		return "", "", errors.New("Link header must hold exactly one link")
+	}
+	u, err := url.Parse(target)
+	targetID, ok := "", false
+	if err == nil {
+		targetID, ok = strings.CutPrefix(u.Path, "/items/")
+	}
+	if !ok || targetID == "" || strings.Contains(targetID, "/") {
+		return "", "", errors.New("Link target must be an item, /items/{id}")
+	}
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "rel") {
+			rel = strings.Trim(value, `"`)
+		}
+	}
+	if rel == "" {
+		return "", "", errors.New("Link header needs a rel parameter")
+	}
+	return targetID, rel, nil
+}
+
+// linkItem (LINK /items/{id})
+// This links the item to the one in the Link header, as RFC 2068 describes:
+// `Link: </items/2>; rel="related"` does what POST /items/{id}/links does.
+func linkItem(w http.ResponseWriter, r *http.Request) {
+	target, rel, err := parseLinkHeader(r.Header.Get("Link"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	createLink(w, r, ItemLink{SourceID: mux.Vars(r)["id"], TargetID: target, RelationType: rel})
+}
+
+// unlinkItem (UNLINK /items/{id})
+// This removes the link in the Link header from the item.
+func unlinkItem(w http.ResponseWriter, r *http.Request) {
+	target, rel, err := parseLinkHeader(r.Header.Get("Link"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	removeLink(w, r, ItemLink{SourceID: mux.Vars(r)["id"], TargetID: target, RelationType: rel})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestItemLinks (POST, GET and DELETE /items/{id}/links)
+func TestItemLinks(t *testing.T) {
+	resetGlobalItems()
+	links = NewLinkStore()
+	store.Create(t.Context(), Item{ID: "3", Name: "Third"})
+	router := newRouter()
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+	listLinks := func(path string) []ItemLink {
+		rr := serve("GET", path, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var list []ItemLink
+		json.NewDecoder(rr.Body).Decode(&list)
+		return list
+	}
+
+	// 1. Link item 1 to items 2 and 3
+	for _, body := range []string{
+		`{"target_id":"2","relation_type":"related"}`,
+		`{"target_id":"3","relation_type":"parent"}`,
+	} {
+		if rr := serve("POST", "/items/1/links", body); rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body)
+		}
+	}
+	if rr := serve("POST", "/items/1/links", `{"target_id":"2","relation_type":"related"}`); rr.Code != http.StatusConflict {
+		t.Errorf("handler returned wrong status code for a duplicate link: got %v want %v", rr.Code, http.StatusConflict)
+	}
+
+	// 2. List the links, all of them and then by relation type
+	if got := listLinks("/items/1/links"); len(got) != 2 {
+		t.Errorf("wrong number of links: got %d want 2", len(got))
+	}
+	want := ItemLink{SourceID: "1", TargetID: "2", RelationType: "related"}
+	if got := listLinks("/items/1/links?relation_type=related"); len(got) != 1 || got[0] != want {
+		t.Errorf("wrong related links: got %+v want [%+v]", got, want)
+	}
+	if got := listLinks("/items/2/links"); len(got) != 0 {
+		t.Errorf("links should be directional: got %+v", got)
+	}
+
+	// 3. Delete the related link
+	if rr := serve("DELETE", "/items/1/links/2/related", ""); rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if got := listLinks("/items/1/links?relation_type=related"); len(got) != 0 {
+		t.Errorf("link still listed after delete: %+v", got)
+	}
+	if rr := serve("DELETE", "/items/1/links/2/related", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+
+	// Sub-test for "Invalid Links"
+	t.Run("Invalid Links", func(t *testing.T) {
+		for body, want := range map[string]int{
+			`{"target_id":"99","relation_type":"related"}`: http.StatusUnprocessableEntity,
+			`{"target_id":"1","relation_type":"related"}`:  http.StatusBadRequest,
+			`{"target_id":"2"}`:                            http.StatusBadRequest,
+		} {
+			if rr := serve("POST", "/items/1/links", body); rr.Code != want {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", body, rr.Code, want)
+			}
+		}
+	})
+
+	// Sub-test for "Deleting An Item"
+	t.Run("Deleting An Item", func(t *testing.T) {
+		serve("DELETE", "/items/3", "")
+		if got := listLinks("/items/1/links"); len(got) != 0 {
+			t.Errorf("links to a deleted item should be removed: got %+v", got)
+		}
+	})
+}
+
+// TestLinkMethods (LINK and UNLINK /items/{id})
+func TestLinkMethods(t *testing.T) {
+	resetGlobalItems()
+	links = NewLinkStore()
+	router := newRouter()
+
+	send := func(method, link string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/items/1", nil)
+		req.Header.Set("Link", link)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	link := ItemLink{SourceID: "1", TargetID: "2", RelationType: "related"}
+
+	if rr := send("LINK", `</items/2>; rel="related"`); rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body)
+	}
+	if !links.Has(link) {
+		t.Errorf("LINK did not store %+v", link)
+	}
+	if rr := send("UNLINK", `<http://example.com/items/2>; rel=related`); rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	if links.Has(link) {
+		t.Errorf("UNLINK did not remove %+v", link)
+	}
+
+	// Sub-test for "Bad Link Headers"
+	t.Run("Bad Link Headers", func(t *testing.T) {
+		for _, header := range []string{
+			"",
+			"/items/2",
+			`</items/2>`,
+			`</templates/2>; rel="related"`,
+			`</items/2>; rel="related", </items/3>; rel="related"`,
+		} {
+			if rr := send("LINK", header); rr.Code != http.StatusBadRequest {
+				t.Errorf("%q: handler returned wrong status code: got %v want %v", header, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
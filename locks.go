@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// lockTokenHeader carries the token of the lock a client holds.
+const lockTokenHeader = "X-Lock-Token"
+
+// itemLock is an advisory lock held on one item.
+type itemLock struct {
+	Token     string
+	Owner     string
+	ExpiresAt time.Time
+}
+
+// ItemLocks tracks which items are locked by long-running clients.
+// Locks expire on their own; expired locks are ignored and pruned lazily.
+type ItemLocks struct {
+	mu    sync.Mutex
+	locks map[string]itemLock
+	now   func() time.Time // tests replace this to move time forward
+}
+
+// NewItemLocks returns an ItemLocks with no locks held.
+func NewItemLocks() *ItemLocks {
+	return &ItemLocks{locks: make(map[string]itemLock), now: time.Now}
+}
+
+// itemLocks holds the locks enforced on item writes.
+var itemLocks = NewItemLocks()
+
+// active returns the unexpired lock on id. The caller must hold l.mu.
+func (l *ItemLocks) active(id string) (itemLock, bool) {
+	lock, ok := l.locks[id]
+	if ok && !l.now().Before(lock.ExpiresAt) {
+		delete(l.locks, id)
+		return itemLock{}, false
+	}
+	return lock, ok
+}
+
+// Lock locks id for ttl. Locking again with the same token extends the lock;
+// if another token holds it, that lock is returned with ok set to false.
+func (l *ItemLocks) Lock(id, token, owner string, ttl time.Duration) (itemLock, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for other := range l.locks {
+		l.active(other) // prune expired locks
+	}
+	if held, ok := l.active(id); ok && !tokensEqual(held.Token, token) {
+		return held, false
+	}
+	lock := itemLock{Token: token, Owner: owner, ExpiresAt: l.now().Add(ttl)}
+	l.locks[id] = lock
+	return lock, true
+}
+
+// Unlock releases the lock on id if token holds it, and reports whether it did.
+func (l *ItemLocks) Unlock(id, token string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	held, ok := l.active(id)
+	if !ok || !tokensEqual(held.Token, token) {
+		return false
+	}
+	delete(l.locks, id)
+	return true
+}
+
+// Holds reports whether token holds the lock on id.
+func (l *ItemLocks) Holds(id, token string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	held, ok := l.active(id)
+	return ok && tokensEqual(held.Token, token)
+}
+
+// Locked returns the IDs of the items currently locked, sorted.
+func (l *ItemLocks) Locked() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var ids []string
+	for id := range l.locks {
+		if _, ok := l.active(id); ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Check returns the lock that stops a client with token from changing id, if any.
+func (l *ItemLocks) Check(id, token string) (itemLock, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	held, ok := l.active(id)
+	if !ok || tokensEqual(held.Token, token) {
+		return itemLock{}, false
+	}
+	return held, true
+}
+
+// tokensEqual compares lock tokens in constant time.
+func tokensEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// respondWithLocked answers 423 Locked for an item held by someone else.
+// The token itself is never disclosed.
+func respondWithLocked(w http.ResponseWriter, lock itemLock) {
+	respondWithJSON(w, http.StatusLocked, map[string]string{
+		"error":      "item is locked",
+		"locked_by":  lock.Owner,
+		"expires_at": lock.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// lockMiddleware rejects writes to a locked item unless X-Lock-Token holds the lock.
+// It covers every write route with an {id}, except the lock endpoints themselves.
+func lockMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := mux.Vars(r)["id"]
+		if !ok || r.Method == http.MethodGet || strings.HasSuffix(r.URL.Path, "/lock") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if lock, locked := itemLocks.Check(id, r.Header.Get(lockTokenHeader)); locked {
+			respondWithLocked(w, lock)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// lockItem (POST /items/{id}/lock)
+// This locks an item for ttl_seconds so that only the holder of token can change it.
+// With ?dry_run=true it answers as if it had, but takes no lock.
+func lockItem(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	var request struct {
+		Token      string `json:"token"`
+		Owner      string `json:"owner"`
+		TTLSeconds int    `json:"ttl_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if request.Token == "" || request.TTLSeconds <= 0 {
+		respondWithError(w, http.StatusBadRequest, "token and a positive ttl_seconds are required")
+		return
+	}
+	if _, err := storeFromContext(r.Context()).GetByID(r.Context(), id); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+
+	ttl := time.Duration(request.TTLSeconds) * time.Second
+	var lock itemLock
+	if isDryRun(r.Context()) {
+		// Report the lock that would be taken, without taking it
+		if held, locked := itemLocks.Check(id, request.Token); locked {
+			respondWithLocked(w, held)
+			return
+		}
+		lock = itemLock{Token: request.Token, Owner: request.Owner, ExpiresAt: itemLocks.now().Add(ttl)}
+	} else {
+		var ok bool
+		if lock, ok = itemLocks.Lock(id, request.Token, request.Owner, ttl); !ok {
+			respondWithLocked(w, lock)
+			return
+		}
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"result":     "success",
+		"id":         id,
+		"expires_at": lock.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// unlockItem (DELETE /items/{id}/lock)
+// This releases a lock early; X-Lock-Token must hold it. With ?dry_run=true
+// the lock is kept.
+func unlockItem(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	if lock, locked := itemLocks.Check(id, r.Header.Get(lockTokenHeader)); locked {
+		respondWithLocked(w, lock)
+		return
+	}
+	unlock := itemLocks.Unlock
+	if isDryRun(r.Context()) {
+		unlock = itemLocks.Holds
+	}
+	if !unlock(id, r.Header.Get(lockTokenHeader)) {
+		respondWithError(w, http.StatusNotFound, "item is not locked")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "id_unlocked": id})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestItemLocking covers locking, lock-protected writes, unlocking and expiry.
+func TestItemLocking(t *testing.T) {
+	router := newRouter()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	setup := func() {
+		resetGlobalItems()
+		itemLocks = NewItemLocks()
+		itemLocks.now = func() time.Time { return now }
+	}
+	defer func() { itemLocks = NewItemLocks() }()
+
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set(lockTokenHeader, token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	update := `{"name":"Changed","description":"changed"}`
+
+	// Sub-test for "Locked Item"
+	t.Run("Locked Item", func(t *testing.T) {
+		setup()
+		if rr := do("POST", "/items/1/lock", "", `{"token":"job-42","owner":"importer","ttl_seconds":60}`); rr.Code != http.StatusOK {
+			t.Fatalf("lock failed: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		rr := do("PUT", "/items/1", "", update)
+		if rr.Code != http.StatusLocked {
+			t.Fatalf("update without token: got %v want %v", rr.Code, http.StatusLocked)
+		}
+		var body map[string]string
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body["error"] != "item is locked" || body["locked_by"] != "importer" || body["expires_at"] != "2024-01-01T12:01:00Z" {
+			t.Errorf("locked response has wrong body: %v", body)
+		}
+		if rr := do("DELETE", "/items/1", "wrong-token", ""); rr.Code != http.StatusLocked {
+			t.Errorf("delete with wrong token: got %v want %v", rr.Code, http.StatusLocked)
+		}
+		if rr := do("PUT", "/items/bulk", "", `[{"id":"1","name":"Bulk"}]`); rr.Code != http.StatusLocked {
+			t.Errorf("bulk update without token: got %v want %v", rr.Code, http.StatusLocked)
+		}
+		if rr := do("PUT", "/items/1", "job-42", update); rr.Code != http.StatusOK {
+			t.Errorf("update with token: got %v want %v", rr.Code, http.StatusOK)
+		}
+		// Other items are not affected
+		if rr := do("PUT", "/items/2", "", update); rr.Code != http.StatusOK {
+			t.Errorf("update of an unlocked item: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	// Sub-test for "Lock Held By Someone Else"
+	t.Run("Lock Held By Someone Else", func(t *testing.T) {
+		setup()
+		do("POST", "/items/1/lock", "", `{"token":"job-42","ttl_seconds":60}`)
+		if rr := do("POST", "/items/1/lock", "", `{"token":"job-43","ttl_seconds":60}`); rr.Code != http.StatusLocked {
+			t.Errorf("second lock: got %v want %v", rr.Code, http.StatusLocked)
+		}
+		if rr := do("POST", "/items/999/lock", "", `{"token":"job-42","ttl_seconds":60}`); rr.Code != http.StatusNotFound {
+			t.Errorf("lock of a missing item: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	// Sub-test for "Unlock"
+	t.Run("Unlock", func(t *testing.T) {
+		setup()
+		do("POST", "/items/1/lock", "", `{"token":"job-42","ttl_seconds":60}`)
+		if rr := do("DELETE", "/items/1/lock", "wrong-token", ""); rr.Code != http.StatusLocked {
+			t.Errorf("unlock with wrong token: got %v want %v", rr.Code, http.StatusLocked)
+		}
+		if rr := do("DELETE", "/items/1/lock", "job-42", ""); rr.Code != http.StatusOK {
+			t.Errorf("unlock: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if rr := do("PUT", "/items/1", "", update); rr.Code != http.StatusOK {
+			t.Errorf("update after unlock: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	// Sub-test for "Lock Expires"
+	t.Run("Lock Expires", func(t *testing.T) {
+		setup()
+		do("POST", "/items/1/lock", "", `{"token":"job-42","ttl_seconds":60}`)
+		now = now.Add(61 * time.Second)
+		if rr := do("PUT", "/items/1", "", update); rr.Code != http.StatusOK {
+			t.Errorf("update after expiry: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+	// Sub-test for "Dry Run"
+	t.Run("Dry Run", func(t *testing.T) {
+		setup()
+		rr := do("POST", "/items/1/lock?dry_run=true", "", `{"token":"job-42","owner":"importer","ttl_seconds":60}`)
+		var body map[string]string
+		json.NewDecoder(rr.Body).Decode(&body)
+		if want := now.Add(time.Minute).Format(time.RFC3339); rr.Code != http.StatusOK || body["expires_at"] != want {
+			t.Fatalf("dry-run lock: got %v %v want %v", rr.Code, body, http.StatusOK)
+		}
+		if rr := do("PUT", "/items/1", "", update); rr.Code != http.StatusOK {
+			t.Errorf("update after dry-run lock: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		do("POST", "/items/1/lock", "", `{"token":"job-42","ttl_seconds":60}`)
+		if rr := do("DELETE", "/items/1/lock?dry_run=true", "job-42", ""); rr.Code != http.StatusOK {
+			t.Errorf("dry-run unlock: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if rr := do("PUT", "/items/1", "", update); rr.Code != http.StatusLocked {
+			t.Errorf("update after dry-run unlock: got %v want %v", rr.Code, http.StatusLocked)
+		}
+		if rr := do("POST", "/items/1/lock?dry_run=true", "", `{"token":"other","ttl_seconds":60}`); rr.Code != http.StatusLocked {
+			t.Errorf("dry-run lock of a locked item: got %v want %v", rr.Code, http.StatusLocked)
+		}
+	})
+}
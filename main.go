@@ -6,37 +6,180 @@ package main
 
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
 )
 
 // Item struct (Model)
 // This represents the data we're working with.
 type Item struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Version starts at 1 and goes up by one with every update.
+	Version int `json:"version"`
+	Likes   int `json:"likes"`
+	// LastRequestID is the X-Request-ID of the request that created or last updated the item.
+	LastRequestID string `json:"last_request_id,omitempty"`
+	// Namespace is the tenant the item belongs to; empty for items created
+	// without one. It only changes through POST /items/{id}/move.
+	Namespace string `json:"namespace,omitempty"`
+	// Pinned items are listed first by GET /items, whatever the sort.
+	Pinned bool `json:"pinned"`
+	// ViewCount is how often GET /items/{id} has served the item. It is kept
+	// by the ViewCounter, not the Storage, and filled in by the GET endpoints.
+	ViewCount int64 `json:"view_count"`
+	// Score is computeScore's engagement rating, filled in by the GET
+	// endpoints like ViewCount. It is never stored.
+	Score float64 `json:"score,omitempty"`
+	// CloneCount is how many items have been derived from this one with
+	// POST /items/derive.
+	CloneCount int `json:"clone_count"`
+	// Metadata holds free-form fields, such as the extra columns of a CSV import.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
-// In-memory "database"
+// lastModified is when the item list last changed; it starts at process start.
+// getItems serves it as Last-Modified so caches can revalidate cheaply.
 var (
-	items     []Item
-	itemsLock sync.Mutex // Mutex to make our slice-based DB thread-safe
+	lastModified     = time.Now()
+	lastModifiedLock sync.RWMutex
 )
 
+// touchLastModified records that the item list has just changed.
+func touchLastModified() {
+	lastModifiedLock.Lock()
+	defer lastModifiedLock.Unlock()
+	lastModified = time.Now()
+}
+
+// getLastModified returns when the item list last changed.
+func getLastModified() time.Time {
+	lastModifiedLock.RLock()
+	defer lastModifiedLock.RUnlock()
+	return lastModified
+}
+
+// statusClientClosedRequest is the non-standard status (popularised by nginx)
+// reported when the client gave up on the request before we could answer.
+const statusClientClosedRequest = 499
+
+// responseEnvelope wraps every JSON response when RESPONSE_ENVELOPE=true.
+// Successful responses fill Data, error responses fill Error.
+type responseEnvelope struct {
+	RequestID string      `json:"request_id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// newEnvelope starts an envelope for the response being written to w.
+// The request ID is read back from the header set by requestIDMiddleware.
+func newEnvelope(w http.ResponseWriter) responseEnvelope {
+	return responseEnvelope{
+		RequestID: w.Header().Get(requestIDHeader),
+		Timestamp: time.Now().UTC(),
+	}
+}
+
 // respondWithError is a helper function for sending JSON error messages
 func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+	if config.ResponseEnvelope {
+		envelope := newEnvelope(w)
+		envelope.Error = message
+		writeJSON(w, code, envelope)
+		return
+	}
+	writeJSON(w, code, map[string]string{"error": message})
+}
+
+// respondWithStorageError maps an error returned by the Storage layer to a JSON error response
+func respondWithStorageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errItemNotFound):
+		respondWithError(w, http.StatusNotFound, "Item not found")
+	case errors.Is(err, errDuplicateID):
+		respondWithError(w, http.StatusConflict, "Item ID already exists")
+	case errors.Is(err, context.Canceled):
+		respondWithError(w, statusClientClosedRequest, "Request cancelled")
+	case errors.Is(err, context.DeadlineExceeded):
+		respondWithError(w, http.StatusServiceUnavailable, "Request timed out")
+	default:
+		log.Printf("storage error: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Internal storage error")
+	}
 }
 
 // respondWithJSON is a helper function for sending JSON responses
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	payload = filterAdminFields(payload, isAdminView(w))
+	writeJSON(w, code, wrapPayload(w, payload))
+}
+
+// wrapPayload puts payload in a response envelope when RESPONSE_ENVELOPE=true
+func wrapPayload(w http.ResponseWriter, payload interface{}) interface{} {
+	if !config.ResponseEnvelope {
+		return payload
+	}
+	envelope := newEnvelope(w)
+	envelope.Data = payload
+	return envelope
+}
+
+// jsonpCallbackPattern matches safe JavaScript callback names such as "myFunc" or "app.onItems".
+// Anything else could be used to inject script into the response.
+var jsonpCallbackPattern = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$.]*$`)
+
+// respondWithJSONP is a helper for read endpoints that legacy browser clients call via JSONP.
+// With ?callback=fn the JSON is sent as the script "fn(<json>);", otherwise it behaves like respondWithJSON.
+func respondWithJSONP(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
+	callback := r.URL.Query().Get("callback")
+	if callback == "" {
+		respondWithJSON(w, code, payload)
+		return
+	}
+	if !jsonpCallbackPattern.MatchString(callback) {
+		respondWithError(w, http.StatusBadRequest, "Invalid callback name")
+		return
+	}
+
+	payload = filterAdminFields(payload, isAdminView(w))
+	response, err := json.Marshal(wrapPayload(w, payload))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to marshal JSON response")
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, "%s(%s);", callback, response)
+}
+
+// writeJSON marshals payload and writes it as-is with the given status code
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to marshal JSON response")
@@ -47,85 +190,749 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(response)
 }
 
+// htmlTagPattern matches anything that looks like an HTML tag.
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// sanitizeHTML strips all HTML tags from s.
+// Only the tags themselves are removed, the text between them is kept, so
+// "<script>alert(1)</script>foo" becomes "alert(1)foo". That text is inert
+// once the tags are gone.
+func sanitizeHTML(s string) string {
+	return htmlTagPattern.ReplaceAllString(s, "")
+}
+
+// sanitizeItem cleans the user-supplied fields of an item before it is stored.
+// Tags are always deduplicated; HTML sanitization is skipped when ALLOW_HTML=true.
+func sanitizeItem(item *Item) {
+	item.Tags = dedupeTags(item.Tags)
+	if config.AllowHTML {
+		return
+	}
+	item.Description = sanitizeHTML(item.Description)
+}
+
+// maxDescriptionLength is the longest description accepted, in characters.
+const maxDescriptionLength = 2000
+
+// validateItem checks an item's user-supplied fields before it is stored.
+// With VALIDATE_MARKDOWN=true a description must also render as markdown:
+// one that produces no output at all, such as a bare link reference
+// definition, is rejected.
+func validateItem(item Item) error {
+	if strings.TrimSpace(item.Name) == "" {
+		return errors.New("name is required")
+	}
+	if n := utf8.RuneCountInString(item.Description); n > maxDescriptionLength {
+		return fmt.Errorf("description is %d characters, the maximum is %d", n, maxDescriptionLength)
+	}
+	if err := validateTags(item.Tags); err != nil {
+		return err
+	}
+	if config.ValidateMarkdown && strings.TrimSpace(item.Description) != "" {
+		html, err := formatMarkdown(item.Description)
+		if err != nil || strings.TrimSpace(html) == "" {
+			return errors.New("description is not valid markdown")
+		}
+	}
+	return nil
+}
+
+// readOnlyFields are the JSON fields that clients may not send in an update.
+// They are managed by the server, so accepting them would only hide mistakes.
+var readOnlyFields = map[string]bool{"id": true, "created_at": true, "updated_at": true, "version": true, "likes": true, "last_request_id": true, "namespace": true, "pinned": true, "view_count": true, "score": true, "clone_count": true}
+
+// findReadOnlyField returns the first read-only field present in fields, or "".
+// JSON keys match struct fields case-insensitively, so the check does too.
+func findReadOnlyField(fields map[string]interface{}) string {
+	var found []string
+	for key := range fields {
+		if readOnlyFields[strings.ToLower(key)] {
+			found = append(found, key)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	sort.Strings(found)
+	return found[0]
+}
+
+// maxIDAttempts is how many random IDs createItem tries before giving up on collisions.
+const maxIDAttempts = 5
+
+// randKey is the context key under which an injected random source is stored.
+type randKey struct{}
+
+// lockedSource makes a rand.Source safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+// globalRand is the random source used when none has been injected.
+var globalRand = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)})
+
+// withRand returns a copy of ctx that carries rng as the random source for ID generation.
+// Tests use this to inject a seeded source; rng must not be shared between goroutines.
+func withRand(ctx context.Context, rng *rand.Rand) context.Context {
+	return context.WithValue(ctx, randKey{}, rng)
+}
+
+// randFromContext returns the random source stored in ctx, falling back to the global one.
+func randFromContext(ctx context.Context) *rand.Rand {
+	if rng, ok := ctx.Value(randKey{}).(*rand.Rand); ok {
+		return rng
+	}
+	return globalRand
+}
+
+// parseTimeParam reads an optional RFC 3339 timestamp from the query string.
+// A missing parameter returns the zero time.
+func parseTimeParam(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: must be an RFC 3339 timestamp", name)
+	}
+	return t, nil
+}
+
+// filterByRequestID keeps the items last written by the request with ID requestID.
+// An empty requestID keeps everything.
+func filterByRequestID(items []Item, requestID string) []Item {
+	if requestID == "" {
+		return items
+	}
+	filtered := []Item{}
+	for _, item := range items {
+		if item.LastRequestID == requestID {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// filterByNameSubstring keeps the items whose name contains substring,
+// ignoring case. An empty substring keeps everything.
+func filterByNameSubstring(items []Item, substring string) []Item {
+	if substring == "" {
+		return items
+	}
+	substring = strings.ToLower(substring)
+	filtered := []Item{}
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Name), substring) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// filterByNames keeps the items whose name is any of names, ignoring case.
+// No names keeps everything.
+func filterByNames(items []Item, names []string) []Item {
+	if len(names) == 0 {
+		return items
+	}
+	filtered := []Item{}
+	for _, item := range items {
+		if slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(item.Name, name) }) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// filterByNamespace keeps the items in namespace. An empty namespace keeps everything.
+func filterByNamespace(items []Item, namespace string) []Item {
+	if namespace == "" {
+		return items
+	}
+	filtered := []Item{}
+	for _, item := range items {
+		if item.Namespace == namespace {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// filterByDateRange keeps the items created strictly after `after` and strictly
+// before `before`. A zero time leaves that side of the range open.
+func filterByDateRange(items []Item, after, before time.Time) []Item {
+	if after.IsZero() && before.IsZero() {
+		return items
+	}
+	filtered := []Item{}
+	for _, item := range items {
+		if !after.IsZero() && !item.CreatedAt.After(after) {
+			continue
+		}
+		if !before.IsZero() && !item.CreatedAt.Before(before) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
 // --- Handler Functions ---
 
 // getItems (GET /items)
-// This retrieves the full list of items.
+// This retrieves the full list of items, optionally sorted with ?sort=<field>&order=asc|desc.
+// Without ?sort= the DEFAULT_SORT and DEFAULT_ORDER settings apply.
+// ?request_id=<id> keeps only the items last written by that request.
+// ?namespace=<name> keeps only the items in that namespace.
+// ?name=<text> keeps the items whose name contains the text, and
+// ?names=A&names=B the items named exactly A or B, both ignoring case.
+// Pinned items come first; ?include_pinned=false leaves them out.
+// ?page=N&per_page=M returns one page, with an X-Page-Token header for the
+// next one when there is one; ?page_token=<token> fetches that page.
+// ?format=map returns a JSON object keyed by item ID instead of an array;
+// objects have no order, so the sort is lost. X-Total-Count is the number of
+// matching items before pagination.
+// Clients that prefer text/csv in their Accept header get the list as a CSV download,
+// and those preferring application/scim+json a SCIM 2.0 ListResponse.
+// It answers 304 Not Modified when nothing changed since If-Modified-Since.
 func getItems(w http.ResponseWriter, r *http.Request) {
-	itemsLock.Lock()
-	defer itemsLock.Unlock()
+	// HTTP dates only have second precision.
+	// Read this before the items so the header is never newer than the data.
+	modified := getLastModified().Truncate(time.Second)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 
-	respondWithJSON(w, http.StatusOK, items)
+	query := r.URL.Query()
+	after, err := parseTimeParam(query, "created_after")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	before, err := parseTimeParam(query, "created_before")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, paginated, err := parsePageParams(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "array" && format != "map" {
+		respondWithError(w, http.StatusBadRequest, "format must be 'array' or 'map'")
+		return
+	}
+
+	items, err := storeFromContext(r.Context()).GetAll(r.Context())
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	withViewCounts(items)
+	withScores(items)
+	items = filterByDateRange(items, after, before)
+	items = filterByRequestID(items, query.Get("request_id"))
+	items = filterByNamespace(items, query.Get("namespace"))
+	items = filterByNameSubstring(items, query.Get("name"))
+	items = filterByNames(items, query["names"])
+	if query.Get("include_pinned") == "false" {
+		items = filterUnpinned(items)
+	}
+	field, order := query.Get("sort"), query.Get("order")
+	if query.Get("page_token") != "" {
+		// The token keeps the sort the first page was served with
+		field, order = page.Sort, page.Order
+	} else if field == "" {
+		field = config.DefaultSort
+		if order == "" {
+			order = config.DefaultOrder
+		}
+	}
+	if err := sortItems(items, field, order); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pinnedFirst(items)
+	total, startIndex := len(items), 1
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if paginated {
+		startIndex = (page.Page-1)*page.PerPage + 1
+		page.Total, page.Sort, page.Order = len(items), field, order
+		if next, ok := page.next(); ok {
+			w.Header().Set(pageTokenHeader, generatePageToken(next))
+		}
+		items = paginate(items, page)
+	}
+	pushItems(w, r, items)
+
+	switch negotiate(r.Header.Get("Accept"), "application/json", "text/csv", scimMediaType) {
+	case scimMediaType:
+		writeSCIM(w, http.StatusOK, scimList(items, total, startIndex, isAdminView(w)))
+		return
+	case "text/csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="items.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err := renderCSV(w, items, isAdminView(w)); err != nil {
+			log.Printf("failed to write CSV: %v", err)
+		}
+		return
+	}
+	if format == "map" {
+		respondWithJSONP(w, r, http.StatusOK, renderItemsMap(items))
+		return
+	}
+	respondWithJSONP(w, r, http.StatusOK, items)
+}
+
+// renderItemsMap keys items by ID, for ?format=map.
+func renderItemsMap(items []Item) map[string]Item {
+	byID := make(map[string]Item, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	return byID
 }
 
 // getItem (GET /items/{id})
-// This retrieves a single item by its ID.
+// This retrieves a single item by its ID and counts it as viewed.
+// With READ_YOUR_WRITES=true it answers 202 until the write whose
+// X-Write-Token came back in X-Consistency is visible.
 func getItem(w http.ResponseWriter, r *http.Request) {
-	itemsLock.Lock()
-	defer itemsLock.Unlock()
-
 	params := mux.Vars(r) // Get URL parameters
 	id := params["id"]
 
+	item, err := storeFromContext(r.Context()).GetByID(r.Context(), id)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	if !checkConsistency(w, r, item) {
+		return
+	}
+	item.ViewCount = views.Increment(id)
+	item.Score = computeScore(item)
+	recordAccess(r, id, "read")
+	respondWithJSONP(w, r, http.StatusOK, item)
+}
+
+// formatItemSummary renders an item as a short plain-text message for chat or email.
+func formatItemSummary(item Item) string {
+	tags := "(none)"
+	if len(item.Tags) > 0 {
+		tags = strings.Join(item.Tags, ", ")
+	}
+	return fmt.Sprintf("Item #%s: %s\n%s\nCreated: %s\nTags: %s\n",
+		item.ID, item.Name, item.Description, item.CreatedAt.Format("2006-01-02"), tags)
+}
+
+// getItemSummary (GET /items/{id}/summary)
+// This returns formatItemSummary as text/plain for clients that accept it,
+// and the item as JSON, like getItem, for everyone else.
+func getItemSummary(w http.ResponseWriter, r *http.Request) {
+	if negotiate(r.Header.Get("Accept"), "application/json", "text/plain") != "text/plain" {
+		getItem(w, r)
+		return
+	}
+	params := mux.Vars(r)
+	id := params["id"]
+
+	item, err := storeFromContext(r.Context()).GetByID(r.Context(), id)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, formatItemSummary(item))
+}
+
+// getRandomItem (GET /items/random)
+// This returns one randomly chosen item. IDs listed in ?exclude=id1,id2
+// are left out of the draw.
+func getRandomItem(w http.ResponseWriter, r *http.Request) {
+	items, err := storeFromContext(r.Context()).GetAll(r.Context())
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+
+	excluded := make(map[string]bool)
+	if exclude := r.URL.Query().Get("exclude"); exclude != "" {
+		for _, id := range strings.Split(exclude, ",") {
+			excluded[strings.TrimSpace(id)] = true
+		}
+	}
+
+	var pool []Item
 	for _, item := range items {
-		if item.ID == id {
-			respondWithJSON(w, http.StatusOK, item)
-			return
+		if !excluded[item.ID] {
+			pool = append(pool, item)
+		}
+	}
+	if len(pool) == 0 {
+		respondWithError(w, http.StatusNotFound, "no items available")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, pool[randFromContext(r.Context()).Intn(len(pool))])
+}
+
+// createWithNewID stores item under a freshly generated ID.
+func createWithNewID(ctx context.Context, item Item) (Item, error) {
+	// IDs can collide (random ones especially), so pick a new one if the store already has it.
+	var created Item
+	var err error
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		item.ID = newItemID(ctx)
+		if created, err = storeFromContext(ctx).Create(ctx, item); !errors.Is(err, errDuplicateID) {
+			break
+		}
+	}
+	return created, err
+}
+
+// errNameTaken is reported for a new item whose name is already used in its
+// namespace while UNIQUE_NAMES=true.
+var errNameTaken = errors.New("name already exists")
+
+// decodeNewItem reads the item a POST /items request describes: the body on
+// top of any ?template=, in the ?namespace= if one is given. Errors are
+// meant for the client. The raw body is returned for schema checks.
+func decodeNewItem(r *http.Request) (Item, []byte, error) {
+	var item Item
+	if name := r.URL.Query().Get("template"); name != "" {
+		t, ok := templates.Get(name)
+		if !ok {
+			return Item{}, nil, fmt.Errorf("unknown template '%s'", name)
+		}
+		// Decoding on top of the template only overwrites the fields present in the body
+		t.apply(&item)
+	}
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err == nil && isSCIMRequest(r) {
+		if body, err = scimToItemJSON(body); err != nil {
+			return Item{}, nil, err
 		}
 	}
-	respondWithError(w, http.StatusNotFound, "Item not found")
+	if err != nil || json.Unmarshal(body, &item) != nil {
+		return Item{}, nil, errors.New("Invalid request payload")
+	}
+	if field := findComputedField(body); field != "" {
+		return Item{}, nil, fmt.Errorf("field '%s' is read-only", field)
+	}
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		item.Namespace = namespace
+	}
+	return item, body, nil
 }
 
 // createItem (POST /items)
 // This covers your "add" and "post" request. It creates a new item.
+// With ?template=<name> the template's fields are used for anything the body leaves out.
+// ?namespace=<name> puts the item in that namespace, overriding the body's.
+// With SCHEMA_FILE set, the body must also match that JSON Schema.
+// With X-Dedup-Name: true, an existing item with the same name (ignoring case)
+// in the same namespace is returned with 200 and X-Deduplicated: true instead.
+// Otherwise, with UNIQUE_NAMES=true, such a name is rejected with 409.
+// A Content-Type: application/scim+json body is read as a SCIM resource,
+// and the created item is returned as one.
 func createItem(w http.ResponseWriter, r *http.Request) {
-	var item Item
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&item); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	item, body, err := decodeNewItem(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	defer r.Body.Close()
+	if details := checkItemSchema(body); details != nil {
+		respondWithSchemaErrors(w, details)
+		return
+	}
+	if err := validateItem(item); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	sanitizeItem(&item)
+	item.CreatedAt = time.Now().UTC()
+	item.LastRequestID = GetRequestID(r.Context())
 
-	itemsLock.Lock()
-	defer itemsLock.Unlock()
+	dedup := r.Header.Get(dedupNameHeader) == "true"
+	if dedup || config.UniqueNames {
+		dedupCreateLock.Lock()
+		defer dedupCreateLock.Unlock()
+		existing, found, err := findByName(r.Context(), storeFromContext(r.Context()), item.Namespace, item.Name)
+		if err != nil {
+			respondWithStorageError(w, err)
+			return
+		}
+		if found && dedup {
+			w.Header().Set(deduplicatedHeader, "true")
+			respondWithJSON(w, http.StatusOK, existing)
+			return
+		}
+		if found {
+			respondWithError(w, http.StatusConflict, errNameTaken.Error())
+			return
+		}
+	}
 
-	// Simple ID generation (in a real app, use UUIDs or database serials)
-	item.ID = strconv.Itoa(rand.Intn(1000000))
-	items = append(items, item)
+	created, err := createWithNewID(r.Context(), item)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemCreated, created)
+	setWriteToken(w, created)
 
-	respondWithJSON(w, http.StatusCreated, item)
+	if isSCIMRequest(r) {
+		writeSCIM(w, http.StatusCreated, toSCIMResource(created, isAdminView(w)))
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, created)
 }
 
 // updateItem (PUT /items/{id})
 // This covers your "update" request. It modifies an existing item.
+// With STRICT_DECODE=true, fields that Item does not have are rejected.
+// With SCHEMA_FILE set, the body must also match that JSON Schema.
 func updateItem(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
+	// Decode into a map first so we can see exactly which fields were sent
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	var fields map[string]interface{}
+	if err != nil || json.Unmarshal(body, &fields) != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if field := findReadOnlyField(fields); field != "" {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("field '%s' is read-only", field))
+		return
+	}
+
 	var updatedItem Item
-	decoder := json.NewDecoder(r.Body)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if config.StrictDecode {
+		decoder.DisallowUnknownFields()
+	}
 	if err := decoder.Decode(&updatedItem); err != nil {
+		message := "Invalid request payload"
+		if config.StrictDecode {
+			message = err.Error()
+		}
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+	if details := checkItemSchema(body); details != nil {
+		respondWithSchemaErrors(w, details)
+		return
+	}
+	if err := validateItem(updatedItem); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	sanitizeItem(&updatedItem)
+	requestID := GetRequestID(r.Context())
+
+	item, err := storeFromContext(r.Context()).Update(r.Context(), id, func(item *Item) error {
+		item.Name = updatedItem.Name
+		item.Description = updatedItem.Description
+		item.Tags = updatedItem.Tags
+		item.LastRequestID = requestID
+		// Note: We keep the original ID
+		return nil
+	})
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemUpdated, item)
+	setWriteToken(w, item)
+	recordAccess(r, id, "update")
+
+	respondWithJSON(w, http.StatusOK, item)
+}
+
+// deriveItem (POST /items/derive)
+// This creates a new item from a copy of an existing one, with the fields in
+// "overrides" replaced. The copy starts fresh: new ID, creation time, version,
+// likes and clone count, and it is not pinned. The source's CloneCount goes up by one.
+func deriveItem(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		SourceID  string                 `json:"source_id"`
+		Overrides map[string]interface{} `json:"overrides"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 	defer r.Body.Close()
+	if field := findReadOnlyField(request.Overrides); field != "" {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("field '%s' is read-only", field))
+		return
+	}
 
-	itemsLock.Lock()
-	defer itemsLock.Unlock()
+	item, err := storeFromContext(r.Context()).GetByID(r.Context(), request.SourceID)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	// Round-trip the overrides so they are applied with the same rules as a request body
+	overrides, _ := json.Marshal(request.Overrides)
+	if err := json.Unmarshal(overrides, &item); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid overrides")
+		return
+	}
+	if err := validateItem(item); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	sanitizeItem(&item)
+	item.CreatedAt = time.Now().UTC()
+	item.Likes = 0
+	item.Pinned = false
+	item.CloneCount = 0
+	item.LastRequestID = GetRequestID(r.Context())
 
-	for index, item := range items {
-		if item.ID == id {
-			// Found the item, now update it
-			items[index].Name = updatedItem.Name
-			items[index].Description = updatedItem.Description
-			// Note: We keep the original ID
-			respondWithJSON(w, http.StatusOK, items[index])
-			return
+	created, err := createWithNewID(r.Context(), item)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemCreated, created)
+	source, err := storeFromContext(r.Context()).Update(withCounterUpdate(r.Context()), request.SourceID, func(item *Item) error {
+		item.CloneCount++
+		return nil
+	})
+	if err != nil {
+		// The copy exists either way, so report it and leave the count behind
+		log.Printf("counting clone of item %s: %v", request.SourceID, err)
+	} else {
+		recordItemChange(r.Context(), eventItemUpdated, source)
+	}
+
+	respondWithJSON(w, http.StatusCreated, created)
+}
+
+// errNameMismatch is returned by renameItem's update when the current name is not the expected one.
+var errNameMismatch = errors.New("current name does not match expected_name")
+
+// renameItem (POST /items/{id}/rename)
+// This changes an item's name only if it still has the name the client expects,
+// so two clients cannot silently overwrite each other's rename.
+// The new name goes through the same checks as an update.
+func renameItem(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	var request struct {
+		ExpectedName string `json:"expected_name"`
+		NewName      string `json:"new_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if request.NewName == "" {
+		respondWithError(w, http.StatusBadRequest, "new_name is required")
+		return
+	}
+
+	requestID := GetRequestID(r.Context())
+
+	// The check and the change happen inside one Update, so no other writer can get in between
+	var invalid error
+	item, err := storeFromContext(r.Context()).Update(r.Context(), id, func(item *Item) error {
+		if item.Name != request.ExpectedName {
+			return errNameMismatch
 		}
+		renamed := *item
+		renamed.Name = request.NewName
+		if invalid = validateItem(renamed); invalid != nil {
+			return invalid
+		}
+		sanitizeItem(&renamed)
+		renamed.LastRequestID = requestID
+		*item = renamed
+		return nil
+	})
+	if errors.Is(err, errNameMismatch) {
+		respondWithError(w, http.StatusConflict, err.Error())
+		return
 	}
+	if invalid != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, invalid.Error())
+		return
+	}
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemUpdated, item)
 
-	respondWithError(w, http.StatusNotFound, "Item not found")
+	respondWithJSON(w, http.StatusOK, item)
+}
+
+// moveItem (POST /items/{id}/move)
+// This moves an item to the namespace in {"target_namespace": ...}.
+func moveItem(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	var request struct {
+		TargetNamespace string `json:"target_namespace"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if request.TargetNamespace == "" {
+		respondWithError(w, http.StatusBadRequest, "target_namespace is required")
+		return
+	}
+	requestID := GetRequestID(r.Context())
+
+	item, err := storeFromContext(r.Context()).Update(r.Context(), id, func(item *Item) error {
+		item.Namespace = request.TargetNamespace
+		item.LastRequestID = requestID
+		return nil
+	})
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemUpdated, item)
+
+	respondWithJSON(w, http.StatusOK, item)
 }
 
 // deleteItem (DELETE /items/{id})
@@ -134,51 +941,309 @@ func deleteItem(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	id := params["id"]
 
-	itemsLock.Lock()
-	defer itemsLock.Unlock()
-
-	for index, item := range items {
-		if item.ID == id {
-			// Remove the item from the slice
-			// This syntax means "append everything before this index...
-			// with everything after this index."
-			items = append(items[:index], items[index+1:]...)
-			respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "id_deleted": id})
-			return
-		}
+	if err := storeFromContext(r.Context()).Delete(r.Context(), id); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemDeleted, Item{ID: id})
+	setWriteToken(w, Item{ID: id, UpdatedAt: time.Now()})
+	recordAccess(r, id, "delete")
+	if !isDryRun(r.Context()) {
+		attachments.DeleteItem(id)
+		views.Delete(id)
+		notes.DeleteItem(id)
+		links.DeleteItem(id)
 	}
 
-	respondWithError(w, http.StatusNotFound, "Item not found")
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "id_deleted": id})
 }
 
 // --- Main Function ---
 
-func main() {
-	// Initialize the router
-	r := mux.NewRouter()
+// namedMiddleware is one layer of the router's middleware stack.
+type namedMiddleware struct {
+	name       string
+	middleware mux.MiddlewareFunc
+}
+
+// middlewareStack lists the middleware newRouter installs, outermost first,
+// leaving out the ones the current config turns off.
+func middlewareStack() []namedMiddleware {
+	var stack []namedMiddleware
+	use := func(name string, mw mux.MiddlewareFunc) {
+		stack = append(stack, namedMiddleware{name, mw})
+	}
+	use("tracing", tracingMiddleware())
+	use("requestID", requestIDMiddleware)
+	use("timing", timingMiddleware)
+	use("inFlight", inFlightMiddleware)
+	use("requestCount", requestCountMiddleware)
+	use("maintenance", maintenanceMiddleware)
+	if config.MaxURLLength > 0 {
+		use("urlLength", urlLengthLimitMiddleware(config.MaxURLLength))
+	}
+	use("compression", compressionMiddleware) // outside the size limit, which counts uncompressed bytes
+	if config.MaxResponseBytes > 0 {
+		use("responseSize", responseSizeLimitMiddleware(config.MaxResponseBytes))
+	}
+	if len(config.BlockedCIDRs) > 0 {
+		use("ipBlocklist", ipBlocklistMiddleware(config.BlockedCIDRs))
+	}
+	if config.RateLimitRPS > 0 {
+		use("rateLimit", rateLimitMiddleware(config.RateLimitRPS, max(config.RateLimitBurst, 1)))
+	}
+	if config.MaxConcurrentRequests > 0 {
+		use("concurrencyLimit", concurrencyLimitMiddleware(config.MaxConcurrentRequests))
+	}
+	if len(config.CORSAllowedOrigins) > 0 {
+		// Preflights carry no API key, so this must run before apiKeyMiddleware
+		use("cors", corsMiddleware(config.CORSAllowedOrigins, config.CORSMaxAge))
+	}
+	use("apiKey", apiKeyMiddleware)
+	use("adminView", adminViewMiddleware)
+	use("charset", charsetNormalizationMiddleware)
+	if config.MaxJSONDepth > 0 {
+		use("jsonDepth", jsonDepthLimitMiddleware(config.MaxJSONDepth))
+	}
+	use("storeSwap", storeSwapMiddleware) // must run before dryRunMiddleware wraps the store
+	use("dryRun", dryRunMiddleware)
+	use("lock", lockMiddleware)
+	if config.ArtificialDelay > 0 {
+		use("artificialDelay", artificialDelayMiddleware(config.ArtificialDelay))
+	}
+	return stack
+}
 
-	// Add some mock data
-	items = append(items, Item{ID: "1", Name: "Default Item 1", Description: "This is the first item"})
-	items = append(items, Item{ID: "2", Name: "Default Item 2", Description: "This is the second item"})
-	items = append(items, Item{ID: "3", Name: "Default Item 3", Description: "This is the third item"})
-	items = append(items, Item{ID: "4", Name: "Default Item 4", Description: "This is the fourth item"})
-	items = append(items, Item{ID: "5", Name: "Default Item 5", Description: "This is the fifth item"})
+// newRouter builds the router with every API endpoint registered.
+func newRouter() *mux.Router {
+	r := mux.NewRouter()
+	for _, m := range middlewareStack() {
+		r.Use(m.middleware)
+	}
 
 	// Define API endpoints and map them to handler functions
 	// Your "get" functions
 	r.HandleFunc("/items", getItems).Methods("GET")
+	r.HandleFunc("/items/random", getRandomItem).Methods("GET")      // Must come before /items/{id}
+	r.HandleFunc("/items/stats", statsItems).Methods("GET")          // Must come before /items/{id}
+	r.HandleFunc("/items/featured", getFeaturedItems).Methods("GET") // Must come before /items/{id}
+	r.HandleFunc("/items/duplicates", getDuplicates).Methods("GET")  // Must come before /items/{id}
 	r.HandleFunc("/items/{id}", getItem).Methods("GET")
+	r.HandleFunc("/items/{id}/summary", getItemSummary).Methods("GET")
+	r.HandleFunc("/items/{id}/history", getItemHistory).Methods("GET")
 
 	// Your "add" / "post" function
 	r.HandleFunc("/items", createItem).Methods("POST")
+	r.HandleFunc("/items/bulk", createItemsBulk).Methods("POST")
+	r.HandleFunc("/items/validate", validateNewItem).Methods("POST")
+	r.HandleFunc("/items/import/jsonl", importItemsJSONL).Methods("POST")
+	r.HandleFunc("/items/import/csv", importItemsCSV).Methods("POST")
+	r.HandleFunc("/items/derive", deriveItem).Methods("POST")
+	r.HandleFunc("/items/diff", diffItems).Methods("POST")
+	r.HandleFunc("/items/merge", mergeItems).Methods("POST")
+	r.HandleFunc("/items/reorder", reorderItems).Methods("POST")
 
 	// Your "update" function
+	r.HandleFunc("/items/bulk", updateItemsBulk).Methods("PUT") // Must come before /items/{id}
 	r.HandleFunc("/items/{id}", updateItem).Methods("PUT")
+	r.HandleFunc("/items/{id}/rename", renameItem).Methods("POST")
+	r.HandleFunc("/items/{id}/move", moveItem).Methods("POST")
+	r.HandleFunc("/items/{id}/duplicate-check", checkDuplicates).Methods("POST")
+	r.HandleFunc("/items/{id}/tags/replace", replaceItemTags).Methods("POST")
+	r.HandleFunc("/items/{id}/history/rollback", rollbackItem).Methods("POST")
+	r.HandleFunc("/items/{id}/lock", lockItem).Methods("POST")
+	r.HandleFunc("/items/{id}/like", likeItem).Methods("POST")
+	r.HandleFunc("/items/{id}/unlike", unlikeItem).Methods("POST")
+	r.HandleFunc("/items/{id}/pin", pinItem).Methods("POST")
+	r.HandleFunc("/items/{id}/unpin", unpinItem).Methods("POST")
+	r.HandleFunc("/items/{id}/lock", unlockItem).Methods("DELETE")
 
 	// Your "delete" function
+	r.HandleFunc("/items", deleteItemsMatching).Methods("DELETE")
 	r.HandleFunc("/items/{id}", deleteItem).Methods("DELETE")
 
+	// Item attachments
+	r.HandleFunc("/items/{id}/attachments", uploadAttachment).Methods("POST")
+	r.HandleFunc("/items/{id}/attachments", listAttachments).Methods("GET")
+	r.HandleFunc("/items/{id}/attachments/{aid}", downloadAttachment).Methods("GET")
+	r.HandleFunc("/items/{id}/attachments/{aid}", deleteAttachment).Methods("DELETE")
+
+	// Item notes
+	r.HandleFunc("/items/{id}/notes", addNote).Methods("POST")
+	r.HandleFunc("/items/{id}/notes", listNotes).Methods("GET")
+	r.HandleFunc("/items/{id}/notes/{nid}", deleteNote).Methods("DELETE")
+
+	// Item links
+	r.HandleFunc("/items/{id}/links", addLink).Methods("POST")
+	r.HandleFunc("/items/{id}/links", listLinks).Methods("GET")
+	r.HandleFunc("/items/{id}/links/{target_id}/{relation_type}", deleteLink).Methods("DELETE")
+	r.HandleFunc("/items/{id}", linkItem).Methods("LINK")
+	r.HandleFunc("/items/{id}", unlinkItem).Methods("UNLINK")
+	r.HandleFunc("/items/{id}/graph", getItemGraph).Methods("GET")
+
+	// Item access log, for admins
+	r.HandleFunc("/items/{id}/access_log", getAccessLog).Methods("GET")
+
+	// Item templates
+	r.HandleFunc("/templates", listTemplates).Methods("GET")
+	r.HandleFunc("/templates", createTemplate).Methods("POST")
+	r.HandleFunc("/templates/{name}", deleteTemplate).Methods("DELETE")
+
+	// Named snapshots of every item, for dev and test setups
+	r.HandleFunc("/snapshots", listSnapshots).Methods("GET")
+	r.HandleFunc("/snapshots", createSnapshot).Methods("POST")
+	r.HandleFunc("/snapshots/{name}", getSnapshot).Methods("GET")
+	r.HandleFunc("/snapshots/{name}/restore", restoreSnapshot).Methods("POST")
+	r.HandleFunc("/snapshots/{name}", deleteSnapshot).Methods("DELETE")
+
+	// Search across items and templates
+	r.HandleFunc("/search", search).Methods("GET")
+
+	// Item cache
+	r.HandleFunc("/cache/warm", warmCache).Methods("POST")
+
+	// Crawler support, for deployments exposed to search engines
+	r.HandleFunc("/robots.txt", serveRobots).Methods("GET")
+	r.HandleFunc("/sitemap.xml", serveSitemap).Methods("GET")
+
+	// Health check and metrics for operators
+	r.HandleFunc("/health", healthCheck).Methods("GET")
+	r.HandleFunc("/metrics", serveMetrics).Methods("GET")
+	if config.EnableDebug {
+		r.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+	}
+
+	// Live item change events
+	r.HandleFunc("/ws/items", serveItemsWS).Methods("GET")
+	r.HandleFunc("/feed", getFeed).Methods("GET") // long-polling for clients without WebSockets
+
+	// Admin endpoints, protected by ADMIN_TOKEN
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(adminAuthMiddleware)
+	admin.HandleFunc("/apikeys/{key}", addAPIKey).Methods("POST")
+	admin.HandleFunc("/apikeys/{key}", removeAPIKey).Methods("DELETE")
+	admin.HandleFunc("/migrate", migrateStorage).Methods("POST")
+	admin.HandleFunc("/gc", collectGarbage).Methods("POST")
+	admin.HandleFunc("/maintenance", setMaintenance).Methods("POST")
+	admin.HandleFunc("/featured", setFeaturedItems).Methods("POST")
+	admin.HandleFunc("/featured/{id}", unfeatureItem).Methods("DELETE")
+
+	// Middleware only runs for matched routes, so give CORS preflights one
+	if len(config.CORSAllowedOrigins) > 0 {
+		r.PathPrefix("/").Methods("OPTIONS").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	applyRouteTimeouts(r, config.RouteTimeouts)
+	return r
+}
+
+// newMockStore returns an in-memory store with some mock data
+func newMockStore() *MemoryStore {
+	now := time.Now().UTC()
+	return NewMemoryStore(
+		Item{ID: "1", Name: "Default Item 1", Description: "This is the first item", CreatedAt: now},
+		Item{ID: "2", Name: "Default Item 2", Description: "This is the second item", CreatedAt: now},
+		Item{ID: "3", Name: "Default Item 3", Description: "This is the third item", CreatedAt: now},
+		Item{ID: "4", Name: "Default Item 4", Description: "This is the fourth item", CreatedAt: now},
+		Item{ID: "5", Name: "Default Item 5", Description: "This is the fifth item", CreatedAt: now},
+	)
+}
+
+// openStore returns the storage backend selected by the configuration:
+// PostgreSQL for DATABASE_URL, SQLite for DB_PATH, otherwise mock data in memory.
+func openStore(cfg ServerConfig) (Storage, error) {
+	switch {
+	case cfg.DatabaseURL != "":
+		log.Println("Using PostgreSQL storage")
+		return NewPostgresStore(cfg.DatabaseURL)
+	case cfg.DBPath != "":
+		log.Printf("Using SQLite storage at %s", cfg.DBPath)
+		return NewSQLiteStore(cfg.DBPath)
+	default:
+		return newMockStore(), nil
+	}
+}
+
+// wrapStore puts backend behind the wrappers every store is served through:
+// tracing, the mirror into shadow when it is not nil, the CACHE_SIZE cache
+// and the name index, which is built from the items already in backend.
+func wrapStore(ctx context.Context, backend, shadow Storage) (*NameIndex, error) {
+	backend = NewTracedStore(backend)
+	if shadow != nil {
+		backend = NewShadowStore(backend, shadow)
+	}
+	if config.CacheSize > 0 {
+		backend = NewCachedStore(backend, config.CacheSize)
+	}
+	index := NewNameIndex(backend)
+	if err := index.Rebuild(ctx); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func main() {
+	// Read settings from the environment
+	config = loadConfig()
+
+	// Pick the storage backend
+	backend, err := openStore(config)
+	if err != nil {
+		log.Fatalf("failed to open storage: %v", err)
+	}
+	var shadow Storage
+	if config.ShadowDBPath != "" {
+		log.Printf("Shadowing storage into SQLite at %s", config.ShadowDBPath)
+		if shadow, err = NewSQLiteStore(config.ShadowDBPath); err != nil {
+			log.Fatalf("failed to open shadow storage: %v", err)
+		}
+	}
+	index, err := wrapStore(context.Background(), backend, shadow)
+	if err != nil {
+		log.Fatalf("failed to build the name index: %v", err)
+	}
+	store = NewHistoryStore(index)
+	if idGenerator, err = newIDGenerator(context.Background(), config, index); err != nil {
+		log.Fatalf("failed to set up ID generation: %v", err)
+	}
+	if _, err := parseCIDRs(config.BlockedCIDRs); err != nil {
+		log.Fatalf("invalid BLOCKED_CIDRS: %v", err)
+	}
+	if config.SchemaFile != "" {
+		if itemSchema, err = loadItemSchema(config.SchemaFile); err != nil {
+			log.Fatalf("failed to load SCHEMA_FILE: %v", err)
+		}
+	}
+
+	// Require API keys when any are configured
+	if len(config.APIKeys) > 0 || config.RedisAddr != "" {
+		var rdb *redis.Client
+		if config.RedisAddr != "" {
+			rdb = redis.NewClient(&redis.Options{Addr: config.RedisAddr})
+		}
+		apiKeys = NewAPIKeyStore(config.APIKeys, rdb)
+		if rdb != nil {
+			healthChecks["redis"] = apiKeys
+		}
+	}
+
+	// Start delivering item events to WebSocket clients
+	go hub.Run()
+	events = NewMessageQueue(config.QueueDepth, config.QueueWorkers)
+	events.Subscribe(func(msg Message) { hub.Publish(msg.Event) })
+	events.Start()
+
+	// Initialize the router
+	r := newRouter()
+
 	// Start the server
+	if config.TLSCertFile != "" {
+		server := &http.Server{Addr: ":8443", Handler: r, TLSConfig: buildTLSConfig()}
+		log.Println("🚀 Server starting with HTTPS on port 8443...")
+		log.Fatal(server.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile))
+	}
 	log.Println("🚀 Server starting on port 8080...")
 	log.Fatal(http.ListenAndServe(":8080", r))
 }
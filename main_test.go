@@ -2,10 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -13,13 +20,19 @@ import (
 // resetGlobalItems is a helper function to reset our in-memory DB before each test.
 // This is crucial for making tests independent and repeatable.
 func resetGlobalItems() {
-	itemsLock.Lock()
-	defer itemsLock.Unlock()
-	// Clear and re-populate the slice with known mock data
-	items = []Item{
-		{ID: "1", Name: "Mock Item 1", Description: "First mock item"},
-		{ID: "2", Name: "Mock Item 2", Description: "Second mock item"},
-	}
+	// Replace the store with a fresh one holding known mock data
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "Mock Item 1", Description: "First mock item"},
+		Item{ID: "2", Name: "Mock Item 2", Description: "Second mock item"},
+	)
+	views = &ViewCounter{}
+}
+
+// storedItems returns a snapshot of everything currently in the store,
+// so tests can check global state after calling a handler.
+func storedItems() []Item {
+	items, _ := store.GetAll(context.Background())
+	return items
 }
 
 // TestGetItems (GET /items)
@@ -55,6 +68,93 @@ func TestGetItems(t *testing.T) {
 	}
 }
 
+// TestGetItemsCSV (GET /items with Accept: text/csv)
+func TestGetItemsCSV(t *testing.T) {
+	resetGlobalItems()
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		getItems(rr, req)
+		return rr
+	}
+
+	jsonResp := get("application/json")
+	var items []Item
+	if err := json.NewDecoder(jsonResp.Body).Decode(&items); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+
+	rr := get("application/json;q=0.5, text/csv")
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("handler returned wrong content type: got %q", ct)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); cd != `attachment; filename="items.csv"` {
+		t.Errorf("handler returned wrong content disposition: got %q", cd)
+	}
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV body: %v", err)
+	}
+	if len(records) == 0 || strings.Join(records[0][:3], ",") != "id,name,description" {
+		t.Fatalf("CSV does not start with the expected header: %v", records)
+	}
+	if rows := len(records) - 1; rows != len(items) {
+		t.Errorf("CSV has %d rows, JSON has %d items", rows, len(items))
+	}
+
+	// A lower q-value for CSV keeps the JSON response
+	if ct := get("text/csv;q=0.2, application/json").Header().Get("Content-Type"); strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("CSV returned although JSON was preferred: %q", ct)
+	}
+}
+
+// TestRenderCSV checks that formula-like cells are neutralised.
+func TestRenderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderCSV(&buf, []Item{{ID: "1", Name: "=SUM(A1:A2)", Description: "plain"}}, true); err != nil {
+		t.Fatalf("renderCSV failed: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV body: %v", err)
+	}
+	if got := records[1][1]; got != "'=SUM(A1:A2)" {
+		t.Errorf("formula cell was not escaped: got %q", got)
+	}
+	if got := records[1][2]; got != "plain" {
+		t.Errorf("plain cell was changed: got %q", got)
+	}
+}
+
+// TestNegotiate checks Accept header matching with q-values and wildcards.
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"text/csv", "text/csv"},
+		{"text/*", "text/csv"},
+		{"*/*", "application/json"},
+		{"application/json;q=0.9, text/csv", "text/csv"},
+		{"text/csv;q=0.1, */*;q=0.5", "application/json"},
+		{"text/*;q=0.9, text/csv;q=0", "application/json"},
+		{"image/png", "application/json"},
+	}
+	for _, tt := range tests {
+		if got := negotiate(tt.accept, "application/json", "text/csv"); got != tt.want {
+			t.Errorf("negotiate(%q): got %q want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
 // TestGetItem (GET /items/{id})
 func TestGetItem(t *testing.T) {
 	// Sub-test for "Item Found"
@@ -119,7 +219,7 @@ func TestCreateItem(t *testing.T) {
 	// Sub-test for "Valid Payload"
 	t.Run("Valid Payload", func(t *testing.T) {
 		resetGlobalItems()
-		initialLength := len(items)
+		initialLength := len(storedItems())
 
 		// Create our request body (JSON)
 		payload := []byte(`{"name":"New Item", "description":"A new test item"}`)
@@ -149,18 +249,17 @@ func TestCreateItem(t *testing.T) {
 		}
 
 		// 3. Check global state (was it actually added?)
-		itemsLock.Lock()
+		items := storedItems()
 		if len(items) != initialLength+1 {
 			t.Errorf("item was not added to the slice: got len %d want %d",
 				len(items), initialLength+1)
 		}
-		itemsLock.Unlock()
 	})
 
 	// Sub-test for "Invalid Payload"
 	t.Run("Invalid Payload", func(t *testing.T) {
 		resetGlobalItems()
-		initialLength := len(items)
+		initialLength := len(storedItems())
 
 		// Malformed JSON
 		payload := []byte(`{"name":"Bad JSON", "description":}`)
@@ -177,12 +276,11 @@ func TestCreateItem(t *testing.T) {
 		}
 
 		// 2. Check global state (should not have changed)
-		itemsLock.Lock()
+		items := storedItems()
 		if len(items) != initialLength {
 			t.Errorf("slice length changed on bad request: got %d want %d",
 				len(items), initialLength)
 		}
-		itemsLock.Unlock()
 	})
 }
 
@@ -221,11 +319,10 @@ func TestUpdateItem(t *testing.T) {
 		}
 
 		// 3. Check global state
-		itemsLock.Lock()
+		items := storedItems()
 		if items[0].Name != "Updated Name" {
 			t.Error("global state was not updated correctly")
 		}
-		itemsLock.Unlock()
 	})
 
 	// Sub-test for "Item Not Found"
@@ -255,7 +352,7 @@ func TestDeleteItem(t *testing.T) {
 	// Sub-test for "Item Found"
 	t.Run("Item Found", func(t *testing.T) {
 		resetGlobalItems() // Starts with 2 items
-		initialLength := len(items)
+		initialLength := len(storedItems())
 
 		req := httptest.NewRequest("DELETE", "/items/1", nil)
 		rr := httptest.NewRecorder()
@@ -272,7 +369,7 @@ func TestDeleteItem(t *testing.T) {
 		}
 
 		// 2. Check global state
-		itemsLock.Lock()
+		items := storedItems()
 		if len(items) != initialLength-1 {
 			t.Errorf("item was not removed from slice: got len %d want %d",
 				len(items), initialLength-1)
@@ -281,13 +378,12 @@ func TestDeleteItem(t *testing.T) {
 		if items[0].ID == "1" {
 			t.Error("wrong item was deleted or item was not deleted")
 		}
-		itemsLock.Unlock()
 	})
 
 	// Sub-test for "Item Not Found"
 	t.Run("Item Not Found", func(t *testing.T) {
 		resetGlobalItems() // Reset state (2 items)
-		initialLength := len(items)
+		initialLength := len(storedItems())
 
 		req := httptest.NewRequest("DELETE", "/items/999", nil)
 		rr := httptest.NewRecorder()
@@ -304,11 +400,1147 @@ func TestDeleteItem(t *testing.T) {
 		}
 
 		// 2. Check global state (should be unchanged)
-		itemsLock.Lock()
+		items := storedItems()
 		if len(items) != initialLength {
 			t.Errorf("slice length changed on bad request: got %d want %d",
 				len(items), initialLength)
 		}
-		itemsLock.Unlock()
+	})
+}
+
+// TestSanitizeHTML checks the tag-stripping helper on its own.
+func TestSanitizeHTML(t *testing.T) {
+	tests := map[string]string{
+		"plain text":                       "plain text",
+		"<script>alert(1)</script>foo":     "alert(1)foo",
+		"<b>bold</b> and <i>italic</i>":    "bold and italic",
+		`<img src="x" onerror="alert(1)">`: "",
+	}
+	for input, want := range tests {
+		if got := sanitizeHTML(input); got != want {
+			t.Errorf("sanitizeHTML(%q): got %q want %q", input, got, want)
+		}
+	}
+}
+
+// TestDescriptionSanitization (POST /items, PUT /items/{id})
+func TestDescriptionSanitization(t *testing.T) {
+	payload := []byte(`{"name":"XSS Item", "description":"<script>alert(1)</script>foo"}`)
+
+	// Sub-test for "Create Strips Tags"
+	t.Run("Create Strips Tags", func(t *testing.T) {
+		resetGlobalItems()
+
+		req := httptest.NewRequest("POST", "/items", bytes.NewBuffer(payload))
+		rr := httptest.NewRecorder()
+
+		createItem(rr, req)
+
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v",
+				status, http.StatusCreated)
+		}
+
+		// Check the stored item, not just the response
+		items := storedItems()
+		stored := items[len(items)-1]
+		if stored.Description != "alert(1)foo" {
+			t.Errorf("stored description was not sanitized: got %q want %q",
+				stored.Description, "alert(1)foo")
+		}
+	})
+
+	// Sub-test for "Update Strips Tags"
+	t.Run("Update Strips Tags", func(t *testing.T) {
+		resetGlobalItems()
+
+		req := httptest.NewRequest("PUT", "/items/1", bytes.NewBuffer(payload))
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		rr := httptest.NewRecorder()
+
+		updateItem(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v",
+				status, http.StatusOK)
+		}
+
+		items := storedItems()
+		stored := items[0]
+		if stored.Description != "alert(1)foo" {
+			t.Errorf("stored description was not sanitized: got %q want %q",
+				stored.Description, "alert(1)foo")
+		}
+	})
+
+	// Sub-test for "ALLOW_HTML Disables Sanitization"
+	t.Run("ALLOW_HTML Disables Sanitization", func(t *testing.T) {
+		resetGlobalItems()
+		config.AllowHTML = true
+		defer func() { config.AllowHTML = false }()
+
+		req := httptest.NewRequest("POST", "/items", bytes.NewBuffer(payload))
+		rr := httptest.NewRecorder()
+
+		createItem(rr, req)
+
+		items := storedItems()
+		stored := items[len(items)-1]
+		if stored.Description != "<script>alert(1)</script>foo" {
+			t.Errorf("description was modified with ALLOW_HTML set: got %q", stored.Description)
+		}
+	})
+}
+
+// TestDescriptionValidation (POST /items)
+func TestDescriptionValidation(t *testing.T) {
+	create := func(description string) int {
+		payload, _ := json.Marshal(map[string]string{"name": "Validated", "description": description})
+		rr := httptest.NewRecorder()
+		createItem(rr, httptest.NewRequest("POST", "/items", bytes.NewBuffer(payload)))
+		return rr.Code
+	}
+
+	// Sub-test for "Too Long"
+	t.Run("Too Long", func(t *testing.T) {
+		resetGlobalItems()
+		if status := create(strings.Repeat("é", maxDescriptionLength+1)); status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+		if status := create(strings.Repeat("é", maxDescriptionLength)); status != http.StatusCreated {
+			t.Errorf("description at the limit was rejected: got %v want %v", status, http.StatusCreated)
+		}
+	})
+
+	// Sub-test for "Strict Markdown"
+	t.Run("Strict Markdown", func(t *testing.T) {
+		resetGlobalItems()
+		config.ValidateMarkdown = true
+		defer func() { config.ValidateMarkdown = false }()
+
+		if status := create("# Title\n\nSome *emphasis* and a [link](https://example.com)."); status != http.StatusCreated {
+			t.Errorf("valid markdown was rejected: got %v want %v", status, http.StatusCreated)
+		}
+		// A lone link reference definition renders to nothing
+		if status := create("[ref]: https://example.com"); status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusUnprocessableEntity)
+		}
+		if len(storedItems()) != 3 {
+			t.Errorf("wrong number of stored items: got %d want %d", len(storedItems()), 3)
+		}
+	})
+}
+
+// TestTagValidation (POST /items)
+func TestTagValidation(t *testing.T) {
+	create := func(tags []string) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(map[string]interface{}{"name": "Tagged", "tags": tags})
+		rr := httptest.NewRecorder()
+		createItem(rr, httptest.NewRequest("POST", "/items", bytes.NewBuffer(payload)))
+		return rr
+	}
+	manyTags := func(n int) []string {
+		tags := make([]string, n)
+		for i := range tags {
+			tags[i] = "tag" + strconv.Itoa(i)
+		}
+		return tags
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want int
+	}{
+		{"Too Many", manyTags(maxTags + 1), http.StatusUnprocessableEntity},
+		{"At The Limit", manyTags(maxTags), http.StatusCreated},
+		{"Too Long", []string{strings.Repeat("t", maxTagLength+1)}, http.StatusUnprocessableEntity},
+		{"Long Once Trimmed", []string{"  " + strings.Repeat("t", maxTagLength) + "  "}, http.StatusCreated},
+		{"Empty", []string{"ok", ""}, http.StatusUnprocessableEntity},
+		{"Blank", []string{"   "}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		// Sub-test for each tag list
+		t.Run(tt.name, func(t *testing.T) {
+			resetGlobalItems()
+			if rr := create(tt.tags); rr.Code != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.want, rr.Body)
+			}
+		})
+	}
+
+	// Sub-test for "Duplicates"
+	t.Run("Duplicates", func(t *testing.T) {
+		resetGlobalItems()
+		rr := create([]string{"Go", " api ", "go", "API", "web"})
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		var created Item
+		json.NewDecoder(rr.Body).Decode(&created)
+		stored, _ := store.GetByID(context.Background(), created.ID)
+		if want := []string{"Go", "api", "web"}; !reflect.DeepEqual(stored.Tags, want) {
+			t.Errorf("tags were not deduplicated: got %v want %v", stored.Tags, want)
+		}
+	})
+}
+
+// TestCancelledRequest checks that handlers stop when the client has gone away.
+func TestCancelledRequest(t *testing.T) {
+	resetGlobalItems()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest("GET", "/items/1", nil).WithContext(ctx)
+	req = mux.SetURLVars(req, map[string]string{"id": "1"})
+	rr := httptest.NewRecorder()
+
+	getItem(rr, req)
+
+	if status := rr.Code; status != statusClientClosedRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, statusClientClosedRequest)
+	}
+}
+
+// TestCreateItemSeededRand (POST /items)
+// A seeded random source injected through the context makes IDs predictable.
+func TestCreateItemSeededRand(t *testing.T) {
+	const seed = 42
+	expected := rand.New(rand.NewSource(seed))
+	firstID := strconv.Itoa(expected.Intn(1000000))
+	secondID := strconv.Itoa(expected.Intn(1000000))
+
+	// Sub-test for "Deterministic ID"
+	t.Run("Deterministic ID", func(t *testing.T) {
+		resetGlobalItems()
+
+		payload := []byte(`{"name":"Seeded Item", "description":"Has a known ID"}`)
+		req := httptest.NewRequest("POST", "/items", bytes.NewBuffer(payload))
+		req = req.WithContext(withRand(req.Context(), rand.New(rand.NewSource(seed))))
+		rr := httptest.NewRecorder()
+
+		createItem(rr, req)
+
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		if item.ID != firstID {
+			t.Errorf("handler returned unexpected ID: got %s want %s", item.ID, firstID)
+		}
+	})
+
+	// Sub-test for "Collision Retry"
+	t.Run("Collision Retry", func(t *testing.T) {
+		// The first ID the seeded source produces is already taken
+		store = NewMemoryStore(Item{ID: firstID, Name: "Existing Item"})
+
+		payload := []byte(`{"name":"Seeded Item", "description":"Has a known ID"}`)
+		req := httptest.NewRequest("POST", "/items", bytes.NewBuffer(payload))
+		req = req.WithContext(withRand(req.Context(), rand.New(rand.NewSource(seed))))
+		rr := httptest.NewRecorder()
+
+		createItem(rr, req)
+
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v",
+				status, http.StatusCreated)
+		}
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		if item.ID != secondID {
+			t.Errorf("handler did not retry after a collision: got ID %s want %s", item.ID, secondID)
+		}
+		if items := storedItems(); len(items) != 2 {
+			t.Errorf("unexpected number of stored items: got %d want %d", len(items), 2)
+		}
+	})
+}
+
+// TestResponseEnvelope checks JSON responses with and without RESPONSE_ENVELOPE.
+func TestResponseEnvelope(t *testing.T) {
+	router := newRouter()
+
+	// Sub-test for "Unwrapped"
+	t.Run("Unwrapped", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/1", nil))
+
+		var item Item
+		if err := json.NewDecoder(rr.Body).Decode(&item); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if item.ID != "1" {
+			t.Errorf("handler returned wrong item: got %+v", item)
+		}
+	})
+
+	// Sub-test for "Wrapped Data"
+	t.Run("Wrapped Data", func(t *testing.T) {
+		resetGlobalItems()
+		config.ResponseEnvelope = true
+		defer func() { config.ResponseEnvelope = false }()
+
+		req := httptest.NewRequest("GET", "/items/1", nil)
+		req.Header.Set("X-Request-ID", "envelope-test")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var envelope struct {
+			RequestID string `json:"request_id"`
+			Timestamp string `json:"timestamp"`
+			Data      Item   `json:"data"`
+			Error     string `json:"error"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if envelope.RequestID != "envelope-test" {
+			t.Errorf("envelope has wrong request_id: got %q want %q", envelope.RequestID, "envelope-test")
+		}
+		if envelope.Timestamp == "" {
+			t.Error("envelope has no timestamp")
+		}
+		if envelope.Data.ID != "1" || envelope.Data.Name != "Mock Item 1" {
+			t.Errorf("envelope has wrong data: got %+v", envelope.Data)
+		}
+		if envelope.Error != "" {
+			t.Errorf("successful response has an error: %q", envelope.Error)
+		}
+	})
+
+	// Sub-test for "Wrapped Error"
+	t.Run("Wrapped Error", func(t *testing.T) {
+		resetGlobalItems()
+		config.ResponseEnvelope = true
+		defer func() { config.ResponseEnvelope = false }()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/999", nil))
+
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusNotFound)
+		}
+		var envelope map[string]interface{}
+		if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if envelope["error"] != "Item not found" {
+			t.Errorf("envelope has wrong error: got %v", envelope["error"])
+		}
+		if _, ok := envelope["data"]; ok {
+			t.Error("error response should not have a data field")
+		}
+		if envelope["request_id"] == "" || envelope["request_id"] == nil {
+			t.Error("error envelope has no request_id")
+		}
+	})
+}
+
+// TestGetRandomItem (GET /items/random)
+func TestGetRandomItem(t *testing.T) {
+	seedThree := func() {
+		store = NewMemoryStore(
+			Item{ID: "1", Name: "Mock Item 1"},
+			Item{ID: "2", Name: "Mock Item 2"},
+			Item{ID: "3", Name: "Mock Item 3"},
+		)
+	}
+	router := newRouter()
+
+	// Sub-test for "All Items Drawn"
+	t.Run("All Items Drawn", func(t *testing.T) {
+		seedThree()
+
+		seen := make(map[string]int)
+		for i := 0; i < 30; i++ {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/random", nil))
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v",
+					status, http.StatusOK)
+			}
+			var item Item
+			json.NewDecoder(rr.Body).Decode(&item)
+			seen[item.ID]++
+		}
+
+		// With 30 draws from 3 items, missing one has a chance of about 1 in 60,000
+		for _, id := range []string{"1", "2", "3"} {
+			if seen[id] == 0 {
+				t.Errorf("item %s was never returned in 30 draws: %v", id, seen)
+			}
+		}
+	})
+
+	// Sub-test for "Exclude"
+	t.Run("Exclude", func(t *testing.T) {
+		seedThree()
+
+		for i := 0; i < 10; i++ {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/random?exclude=1,3", nil))
+			var item Item
+			json.NewDecoder(rr.Body).Decode(&item)
+			if item.ID != "2" {
+				t.Fatalf("handler returned an excluded item: got %s want %s", item.ID, "2")
+			}
+		}
+	})
+
+	// Sub-test for "Seeded Rand"
+	t.Run("Seeded Rand", func(t *testing.T) {
+		seedThree()
+
+		want := rand.New(rand.NewSource(7)).Intn(3)
+		req := httptest.NewRequest("GET", "/items/random", nil)
+		req = req.WithContext(withRand(req.Context(), rand.New(rand.NewSource(7))))
+		rr := httptest.NewRecorder()
+
+		getRandomItem(rr, req)
+
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		if item.ID != strconv.Itoa(want+1) {
+			t.Errorf("handler ignored the injected rand: got ID %s want %d", item.ID, want+1)
+		}
+	})
+
+	// Sub-test for "No Items Available"
+	t.Run("No Items Available", func(t *testing.T) {
+		seedThree()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/random?exclude=1,2,3", nil))
+
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusNotFound)
+		}
+		var body map[string]string
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body["error"] != "no items available" {
+			t.Errorf("handler returned wrong error: got %q", body["error"])
+		}
+	})
+}
+
+// TestGetItemsLastModified (GET /items with If-Modified-Since)
+func TestGetItemsLastModified(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	// Start from a known point in the past so the next write is always a later second
+	lastModifiedLock.Lock()
+	lastModified = time.Now().Add(-time.Minute)
+	lastModifiedLock.Unlock()
+
+	// 1. First fetch returns the list and a Last-Modified header
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+	modified := rr.Header().Get("Last-Modified")
+	if _, err := http.ParseTime(modified); err != nil {
+		t.Fatalf("handler returned invalid Last-Modified %q: %v", modified, err)
+	}
+
+	// 2. Revalidating with that date returns 304 and no body
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("If-Modified-Since", modified)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusNotModified {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusNotModified)
+	}
+	if rr.Body.Len() != 0 {
+		t.Errorf("304 response has a body: %q", rr.Body.String())
+	}
+
+	// 3. After a create the same request returns the list again
+	payload := []byte(`{"name":"New Item", "description":"Changes the list"}`)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/items", bytes.NewBuffer(payload)))
+
+	req = httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("If-Modified-Since", modified)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code after a create: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+// TestUpdateItemReadOnlyFields (PUT /items/{id})
+func TestUpdateItemReadOnlyFields(t *testing.T) {
+	payloads := map[string]string{
+		"id":         `{"id":"42", "name":"Updated Name"}`,
+		"created_at": `{"created_at":"2024-01-01T00:00:00Z", "name":"Updated Name"}`,
+		"version":    `{"version":7, "name":"Updated Name"}`,
+
+		"last_request_id": `{"last_request_id":"forged", "name":"Updated Name"}`,
+		"view_count":      `{"view_count":5, "name":"Updated Name"}`,
+		"score":           `{"score":99, "name":"Updated Name"}`,
+	}
+	for field, payload := range payloads {
+		t.Run(field, func(t *testing.T) {
+			resetGlobalItems()
+
+			req := httptest.NewRequest("PUT", "/items/1", bytes.NewBufferString(payload))
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			rr := httptest.NewRecorder()
+
+			updateItem(rr, req)
+
+			// 1. Check status
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, http.StatusBadRequest)
+			}
+
+			// 2. Check the error names the field
+			var body map[string]string
+			json.NewDecoder(rr.Body).Decode(&body)
+			if want := "field '" + field + "' is read-only"; body["error"] != want {
+				t.Errorf("handler returned wrong error: got %q want %q", body["error"], want)
+			}
+
+			// 3. Check global state (should be unchanged)
+			if items := storedItems(); items[0].Name != "Mock Item 1" {
+				t.Error("item was updated despite the read-only field")
+			}
+		})
+	}
+}
+
+// TestUpdateItemStrictDecode checks that STRICT_DECODE rejects unknown fields.
+func TestUpdateItemStrictDecode(t *testing.T) {
+	put := func(payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/items/1", bytes.NewBufferString(payload))
+		req = mux.SetURLVars(req, map[string]string{"id": "1"})
+		rr := httptest.NewRecorder()
+		updateItem(rr, req)
+		return rr
+	}
+	unknown := `{"name":"X","unknown_field":"Y"}`
+
+	t.Run("Lenient", func(t *testing.T) {
+		resetGlobalItems()
+		if rr := put(unknown); rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	config.StrictDecode = true
+	defer func() { config.StrictDecode = false }()
+
+	t.Run("Strict Unknown Field", func(t *testing.T) {
+		resetGlobalItems()
+		rr := put(unknown)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		var body map[string]string
+		json.NewDecoder(rr.Body).Decode(&body)
+		if !strings.Contains(body["error"], `unknown field "unknown_field"`) {
+			t.Errorf("error does not name the unknown field: %q", body["error"])
+		}
+		if items := storedItems(); items[0].Name != "Mock Item 1" {
+			t.Error("item was updated despite the unknown field")
+		}
+	})
+
+	t.Run("Strict Known Fields", func(t *testing.T) {
+		resetGlobalItems()
+		if rr := put(`{"name":"X","description":"D","tags":["t"]}`); rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+}
+
+// TestLastRequestID checks that items remember the request that wrote them.
+func TestLastRequestID(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	send := func(method, path, requestID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(requestIDHeader, requestID)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 1. Create with a known request ID
+	rr := send("POST", "/items", "create-req", `{"name":"Traced","last_request_id":"forged"}`)
+	var created Item
+	json.NewDecoder(rr.Body).Decode(&created)
+	if created.LastRequestID != "create-req" {
+		t.Fatalf("created item has wrong last_request_id: got %q want %q", created.LastRequestID, "create-req")
+	}
+
+	// 2. Filter by it
+	rr = send("GET", "/items?request_id=create-req", "list-req", "")
+	var items []Item
+	json.NewDecoder(rr.Body).Decode(&items)
+	if len(items) != 1 || items[0].ID != created.ID {
+		t.Errorf("filter by request_id: got %+v want only item %s", items, created.ID)
+	}
+
+	// 3. An update takes over the item
+	send("PUT", "/items/"+created.ID, "update-req", `{"name":"Traced again"}`)
+	rr = send("GET", "/items?request_id=create-req", "list-req", "")
+	items = nil
+	json.NewDecoder(rr.Body).Decode(&items)
+	if len(items) != 0 {
+		t.Errorf("item still matches its creating request after an update: %+v", items)
+	}
+	if item, _ := store.GetByID(context.Background(), created.ID); item.LastRequestID != "update-req" {
+		t.Errorf("updated item has wrong last_request_id: got %q want %q", item.LastRequestID, "update-req")
+	}
+}
+
+// TestGetItemsByName (GET /items?name= and ?names=)
+func TestGetItemsByName(t *testing.T) {
+	store = NewMemoryStore(
+		Item{ID: "a", Name: "Item A"},
+		Item{ID: "b", Name: "Item B"},
+		Item{ID: "c", Name: "Item C"},
+	)
+	defer resetGlobalItems()
+	router := newRouter()
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"Several Exact Names", "?names=Item+A&names=item+c", []string{"a", "c"}},
+		{"Exact Names Only", "?names=Item", []string{}},
+		{"Substring", "?name=item+b", []string{"b"}},
+		{"Substring Matches All", "?name=ITEM", []string{"a", "b", "c"}},
+		{"Both", "?name=c&names=Item+A&names=Item+C", []string{"c"}},
+	}
+	for _, tt := range tests {
+		// Sub-test for each query
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/items"+tt.query, nil))
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+			}
+			var items []Item
+			json.NewDecoder(rr.Body).Decode(&items)
+			ids := []string{}
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("wrong items: got %v want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// TestGetItemsFormat (GET /items?format=)
+func TestGetItemsFormat(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items"+query, nil))
+		return rr
+	}
+
+	// Sub-test for "Map"
+	t.Run("Map", func(t *testing.T) {
+		rr := get("?format=map&per_page=1")
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		var byID map[string]Item
+		if err := json.NewDecoder(rr.Body).Decode(&byID); err != nil {
+			t.Fatalf("response is not a JSON object: %v", err)
+		}
+		if len(byID) != 1 || byID["1"].Name != "Mock Item 1" {
+			t.Errorf("wrong items map: %+v", byID)
+		}
+		if got := rr.Header().Get("X-Total-Count"); got != "2" {
+			t.Errorf("wrong X-Total-Count: got %q want 2", got)
+		}
+	})
+
+	// Sub-test for "Array By Default"
+	t.Run("Array By Default", func(t *testing.T) {
+		for _, query := range []string{"", "?format=array"} {
+			rr := get(query)
+			var items []Item
+			if err := json.NewDecoder(rr.Body).Decode(&items); err != nil || len(items) != 2 {
+				t.Errorf("%q: response is not the item array: %v %+v", query, err, items)
+			}
+			if got := rr.Header().Get("X-Total-Count"); got != "2" {
+				t.Errorf("%q: wrong X-Total-Count: got %q want 2", query, got)
+			}
+		}
+	})
+
+	// Sub-test for "Unknown Format"
+	t.Run("Unknown Format", func(t *testing.T) {
+		if status := get("?format=tree").Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestNamespaces (GET /items?namespace=, POST /items/{id}/move)
+func TestNamespaces(t *testing.T) {
+	store = NewMemoryStore()
+	defer resetGlobalItems()
+	router := newRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+	namesIn := func(namespace string) []string {
+		var items []Item
+		json.NewDecoder(send("GET", "/items?namespace="+namespace+"&sort=name", "").Body).Decode(&items)
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	var moving Item
+	json.NewDecoder(send("POST", "/items?namespace=a", `{"name":"a1"}`).Body).Decode(&moving)
+	send("POST", "/items?namespace=a", `{"name":"a2"}`)
+	send("POST", "/items", `{"name":"b1","namespace":"b"}`)
+	send("POST", "/items", `{"name":"none"}`)
+
+	if got, want := namesIn("a"), []string{"a1", "a2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("namespace a: got %v want %v", got, want)
+	}
+	if got, want := namesIn("b"), []string{"b1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("namespace b: got %v want %v", got, want)
+	}
+	if got := namesIn(""); len(got) != 4 {
+		t.Errorf("no namespace filter should list every item: got %v", got)
+	}
+
+	t.Run("Move", func(t *testing.T) {
+		rr := send("POST", "/items/"+moving.ID+"/move", `{"target_namespace":"b"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if got, want := namesIn("a"), []string{"a2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("namespace a after the move: got %v want %v", got, want)
+		}
+		if got, want := namesIn("b"), []string{"a1", "b1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("namespace b after the move: got %v want %v", got, want)
+		}
+	})
+
+	t.Run("Bad Requests", func(t *testing.T) {
+		if rr := send("POST", "/items/"+moving.ID+"/move", `{}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("missing target_namespace: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		if rr := send("POST", "/items/missing/move", `{"target_namespace":"b"}`); rr.Code != http.StatusNotFound {
+			t.Errorf("unknown item: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+		if rr := send("PUT", "/items/"+moving.ID, `{"name":"a1","namespace":"a"}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("PUT may not change the namespace: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestFilterByDateRange checks the date filter helper on its own.
+func TestFilterByDateRange(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	items := []Item{
+		{ID: "1", CreatedAt: day(1)},
+		{ID: "2", CreatedAt: day(10)},
+		{ID: "3", CreatedAt: day(20)},
+	}
+
+	tests := []struct {
+		name          string
+		after, before time.Time
+		want          []string
+	}{
+		{"no bounds", time.Time{}, time.Time{}, []string{"1", "2", "3"}},
+		{"after only", day(5), time.Time{}, []string{"2", "3"}},
+		{"before only", time.Time{}, day(15), []string{"1", "2"}},
+		{"both", day(5), day(15), []string{"2"}},
+		{"bounds are exclusive", day(1), day(20), []string{"2"}},
+		{"empty range", day(11), day(19), []string{}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, item := range filterByDateRange(items, tt.after, tt.before) {
+			got = append(got, item.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestGetItemsDateRange (GET /items?created_after=…&created_before=…)
+func TestGetItemsDateRange(t *testing.T) {
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "Old", CreatedAt: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		Item{ID: "2", Name: "This Year", CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		Item{ID: "3", Name: "New", CreatedAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+	)
+
+	// Sub-test for "Range"
+	t.Run("Range", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/items?created_after=2024-01-01T00:00:00Z&created_before=2024-12-31T23:59:59Z", nil)
+		rr := httptest.NewRecorder()
+
+		getItems(rr, req)
+
+		var returnedItems []Item
+		json.NewDecoder(rr.Body).Decode(&returnedItems)
+		if len(returnedItems) != 1 || returnedItems[0].ID != "2" {
+			t.Errorf("handler returned wrong items: got %+v want only item 2", returnedItems)
+		}
+	})
+
+	// Sub-test for "Invalid Timestamp"
+	t.Run("Invalid Timestamp", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/items?created_before=yesterday", nil)
+		rr := httptest.NewRecorder()
+
+		getItems(rr, req)
+
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusBadRequest)
+		}
+		var body map[string]string
+		json.NewDecoder(rr.Body).Decode(&body)
+		if !strings.Contains(body["error"], "created_before") {
+			t.Errorf("error does not name the offending field: got %q", body["error"])
+		}
+	})
+}
+
+// TestCreateItemSetsCreatedAt (POST /items)
+func TestCreateItemSetsCreatedAt(t *testing.T) {
+	resetGlobalItems()
+	before := time.Now()
+
+	// Any client-supplied created_at is ignored
+	payload := []byte(`{"name":"New Item", "created_at":"2000-01-01T00:00:00Z"}`)
+	req := httptest.NewRequest("POST", "/items", bytes.NewBuffer(payload))
+	rr := httptest.NewRecorder()
+
+	createItem(rr, req)
+
+	var item Item
+	json.NewDecoder(rr.Body).Decode(&item)
+	if item.CreatedAt.Before(before) || item.CreatedAt.After(time.Now()) {
+		t.Errorf("handler set wrong created_at: got %v", item.CreatedAt)
+	}
+}
+
+// TestJSONP (GET /items and GET /items/{id} with ?callback=)
+func TestJSONP(t *testing.T) {
+	router := newRouter()
+
+	for _, path := range []string{"/items", "/items/1"} {
+		// Sub-test for "Valid Callback"
+		t.Run(path+" Valid Callback", func(t *testing.T) {
+			resetGlobalItems()
+
+			// The plain JSON response is what should end up inside the call
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+			plain := strings.TrimSpace(rr.Body.String())
+			views = &ViewCounter{} // so the second fetch shows the same view_count
+
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", path+"?callback=app.onItems", nil))
+
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, http.StatusOK)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/javascript" {
+				t.Errorf("handler returned wrong Content-Type: got %q want %q", ct, "application/javascript")
+			}
+			if want := "app.onItems(" + plain + ");"; rr.Body.String() != want {
+				t.Errorf("handler returned wrong body: got %q want %q", rr.Body.String(), want)
+			}
+		})
+
+		// Sub-test for "Invalid Callback"
+		t.Run(path+" Invalid Callback", func(t *testing.T) {
+			resetGlobalItems()
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", path+"?callback=alert(1)//", nil))
+
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, http.StatusBadRequest)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("error response has wrong Content-Type: got %q", ct)
+			}
+		})
+	}
+}
+
+// TestRenameItem (POST /items/{id}/rename)
+func TestRenameItem(t *testing.T) {
+	rename := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/items/"+id+"/rename", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		rr := httptest.NewRecorder()
+		renameItem(rr, req)
+		return rr
+	}
+
+	// Sub-test for "Name Matches"
+	t.Run("Name Matches", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := rename("1", `{"expected_name":"Mock Item 1","new_name":"Renamed Item"}`)
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v",
+				status, http.StatusOK)
+		}
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		if item.Name != "Renamed Item" || item.Version != 2 {
+			t.Errorf("handler returned wrong item: got %+v", item)
+		}
+		if stored := storedItems()[0]; stored.Name != "Renamed Item" || stored.Description != "First mock item" {
+			t.Errorf("rename was not stored correctly: got %+v", stored)
+		}
+	})
+
+	// Sub-test for "Name Changed Meanwhile"
+	t.Run("Name Changed Meanwhile", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := rename("1", `{"expected_name":"Old Name","new_name":"Renamed Item"}`)
+		if status := rr.Code; status != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusConflict)
+		}
+		if stored := storedItems()[0]; stored.Name != "Mock Item 1" || stored.Version != 1 {
+			t.Errorf("item was changed by a failed rename: got %+v", stored)
+		}
+	})
+
+	// Sub-test for "Blank Name"
+	t.Run("Blank Name", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := rename("1", `{"expected_name":"Mock Item 1","new_name":"   "}`)
+		if status := rr.Code; status != http.StatusUnprocessableEntity {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusUnprocessableEntity)
+		}
+		if stored := storedItems()[0]; stored.Name != "Mock Item 1" || stored.Version != 1 {
+			t.Errorf("item was changed by an invalid rename: got %+v", stored)
+		}
+	})
+
+	// Sub-test for "Records Request ID"
+	t.Run("Records Request ID", func(t *testing.T) {
+		resetGlobalItems()
+
+		req := httptest.NewRequest("POST", "/items/1/rename", strings.NewReader(`{"expected_name":"Mock Item 1","new_name":"Renamed Item"}`))
+		req.Header.Set(requestIDHeader, "rename-request")
+		rr := httptest.NewRecorder()
+		newRouter().ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if stored := storedItems()[0]; stored.LastRequestID != "rename-request" {
+			t.Errorf("wrong last_request_id: got %q want %q", stored.LastRequestID, "rename-request")
+		}
+	})
+
+	// Sub-test for "Item Not Found"
+	t.Run("Item Not Found", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := rename("999", `{"expected_name":"Mock Item 1","new_name":"Renamed Item"}`)
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusNotFound)
+		}
+	})
+}
+
+// TestFormatItemSummary checks the plain-text rendering on its own.
+func TestFormatItemSummary(t *testing.T) {
+	item := Item{
+		ID:          "7",
+		Name:        "Widget",
+		Description: "A useful widget",
+		Tags:        []string{"tools", "blue"},
+		CreatedAt:   time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC),
+	}
+	want := "Item #7: Widget\nA useful widget\nCreated: 2024-03-05\nTags: tools, blue\n"
+	if got := formatItemSummary(item); got != want {
+		t.Errorf("formatItemSummary: got %q want %q", got, want)
+	}
+
+	item.Tags = nil
+	if got := formatItemSummary(item); !strings.HasSuffix(got, "Tags: (none)\n") {
+		t.Errorf("formatItemSummary without tags: got %q", got)
+	}
+}
+
+// TestGetItemSummary (GET /items/{id}/summary)
+func TestGetItemSummary(t *testing.T) {
+	router := newRouter()
+
+	// Sub-test for "Plain Text"
+	t.Run("Plain Text", func(t *testing.T) {
+		resetGlobalItems()
+
+		req := httptest.NewRequest("GET", "/items/1/summary", nil)
+		req.Header.Set("Accept", "text/plain")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusOK)
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("handler returned wrong content type: got %q", ct)
+		}
+		body := rr.Body.String()
+		if !strings.Contains(body, "Mock Item 1") || !strings.Contains(body, "First mock item") {
+			t.Errorf("summary is missing the item name or description: %q", body)
+		}
+	})
+
+	// Sub-test for "JSON"
+	t.Run("JSON", func(t *testing.T) {
+		resetGlobalItems()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/1/summary", nil))
+
+		var item Item
+		if err := json.NewDecoder(rr.Body).Decode(&item); err != nil || item.ID != "1" {
+			t.Errorf("summary without Accept: text/plain is not the item JSON: %v %+v", err, item)
+		}
+	})
+}
+
+// TestDeriveItem (POST /items/derive)
+func TestDeriveItem(t *testing.T) {
+	derive := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		deriveItem(rr, httptest.NewRequest("POST", "/items/derive", strings.NewReader(body)))
+		return rr
+	}
+
+	// Sub-test for "Derive With Overrides"
+	t.Run("Derive With Overrides", func(t *testing.T) {
+		resetGlobalItems()
+		store.Update(context.Background(), "1", func(item *Item) error {
+			item.Likes = 7
+			item.Tags = []string{"original"}
+			return nil
+		})
+
+		rr := derive(`{"source_id":"1","overrides":{"name":"Derived"}}`)
+		if status := rr.Code; status != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v",
+				status, http.StatusCreated)
+		}
+		var derived Item
+		json.NewDecoder(rr.Body).Decode(&derived)
+		if derived.ID == "1" || derived.Name != "Derived" || derived.Description != "First mock item" {
+			t.Errorf("derived item has wrong fields: %+v", derived)
+		}
+		if derived.Likes != 0 || derived.Version != 1 || len(derived.Tags) != 1 {
+			t.Errorf("derived item was not reset correctly: %+v", derived)
+		}
+
+		items := storedItems()
+		if len(items) != 3 {
+			t.Fatalf("derived item was not stored: got %d items want %d", len(items), 3)
+		}
+		if source := items[0]; source.Name != "Mock Item 1" || source.Likes != 7 {
+			t.Errorf("source item was changed: %+v", source)
+		}
+	})
+
+	// Sub-test for "Read-Only Override"
+	t.Run("Read-Only Override", func(t *testing.T) {
+		resetGlobalItems()
+		rr := derive(`{"source_id":"1","overrides":{"id":"42"}}`)
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusBadRequest)
+		}
+		if len(storedItems()) != 2 {
+			t.Error("an item was created despite the read-only override")
+		}
+	})
+
+	// Sub-test for "Clone Count"
+	t.Run("Clone Count", func(t *testing.T) {
+		resetGlobalItems()
+		for range 5 {
+			rr := derive(`{"source_id":"2"}`)
+			if status := rr.Code; status != http.StatusCreated {
+				t.Fatalf("handler returned wrong status code: got %v want %v",
+					status, http.StatusCreated)
+			}
+			var clone Item
+			json.NewDecoder(rr.Body).Decode(&clone)
+			if clone.CloneCount != 0 {
+				t.Errorf("clone should start with no clones: got %d", clone.CloneCount)
+			}
+		}
+		if source, _ := store.GetByID(context.Background(), "2"); source.CloneCount != 5 {
+			t.Errorf("wrong clone count on the source: got %d want 5", source.CloneCount)
+		}
+
+		rr := httptest.NewRecorder()
+		router := newRouter()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items?sort=clone_count&order=desc", nil))
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		if len(items) != 7 || items[0].ID != "2" {
+			t.Errorf("items not sorted by clone count: %+v", items)
+		}
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("PUT", "/items/2", strings.NewReader(`{"name":"Again","clone_count":0}`)))
+		if status := rr.Code; status != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code for a clone_count update: got %v want %v",
+				status, http.StatusBadRequest)
+		}
+	})
+
+	// Sub-test for "Source Not Found"
+	t.Run("Source Not Found", func(t *testing.T) {
+		resetGlobalItems()
+		if status := derive(`{"source_id":"999"}`).Code; status != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusNotFound)
+		}
 	})
 }
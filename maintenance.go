@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// maintenanceRetryAfter is how many seconds clients are told to wait while
+// the API is under maintenance.
+const maintenanceRetryAfter = 60
+
+// maintenance is set while the API is under maintenance, see POST /admin/maintenance.
+var maintenance atomic.Bool
+
+// maintenanceExempt reports whether path is still served during maintenance.
+// Admin endpoints stay up so operators can turn maintenance off again.
+func maintenanceExempt(path string) bool {
+	return path == "/health" || path == "/version" || strings.HasPrefix(path, "/admin/")
+}
+
+// maintenanceMiddleware answers 503 Service Unavailable while the API is
+// under maintenance, except for the health check and admin endpoints.
+func maintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !maintenance.Load() || maintenanceExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(maintenanceRetryAfter))
+		respondWithJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"error":       "API is under maintenance, try again later",
+			"retry_after": maintenanceRetryAfter,
+		})
+	})
+}
+
+// setMaintenance (POST /admin/maintenance)
+// {"enable": true} puts the API under maintenance, {"enable": false} ends it.
+func setMaintenance(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Enable *bool `json:"enable"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Enable == nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	maintenance.Store(*request.Enable)
+	respondWithJSON(w, http.StatusOK, map[string]bool{"maintenance": *request.Enable})
+}
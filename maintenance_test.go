@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMaintenanceMode (POST /admin/maintenance)
+func TestMaintenanceMode(t *testing.T) {
+	resetGlobalItems()
+	config.AdminToken = "admin-secret"
+	defer func() {
+		config.AdminToken = ""
+		maintenance.Store(false)
+	}()
+	router := newRouter()
+
+	setMode := func(enable string) {
+		req := httptest.NewRequest("POST", "/admin/maintenance", strings.NewReader(`{"enable":`+enable+`}`))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	// 1. Under maintenance, items are unavailable
+	setMode("true")
+	rr := get("/items")
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusServiceUnavailable)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After: got %q want %q", got, "60")
+	}
+	var body struct {
+		Error      string `json:"error"`
+		RetryAfter int    `json:"retry_after"`
+	}
+	json.NewDecoder(rr.Body).Decode(&body)
+	if body.Error != "API is under maintenance, try again later" || body.RetryAfter != 60 {
+		t.Errorf("unexpected body: %+v", body)
+	}
+
+	// 2. The health check is still served
+	if rr := get("/health"); rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code for /health: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	// 3. Turning maintenance off serves items again
+	setMode("false")
+	if rr := get("/items"); rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	// Sub-test for "Requires Admin Token"
+	t.Run("Requires Admin Token", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/admin/maintenance", strings.NewReader(`{"enable":true}`)))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnauthorized)
+		}
+		if maintenance.Load() {
+			t.Error("maintenance was enabled without the admin token")
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+)
+
+// formatMarkdown renders s as CommonMark to HTML.
+// Raw HTML in s is left out of the output.
+func formatMarkdown(s string) (string, error) {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(s), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Merge strategies for a field of POST /items/merge.
+const (
+	mergeKeepSource = "keep_source"
+	mergeKeepTarget = "keep_target"
+	mergeConcat     = "concat" // string fields: target's value, a space, then source's
+	mergeUnion      = "union"  // tag lists: target's tags plus source's new ones
+)
+
+// mergeStrategy says how POST /items/merge combines each field.
+// Empty fields default to keep_target.
+type mergeStrategy struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Tags        string `json:"tags"`
+}
+
+// validate checks that every field has a strategy that suits its type.
+func (s *mergeStrategy) validate() error {
+	for field, strategy := range map[string]*string{"name": &s.Name, "description": &s.Description, "tags": &s.Tags} {
+		if *strategy == "" {
+			*strategy = mergeKeepTarget
+		}
+		allowed := mergeConcat
+		if field == "tags" {
+			allowed = mergeUnion
+		}
+		if *strategy != mergeKeepSource && *strategy != mergeKeepTarget && *strategy != allowed {
+			return fmt.Errorf("strategy for %s must be %s, %s or %s", field, mergeKeepSource, mergeKeepTarget, allowed)
+		}
+	}
+	return nil
+}
+
+// mergeString combines a string field of source and target.
+func mergeString(strategy, source, target string) string {
+	switch strategy {
+	case mergeKeepSource:
+		return source
+	case mergeConcat:
+		if source == "" || target == "" {
+			return target + source
+		}
+		return target + " " + source
+	}
+	return target
+}
+
+// mergeTags combines the tags of source and target.
+func mergeTags(strategy string, source, target []string) []string {
+	switch strategy {
+	case mergeKeepSource:
+		return source
+	case mergeUnion:
+		return dedupeTags(append(append([]string(nil), target...), source...))
+	}
+	return target
+}
+
+// mergeItems (POST /items/merge)
+// This merges the item "source_id" into "target_id" field by field,
+// following "strategy", e.g. {"name":"keep_target","description":"concat",
+// "tags":"union"}. The target is updated and returned; the source is deleted
+// the way DELETE /items/{id} deletes it, so soft-deleted on SQLite. If the
+// delete fails, the target's merged fields are put back. Locked items need
+// X-Lock-Token, as for any other write.
+func mergeItems(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		SourceID string        `json:"source_id"`
+		TargetID string        `json:"target_id"`
+		Strategy mergeStrategy `json:"strategy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if request.SourceID == "" || request.TargetID == "" {
+		respondWithError(w, http.StatusBadRequest, "source_id and target_id are required")
+		return
+	}
+	if request.SourceID == request.TargetID {
+		respondWithError(w, http.StatusBadRequest, "cannot merge an item into itself")
+		return
+	}
+	if err := request.Strategy.validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// lockMiddleware only covers routes with an {id}, so check both items here
+	token := r.Header.Get(lockTokenHeader)
+	for _, id := range []string{request.SourceID, request.TargetID} {
+		if lock, locked := itemLocks.Check(id, token); locked {
+			respondWithLocked(w, lock)
+			return
+		}
+	}
+
+	s := storeFromContext(r.Context())
+	source, err := s.GetByID(r.Context(), request.SourceID)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	var invalid error
+	var original Item
+	merged, err := s.Update(r.Context(), request.TargetID, func(item *Item) error {
+		original = *item
+		next := *item
+		next.Name = mergeString(request.Strategy.Name, source.Name, item.Name)
+		next.Description = mergeString(request.Strategy.Description, source.Description, item.Description)
+		next.Tags = mergeTags(request.Strategy.Tags, source.Tags, item.Tags)
+		if invalid = validateItem(next); invalid != nil {
+			return invalid
+		}
+		next.LastRequestID = GetRequestID(r.Context())
+		*item = next
+		return nil
+	})
+	if invalid != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, invalid.Error())
+		return
+	}
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+
+	if err := s.Delete(r.Context(), source.ID); err != nil {
+		// Put the target back, so that retrying does not merge the source in twice
+		if _, revertErr := s.Update(r.Context(), merged.ID, func(item *Item) error {
+			item.Name, item.Description, item.Tags = original.Name, original.Description, original.Tags
+			item.LastRequestID = original.LastRequestID
+			return nil
+		}); revertErr != nil {
+			log.Printf("failed to undo the merge into %s: %v", merged.ID, revertErr)
+		}
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemUpdated, merged)
+	recordItemChange(r.Context(), eventItemDeleted, Item{ID: source.ID})
+	if !isDryRun(r.Context()) {
+		attachments.DeleteItem(source.ID)
+		views.Delete(source.ID)
+		notes.DeleteItem(source.ID)
+		links.DeleteItem(source.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, merged)
+}
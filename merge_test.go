@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMergeItems (POST /items/merge)
+func TestMergeItems(t *testing.T) {
+	defer resetGlobalItems()
+
+	tests := []struct {
+		name            string
+		strategy        string
+		wantName        string
+		wantDescription string
+		wantTags        string
+	}{
+		{"Defaults", `{}`, "Target", "Kept", "b,c"},
+		{"Keep Source", `{"name":"keep_source","description":"keep_source","tags":"keep_source"}`, "Source", "Merged", "a,B"},
+		{"Keep Target", `{"name":"keep_target","description":"keep_target","tags":"keep_target"}`, "Target", "Kept", "b,c"},
+		{"Concat And Union", `{"name":"keep_target","description":"concat","tags":"union"}`, "Target", "Kept Merged", "b,c,a"},
+	}
+	for _, tt := range tests {
+		// Sub-test for each strategy
+		t.Run(tt.name, func(t *testing.T) {
+			store = NewMemoryStore(
+				Item{ID: "a", Name: "Source", Description: "Merged", Tags: []string{"a", "B"}},
+				Item{ID: "b", Name: "Target", Description: "Kept", Tags: []string{"b", "c"}},
+			)
+			router := newRouter()
+			body := `{"source_id":"a","target_id":"b","strategy":` + tt.strategy + `}`
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/merge", strings.NewReader(body)))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+			}
+			var merged Item
+			json.NewDecoder(rr.Body).Decode(&merged)
+			if merged.ID != "b" || merged.Name != tt.wantName || merged.Description != tt.wantDescription || strings.Join(merged.Tags, ",") != tt.wantTags {
+				t.Errorf("unexpected merged item: %+v", merged)
+			}
+			if _, err := store.GetByID(t.Context(), "a"); !errors.Is(err, errItemNotFound) {
+				t.Errorf("source item was not deleted: %v", err)
+			}
+		})
+	}
+
+	// Sub-test for "Invalid Requests"
+	t.Run("Invalid Requests", func(t *testing.T) {
+		store = NewMemoryStore(Item{ID: "a", Name: "Source"}, Item{ID: "b", Name: "Target"})
+		router := newRouter()
+		for body, want := range map[string]int{
+			`{"source_id":"a","target_id":"a"}`:                              http.StatusBadRequest,
+			`{"source_id":"a","target_id":"b","strategy":{"tags":"concat"}}`: http.StatusBadRequest,
+			`{"source_id":"a","target_id":"b","strategy":{"name":"union"}}`:  http.StatusBadRequest,
+			`{"source_id":"x","target_id":"b"}`:                              http.StatusNotFound,
+			`{"source_id":"a","target_id":"x"}`:                              http.StatusNotFound,
+		} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/merge", strings.NewReader(body)))
+			if rr.Code != want {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", body, rr.Code, want)
+			}
+		}
+		if _, err := store.GetByID(t.Context(), "a"); err != nil {
+			t.Errorf("failed merges deleted the source item: %v", err)
+		}
+	})
+	// Sub-test for "Locked Items"
+	t.Run("Locked Items", func(t *testing.T) {
+		store = NewMemoryStore(Item{ID: "a", Name: "Source"}, Item{ID: "b", Name: "Target"})
+		itemLocks = NewItemLocks()
+		defer func() { itemLocks = NewItemLocks() }()
+		router := newRouter()
+		merge := func(token string) int {
+			req := httptest.NewRequest("POST", "/items/merge", strings.NewReader(`{"source_id":"a","target_id":"b"}`))
+			req.Header.Set(lockTokenHeader, token)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr.Code
+		}
+		for _, id := range []string{"a", "b"} {
+			itemLocks = NewItemLocks()
+			itemLocks.Lock(id, "job-42", "importer", time.Minute)
+			if code := merge(""); code != http.StatusLocked {
+				t.Errorf("merge with %s locked: got %v want %v", id, code, http.StatusLocked)
+			}
+		}
+		if _, err := store.GetByID(t.Context(), "a"); err != nil {
+			t.Errorf("a locked merge deleted the source item: %v", err)
+		}
+		if code := merge("job-42"); code != http.StatusOK {
+			t.Errorf("merge with the lock token: got %v want %v", code, http.StatusOK)
+		}
+	})
+
+	// Sub-test for "Failed Delete"
+	t.Run("Failed Delete", func(t *testing.T) {
+		store = failingDeleteStore{NewMemoryStore(
+			Item{ID: "a", Name: "Source", Tags: []string{"a"}},
+			Item{ID: "b", Name: "Target", Description: "Kept", Tags: []string{"b"}},
+		)}
+		router := newRouter()
+		body := `{"source_id":"a","target_id":"b","strategy":{"name":"keep_source","description":"concat","tags":"union"}}`
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/merge", strings.NewReader(body)))
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusInternalServerError)
+		}
+		target, _ := store.GetByID(t.Context(), "b")
+		if target.Name != "Target" || target.Description != "Kept" || strings.Join(target.Tags, ",") != "b" {
+			t.Errorf("target kept the merged fields after a failed delete: %+v", target)
+		}
+	})
+}
+
+// failingDeleteStore is a Storage whose deletes always fail.
+type failingDeleteStore struct {
+	Storage
+}
+
+func (failingDeleteStore) Delete(ctx context.Context, id string) error {
+	return errors.New("disk full")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// nameLengthBuckets are the upper bounds of the name_length_chars buckets.
+var nameLengthBuckets = []int64{1, 5, 10, 20, 50, 100, 200}
+
+// metricsCollector holds the counters and histograms exposed at GET /metrics.
+type metricsCollector struct {
+	droppedMessages  atomic.Int64
+	shadowMismatches atomic.Int64
+	nameLength       *histogram
+	inFlight         gaugeVec // by route
+}
+
+// newMetricsCollector returns a collector with every metric at zero.
+func newMetricsCollector() *metricsCollector {
+	return &metricsCollector{nameLength: newHistogram(nameLengthBuckets)}
+}
+
+// gaugeVec is a set of gauges told apart by one label's value. It is safe
+// for concurrent use; the zero value is ready to use.
+type gaugeVec struct {
+	values sync.Map // label value -> *atomic.Int64
+}
+
+// add changes the gauge for label by delta.
+func (g *gaugeVec) add(label string, delta int64) {
+	v, _ := g.values.LoadOrStore(label, new(atomic.Int64))
+	v.(*atomic.Int64).Add(delta)
+}
+
+// inFlightMiddleware keeps metrics.inFlight at the number of requests each
+// route is serving. Requests that match no route are not counted.
+func inFlightMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeName(r)
+		inFlight := &metrics.inFlight
+		inFlight.add(route, 1)
+		defer inFlight.add(route, -1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// metrics is the process-wide collector.
+var metrics = newMetricsCollector()
+
+// histogram counts integer observations into buckets with fixed upper
+// bounds, like a Prometheus histogram. It is safe for concurrent use.
+type histogram struct {
+	bounds []int64
+	counts []atomic.Int64 // per bucket, not cumulative; the last is +Inf
+	sum    atomic.Int64
+}
+
+// newHistogram returns an empty histogram with the given ascending bucket bounds.
+func newHistogram(bounds []int64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]atomic.Int64, len(bounds)+1)}
+}
+
+// observe records one value.
+func (h *histogram) observe(v int64) {
+	i := 0
+	for i < len(h.bounds) && v > h.bounds[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(v)
+}
+
+// writeCounter writes a single counter in the Prometheus text exposition format.
+func writeCounter(w io.Writer, name, help string, value int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+}
+
+// writeGaugeVec writes every gauge in g in the Prometheus text exposition
+// format, sorted by label value.
+func writeGaugeVec(w io.Writer, name, help, label string, g *gaugeVec) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	var values []string
+	g.values.Range(func(key, _ any) bool {
+		values = append(values, key.(string))
+		return true
+	})
+	slices.Sort(values)
+	for _, value := range values {
+		v, _ := g.values.Load(value)
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", name, label, value, v.(*atomic.Int64).Load())
+	}
+}
+
+// writeHistogram writes h in the Prometheus text exposition format, with
+// cumulative buckets as the format expects.
+func writeHistogram(w io.Writer, name, help string, h *histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	var cumulative int64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = strconv.FormatInt(h.bounds[i], 10)
+		}
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, le, cumulative)
+	}
+	fmt.Fprintf(w, "%s_sum %d\n%s_count %d\n", name, h.sum.Load(), name, cumulative)
+}
+
+// serveMetrics (GET /metrics)
+// This exposes the collected metrics in the Prometheus text format.
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeCounter(w, "dropped_messages", "Item events dropped because the message queue was full.",
+		metrics.droppedMessages.Load())
+	writeCounter(w, "shadow_mismatches", "Reads and writes where the shadow store disagreed with the primary.",
+		metrics.shadowMismatches.Load())
+	writeHistogram(w, "name_length_chars", "Length in characters of item names as they are created or updated.",
+		metrics.nameLength)
+	writeGaugeVec(w, "in_flight_requests", "Requests being served, by route.", "route", &metrics.inFlight)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+// TestNameLengthHistogram (GET /metrics after POST /items)
+func TestNameLengthHistogram(t *testing.T) {
+	resetGlobalItems()
+	oldMetrics := metrics
+	metrics = newMetricsCollector()
+	defer func() { metrics = oldMetrics }()
+	router := newRouter()
+
+	// Lengths are in runes, so "héllo" is 5 long
+	for _, name := range []string{"a", "héllo", "abcdefgh", strings.Repeat("x", 30), strings.Repeat("x", 250)} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(fmt.Sprintf(`{"name":%q}`, name))))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(rr.Body)
+	if err != nil {
+		t.Fatalf("metrics do not parse: %v", err)
+	}
+	family, ok := families["name_length_chars"]
+	if !ok {
+		t.Fatal("name_length_chars is missing")
+	}
+	h := family.GetMetric()[0].GetHistogram()
+	if h.GetSampleCount() != 5 || h.GetSampleSum() != 1+5+8+30+250 {
+		t.Errorf("wrong count or sum: got %d and %v", h.GetSampleCount(), h.GetSampleSum())
+	}
+	want := map[float64]uint64{1: 1, 5: 2, 10: 3, 20: 3, 50: 4, 100: 4, 200: 4, math.Inf(1): 5}
+	for _, bucket := range h.GetBucket() {
+		if got := bucket.GetCumulativeCount(); got != want[bucket.GetUpperBound()] {
+			t.Errorf("bucket le=%v: got %d want %d", bucket.GetUpperBound(), got, want[bucket.GetUpperBound()])
+		}
+	}
+	if len(h.GetBucket()) != len(want) {
+		t.Errorf("got %d buckets want %d", len(h.GetBucket()), len(want))
+	}
+}
+
+// TestInFlightRequests (GET /metrics during a slow request)
+func TestInFlightRequests(t *testing.T) {
+	resetGlobalItems()
+	oldMetrics := metrics
+	metrics = newMetricsCollector()
+	defer func() { metrics = oldMetrics }()
+	router := newRouter()
+	started, release := make(chan struct{}), make(chan struct{})
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}).Methods("GET")
+
+	inFlight := func() float64 {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+		var parser expfmt.TextParser
+		families, err := parser.TextToMetricFamilies(rr.Body)
+		if err != nil {
+			t.Fatalf("metrics do not parse: %v", err)
+		}
+		for _, m := range families["in_flight_requests"].GetMetric() {
+			if m.GetLabel()[0].GetValue() == "GET /slow" {
+				return m.GetGauge().GetValue()
+			}
+		}
+		t.Fatal("no in_flight_requests gauge for GET /slow")
+		return 0
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	}()
+	<-started
+	if got := inFlight(); got != 1 {
+		t.Errorf("wrong in-flight count during the request: got %v want 1", got)
+	}
+	close(release)
+	<-done
+	if got := inFlight(); got != 0 {
+		t.Errorf("wrong in-flight count after the request: got %v want 0", got)
+	}
+}
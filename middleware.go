@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestIDHeader carries the request ID on both the request and the response.
+const requestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key under which the request ID is stored.
+type requestIDKey struct{}
+
+// newRequestID returns a random 128-bit hex identifier.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// GetRequestID returns the request ID stored in ctx by requestIDMiddleware, or "".
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDMiddleware gives every request an ID.
+// A client-supplied X-Request-ID is kept, otherwise a new one is generated.
+// The ID is echoed in the response header and stored in the request context.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// artificialDelayMiddleware waits for delay before handling each request,
+// so frontend developers can try the API over a simulated slow network.
+// The wait ends early if the client goes away.
+func artificialDelayMiddleware(delay time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// corsAllowedHeaders are the request headers browsers may send cross-origin.
+var corsAllowedHeaders = strings.Join([]string{"Content-Type", "Authorization", apiKeyHeader, requestIDHeader, consistencyHeader}, ", ")
+
+// corsMiddleware lets browsers on the allowed origins call the API.
+// It answers preflight requests itself, advertising maxAge as
+// Access-Control-Max-Age when it is greater than zero so browsers can skip
+// the preflight on later calls.
+func corsMiddleware(allowedOrigins []string, maxAge time.Duration) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(allowed["*"] || allowed[origin]) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, LINK, UNLINK, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			if maxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// concurrencyExempt are routes that stay open while they wait for item
+// events, so they would hold a concurrency slot while doing no work.
+var concurrencyExempt = map[string]bool{"/ws/items": true, "/feed": true}
+
+// concurrencyLimitMiddleware handles at most maxConcurrent requests at a time.
+// Requests over the limit are turned away immediately with 503 and
+// Retry-After rather than queued, so a burst of slow requests cannot pile up.
+// WebSocket and long-poll requests are not counted, or idle listeners
+// would use up the slots.
+func concurrencyLimitMiddleware(maxConcurrent int) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, maxConcurrent)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if concurrencyExempt[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				next.ServeHTTP(w, r)
+			default:
+				w.Header().Set("Retry-After", "1")
+				respondWithError(w, http.StatusServiceUnavailable, "server is busy, try again later")
+			}
+		})
+	}
+}
+
+// bodyMethods are the methods whose request bodies the handlers read.
+var bodyMethods = map[string]bool{"POST": true, "PUT": true, "PATCH": true}
+
+// supportedCharsets are the request body charsets the JSON decoder reads
+// correctly. US-ASCII is a subset of UTF-8, so it needs no conversion.
+var supportedCharsets = map[string]bool{"utf-8": true, "us-ascii": true}
+
+// charsetNormalizationMiddleware turns away POST, PUT and PATCH bodies in a
+// charset other than UTF-8 with 415, since decoding them as UTF-8 would
+// silently garble any non-ASCII text. A body without a charset is taken to
+// be UTF-8, and charset names are compared case-insensitively.
+func charsetNormalizationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		if !bodyMethods[r.Method] || contentType == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		_, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			respondWithError(w, http.StatusUnsupportedMediaType, "malformed Content-Type header")
+			return
+		}
+		if charset, ok := params["charset"]; ok && !supportedCharsets[strings.ToLower(charset)] {
+			respondWithError(w, http.StatusUnsupportedMediaType, "unsupported charset '"+charset+"': request bodies must be UTF-8")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkJSONDepth reports whether no object or array in the JSON read from r
+// is nested more than maxDepth deep; `{"a": [1]}` is 2 deep. It streams the
+// tokens rather than decoding the values. The error is from the decoder, for
+// malformed JSON. Several values in a row, as in JSON Lines, are all checked.
+func checkJSONDepth(r io.Reader, maxDepth int) (bool, error) {
+	decoder := json.NewDecoder(r)
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if depth > maxDepth {
+				return false, nil
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
+
+// maxJSONBodySize is the largest JSON body jsonDepthLimitMiddleware holds
+// in memory while it checks the depth; larger ones get 413.
+const maxJSONBodySize = 10 << 20
+
+// jsonDepthExempt are routes that check the depth themselves, as they stream
+// bodies too large to hold in memory.
+var jsonDepthExempt = map[string]bool{"/items/import/jsonl": true}
+
+// jsonDepthLimitMiddleware turns away POST, PUT and PATCH bodies nested more
+// than maxDepth deep with 400, before a handler decodes them. Bodies that are
+// not JSON, by Content-Type, are passed on untouched; so is malformed JSON,
+// so that the handler reports it the way it always has. The depth is checked
+// as the body is read, so a deep one is turned away without reading the rest.
+func jsonDepthLimitMiddleware(maxDepth int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if !bodyMethods[r.Method] || r.Body == nil || jsonDepthExempt[r.URL.Path] ||
+				(mediaType != "" && !strings.Contains(mediaType, "json")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body := http.MaxBytesReader(w, r.Body, maxJSONBodySize)
+			var read bytes.Buffer
+			ok, err := checkJSONDepth(io.TeeReader(body, &read), maxDepth)
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("JSON body is larger than %d bytes", maxJSONBodySize))
+				return
+			case !ok && err == nil:
+				respondWithError(w, http.StatusBadRequest, fmt.Sprintf("JSON body is nested more than %d levels deep", maxDepth))
+				return
+			}
+			// Malformed JSON stops the check early; hand on the rest unread
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(&read, body), body}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestStartHeader is set by load balancers such as nginx to when they
+// received the request, in Unix milliseconds, optionally prefixed with "t=".
+const requestStartHeader = "X-Request-Start"
+
+// responseTimeHeader carries how long the handler took, e.g. "12.3ms".
+const responseTimeHeader = "X-Response-Time"
+
+// timingWriter adds the timing headers just before the response headers go
+// out, since that is the last moment a header can still be set. It also
+// counts the body bytes, as they go out after any compression.
+type timingWriter struct {
+	http.ResponseWriter
+	start     time.Time
+	queueWait time.Duration
+	queued    bool // whether queueWait is known
+	written   bool
+	bytes     int64
+}
+
+// setTimingHeaders reports the handler time so far and, if known, the queue wait.
+func (t *timingWriter) setTimingHeaders() {
+	if t.written {
+		return
+	}
+	t.written = true
+	handler := time.Since(t.start)
+	t.Header().Set(responseTimeHeader, fmt.Sprintf("%.3fms", float64(handler.Microseconds())/1000))
+	if t.queued {
+		t.Header().Set("Server-Timing", fmt.Sprintf("server;dur=%.1f, queue;dur=%.1f",
+			float64(handler.Microseconds())/1000, float64(t.queueWait.Microseconds())/1000))
+	}
+}
+
+func (t *timingWriter) WriteHeader(code int) {
+	t.setTimingHeaders()
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *timingWriter) Write(b []byte) (int, error) {
+	t.setTimingHeaders()
+	n, err := t.ResponseWriter.Write(b)
+	t.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (t *timingWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// Flush keeps streaming responses working through the wrapper.
+func (t *timingWriter) Flush() {
+	t.setTimingHeaders()
+	http.NewResponseController(t.ResponseWriter).Flush()
+}
+
+// Hijack keeps WebSocket upgrades working through the wrapper.
+func (t *timingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(t.ResponseWriter).Hijack()
+}
+
+// timingMiddleware sets X-Response-Time on every response to how long the
+// handler took until it started the response, in milliseconds. For requests
+// that carry X-Request-Start it also reports in a Server-Timing header how
+// long they waited in the load balancer's queue. Responses with more body
+// than LARGE_RESPONSE_WARN_BYTES are logged as a warning once they are done.
+func timingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		tw := &timingWriter{ResponseWriter: w, start: start}
+		if header := r.Header.Get(requestStartHeader); header != "" {
+			if ms, err := strconv.ParseInt(strings.TrimPrefix(header, "t="), 10, 64); err == nil {
+				// Clocks on different machines can disagree, which would make the wait negative
+				tw.queueWait, tw.queued = max(start.Sub(time.UnixMilli(ms)), 0), true
+			}
+		}
+		next.ServeHTTP(tw, r)
+		tw.setTimingHeaders() // for handlers that never wrote anything
+		if limit := config.WarnResponseBytes; limit > 0 && tw.bytes > limit {
+			slog.Warn("large response", "route", routeName(r), "method", r.Method, "bytes", tw.bytes)
+		}
+	})
+}
+
+// errResponseTooLarge is returned by writes past responseSizeLimitMiddleware's limit.
+var errResponseTooLarge = errors.New("response exceeds MAX_RESPONSE_BYTES")
+
+// limitedWriter passes on at most limit bytes of the body and drops the rest.
+type limitedWriter struct {
+	http.ResponseWriter
+	r         *http.Request
+	limit     int64
+	written   int64
+	truncated bool
+}
+
+func (l *limitedWriter) Write(b []byte) (int, error) {
+	if l.truncated {
+		return 0, errResponseTooLarge
+	}
+	if remaining := l.limit - l.written; int64(len(b)) > remaining {
+		l.truncated = true
+		log.Printf("WARNING: truncated response to %s %s (request %s) at %d bytes",
+			l.r.Method, l.r.URL.Path, GetRequestID(l.r.Context()), l.limit)
+		n, err := l.ResponseWriter.Write(b[:remaining])
+		l.written += int64(n)
+		if err == nil {
+			err = errResponseTooLarge
+		}
+		return n, err
+	}
+	n, err := l.ResponseWriter.Write(b)
+	l.written += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (l *limitedWriter) Unwrap() http.ResponseWriter {
+	return l.ResponseWriter
+}
+
+// Flush keeps streaming responses working through the wrapper.
+func (l *limitedWriter) Flush() {
+	http.NewResponseController(l.ResponseWriter).Flush()
+}
+
+// Hijack keeps WebSocket upgrades working through the wrapper.
+// Hijacked connections are no longer counted.
+func (l *limitedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(l.ResponseWriter).Hijack()
+}
+
+// responseSizeLimitMiddleware cuts response bodies off after maxBytes, so a
+// runaway handler cannot stream gigabytes to a client. The status and headers
+// have usually been sent by then, so the body is truncated rather than
+// replaced with an error, and a warning is logged. Writes past the limit
+// fail with errResponseTooLarge, which stops well-behaved handlers early.
+func responseSizeLimitMiddleware(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&limitedWriter{ResponseWriter: w, r: r, limit: maxBytes}, r)
+		})
+	}
+}
+
+// urlLengthLimitMiddleware answers 414 URI Too Long to requests whose URL is
+// longer than maxLen, before anything else gets to log or parse it.
+func urlLengthLimitMiddleware(maxLen int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.URL.String()) > maxLen {
+				respondWithError(w, http.StatusRequestURITooLong, fmt.Sprintf("URL is longer than %d characters", maxLen))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parseCIDRs parses blocklist entries such as "10.0.0.0/8". It returns
+// every range that parses, and an error naming the ones that do not.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	var errs []error
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, errors.Join(errs...)
+}
+
+// requestIPs returns the addresses a request came from: the connection's
+// peer and every hop listed in X-Forwarded-For. Malformed entries are skipped.
+func requestIPs(r *http.Request) []net.IP {
+	var ips []net.IP
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		ips = append(ips, ip)
+	}
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			if ip := net.ParseIP(strings.TrimSpace(hop)); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips
+}
+
+// ipBlocklistMiddleware answers 403 Forbidden to requests from the blocked
+// ranges. Every X-Forwarded-For hop is checked as well as the peer address,
+// since clients can add hops to the header but cannot remove the proxy's.
+func ipBlocklistMiddleware(cidrs []string) func(http.Handler) http.Handler {
+	blocked, err := parseCIDRs(cidrs)
+	if err != nil {
+		log.Printf("ignoring invalid BLOCKED_CIDRS entries: %v", err)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, ip := range requestIPs(r) {
+				for _, ipNet := range blocked {
+					if ipNet.Contains(ip) {
+						respondWithError(w, http.StatusForbidden, "your IP is blocked")
+						return
+					}
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
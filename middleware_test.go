@@ -0,0 +1,597 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestRequestIDMiddleware checks that every request ends up with an ID.
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r.Context())
+	}))
+
+	// Sub-test for "Generated ID"
+	t.Run("Generated ID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+
+		if seen == "" {
+			t.Fatal("no request ID was stored in the context")
+		}
+		if got := rr.Header().Get(requestIDHeader); got != seen {
+			t.Errorf("response header does not match context: got %q want %q", got, seen)
+		}
+	})
+
+	// Sub-test for "Client ID Kept"
+	t.Run("Client ID Kept", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set(requestIDHeader, "client-id-123")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if seen != "client-id-123" {
+			t.Errorf("client request ID was not kept: got %q", seen)
+		}
+		if got := rr.Header().Get(requestIDHeader); got != "client-id-123" {
+			t.Errorf("client request ID was not echoed: got %q", got)
+		}
+	})
+}
+
+// TestArtificialDelayMiddleware checks the dev-only slow network simulation.
+func TestArtificialDelayMiddleware(t *testing.T) {
+	resetGlobalItems()
+
+	// Sub-test for "Delay Applied"
+	t.Run("Delay Applied", func(t *testing.T) {
+		config.ArtificialDelay = 50 * time.Millisecond
+		defer func() { config.ArtificialDelay = 0 }()
+		router := newRouter()
+
+		start := time.Now()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+		elapsed := time.Since(start)
+
+		if elapsed < 50*time.Millisecond {
+			t.Errorf("request finished too quickly: got %v want at least %v", elapsed, 50*time.Millisecond)
+		}
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v",
+				status, http.StatusOK)
+		}
+	})
+
+	// Sub-test for "Disabled By Default"
+	t.Run("Disabled By Default", func(t *testing.T) {
+		router := newRouter()
+
+		start := time.Now()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+		elapsed := time.Since(start)
+
+		if elapsed >= 50*time.Millisecond {
+			t.Errorf("request was delayed without ARTIFICIAL_DELAY_MS: took %v", elapsed)
+		}
+	})
+}
+
+// TestCORSPreflight checks that preflight responses can be cached by the browser.
+func TestCORSPreflight(t *testing.T) {
+	resetGlobalItems()
+	config.CORSAllowedOrigins = []string{"https://app.example.com"}
+	config.CORSMaxAge = 10 * time.Minute
+	defer func() { config.CORSAllowedOrigins, config.CORSMaxAge = nil, 0 }()
+	router := newRouter()
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("OPTIONS", "/items/1", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Sub-test for "Allowed Origin"
+	t.Run("Allowed Origin", func(t *testing.T) {
+		rr := preflight("https://app.example.com")
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+		}
+		if got := rr.Header().Get("Access-Control-Max-Age"); got != "600" {
+			t.Errorf("Access-Control-Max-Age: got %q want %q", got, "600")
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("Access-Control-Allow-Origin: got %q", got)
+		}
+	})
+
+	// Sub-test for "Other Origin"
+	t.Run("Other Origin", func(t *testing.T) {
+		rr := preflight("https://evil.example.com")
+		if rr.Header().Get("Access-Control-Allow-Origin") != "" || rr.Header().Get("Access-Control-Max-Age") != "" {
+			t.Errorf("CORS headers sent for a disallowed origin: %v", rr.Header())
+		}
+	})
+}
+
+// TestConcurrencyLimitMiddleware checks that requests over the limit are rejected instead of queued.
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	release := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	server := httptest.NewServer(concurrencyLimitMiddleware(2)(slow))
+	defer server.Close()
+
+	statuses := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != "1" {
+				t.Errorf("busy response has wrong Retry-After: %q", resp.Header.Get("Retry-After"))
+			}
+			statuses <- resp.StatusCode
+		}()
+	}
+
+	// Two requests hold the slots until released, so the other three must be turned away
+	for i := 0; i < 3; i++ {
+		if status := <-statuses; status != http.StatusServiceUnavailable {
+			t.Errorf("request over the limit: got %v want %v", status, http.StatusServiceUnavailable)
+		}
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if status := <-statuses; status != http.StatusOK {
+			t.Errorf("request within the limit: got %v want %v", status, http.StatusOK)
+		}
+	}
+}
+
+// TestConcurrencyLimitLongPolls checks that a waiting GET /feed does not
+// use up the only concurrency slot.
+func TestConcurrencyLimitLongPolls(t *testing.T) {
+	resetGlobalItems()
+	feed = NewChangeFeed()
+	oldConfig := config
+	defer func() { config = oldConfig }()
+	config.MaxConcurrentRequests = 1
+	router := newRouter()
+
+	// 1. Start a long poll and let it wait
+	done := make(chan int)
+	go func() {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/feed?timeout=30", nil))
+		done <- rr.Code
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// 2. Other requests are still served
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	// 3. A new item ends the poll
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"Wake"}`)))
+	select {
+	case code := <-done:
+		if code != http.StatusOK {
+			t.Errorf("long poll returned wrong status code: got %v want %v", code, http.StatusOK)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("long poll did not return after the item was created")
+	}
+}
+
+// TestCharsetNormalizationMiddleware checks which request body charsets are accepted.
+func TestCharsetNormalizationMiddleware(t *testing.T) {
+	handler := charsetNormalizationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		method, contentType string
+		want                int
+	}{
+		{"POST", "application/json; charset=utf-8", http.StatusOK},
+		{"PUT", "application/json; charset=UTF-8", http.StatusOK},
+		{"PATCH", "application/json; charset=us-ascii", http.StatusOK},
+		{"POST", "application/json", http.StatusOK},
+		{"POST", "", http.StatusOK},
+		{"POST", "application/json; charset=iso-8859-1", http.StatusUnsupportedMediaType},
+		{"POST", "application/json; charset", http.StatusUnsupportedMediaType},
+		{"GET", "application/json; charset=iso-8859-1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/items", nil)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestServerTimingMiddleware checks the Server-Timing header for X-Request-Start.
+func TestServerTimingMiddleware(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+	durations := regexp.MustCompile(`^server;dur=([0-9.]+), queue;dur=([0-9.]+)$`)
+
+	// Sub-test for "Queued Request"
+	t.Run("Queued Request", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.Header.Set(requestStartHeader, strconv.FormatInt(time.Now().Add(-500*time.Millisecond).UnixMilli(), 10))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		m := durations.FindStringSubmatch(rr.Header().Get("Server-Timing"))
+		if m == nil {
+			t.Fatalf("malformed Server-Timing header: %q", rr.Header().Get("Server-Timing"))
+		}
+		server, _ := strconv.ParseFloat(m[1], 64)
+		queue, _ := strconv.ParseFloat(m[2], 64)
+		if server < 0 {
+			t.Errorf("negative server duration: %v", server)
+		}
+		if queue < 500 || queue > 1000 {
+			t.Errorf("queue duration not close to 500ms: %v", queue)
+		}
+	})
+
+	// Sub-test for "No Request Start"
+	t.Run("No Request Start", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+		if header := rr.Header().Get("Server-Timing"); header != "" {
+			t.Errorf("Server-Timing set without X-Request-Start: %q", header)
+		}
+	})
+}
+
+// TestResponseTimeHeader checks that every response says how long its handler took.
+func TestResponseTimeHeader(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+	responseTime := regexp.MustCompile(`^([0-9.]+)ms$`)
+
+	requests := map[string]*http.Request{
+		"Written Body":   httptest.NewRequest("GET", "/items/1", nil),
+		"Error Response": httptest.NewRequest("GET", "/items/999", nil),
+		"Not Modified": func() *http.Request {
+			req := httptest.NewRequest("GET", "/items", nil)
+			req.Header.Set("If-Modified-Since", "Fri, 01 Jan 2100 00:00:00 GMT")
+			return req
+		}(),
+	}
+	for name, req := range requests {
+		// Sub-test for each kind of response
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			m := responseTime.FindStringSubmatch(rr.Header().Get(responseTimeHeader))
+			if m == nil {
+				t.Fatalf("malformed %s header: %q", responseTimeHeader, rr.Header().Get(responseTimeHeader))
+			}
+			if ms, err := strconv.ParseFloat(m[1], 64); err != nil || ms < 0 {
+				t.Errorf("bad duration %q: %v", m[1], err)
+			}
+		})
+	}
+
+	// Sub-test for "Handler Without Output"
+	t.Run("Handler Without Output", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		timingMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		if rr.Header().Get(responseTimeHeader) == "" {
+			t.Errorf("%s missing when the handler wrote nothing", responseTimeHeader)
+		}
+	})
+}
+
+// TestResponseSizeLimitMiddleware checks that oversized bodies are cut off.
+func TestResponseSizeLimitMiddleware(t *testing.T) {
+	logs := captureLog(t)
+	var writeErr error
+	handler := responseSizeLimitMiddleware(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5 && writeErr == nil; i++ {
+			_, writeErr = w.Write([]byte("abcdef"))
+		}
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+
+	if got := rr.Body.String(); got != "abcdefabcd" {
+		t.Errorf("wrong truncated body: got %q want %q", got, "abcdefabcd")
+	}
+	if writeErr != errResponseTooLarge {
+		t.Errorf("write past the limit returned %v, want errResponseTooLarge", writeErr)
+	}
+	if !strings.Contains(logs.String(), "truncated response to GET /items") {
+		t.Errorf("no truncation warning logged: %q", logs.String())
+	}
+
+	// Bodies within the limit are untouched
+	rr = httptest.NewRecorder()
+	responseSizeLimitMiddleware(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	})).ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+	if got := rr.Body.String(); got != "0123456789" {
+		t.Errorf("body within the limit was changed: got %q", got)
+	}
+}
+
+// TestIPBlocklistMiddleware checks that requests from blocked ranges get 403.
+func TestIPBlocklistMiddleware(t *testing.T) {
+	resetGlobalItems()
+	config.BlockedCIDRs = []string{"10.0.0.0/8", "2001:db8::/32"}
+	defer func() { config.BlockedCIDRs = nil }()
+	router := newRouter()
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		wantForbidden bool
+	}{
+		{"Blocked Forwarded Client", "192.0.2.1:1234", "10.1.2.3", true},
+		{"Blocked Hop In Chain", "192.0.2.1:1234", "203.0.113.5, 10.9.9.9", true},
+		{"Blocked Peer", "10.0.0.1:1234", "", true},
+		{"Blocked IPv6", "[2001:db8::1]:1234", "", true},
+		{"Allowed Forwarded Client", "192.0.2.1:1234", "192.168.1.1", false},
+		{"Allowed Peer", "192.168.1.1:1234", "", false},
+	}
+	for _, tt := range tests {
+		// Sub-test for each client address
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/items", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			want := http.StatusOK
+			if tt.wantForbidden {
+				want = http.StatusForbidden
+			}
+			if rr.Code != want {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, want)
+			}
+			if tt.wantForbidden && !strings.Contains(rr.Body.String(), `"error":"your IP is blocked"`) {
+				t.Errorf("wrong body: %s", rr.Body)
+			}
+		})
+	}
+
+	// Sub-test for "Invalid Entries"
+	t.Run("Invalid Entries", func(t *testing.T) {
+		nets, err := parseCIDRs([]string{"10.0.0.0/8", "not-a-cidr", "300.0.0.0/8"})
+		if len(nets) != 1 || err == nil {
+			t.Errorf("got %v, %v; want one range and an error", nets, err)
+		}
+	})
+}
+
+// TestURLLengthLimitMiddleware checks that overlong URLs get 414.
+func TestURLLengthLimitMiddleware(t *testing.T) {
+	resetGlobalItems()
+	config.MaxURLLength = 64
+	defer func() { config.MaxURLLength = 0 }()
+	router := newRouter()
+	urlOfLength := func(n int) string {
+		prefix := "/items?q="
+		return prefix + strings.Repeat("x", n-len(prefix))
+	}
+
+	// Sub-test for "At The Limit"
+	t.Run("At The Limit", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", urlOfLength(64), nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	// Sub-test for "Over The Limit"
+	t.Run("Over The Limit", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", urlOfLength(65), nil))
+		if rr.Code != http.StatusRequestURITooLong {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusRequestURITooLong)
+		}
+		var body map[string]string
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil || body["error"] != "URL is longer than 64 characters" {
+			t.Errorf("wrong 414 body: %v %v", body, err)
+		}
+	})
+}
+
+// TestLargeResponseWarning checks that big responses are logged with their size.
+func TestLargeResponseWarning(t *testing.T) {
+	logs := captureLog(t)
+	config.WarnResponseBytes = 100 << 10
+	defer func() { config.WarnResponseBytes = 0 }()
+	router := newRouter()
+	router.HandleFunc("/big/{n}", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(mux.Vars(r)["n"])
+		w.Write([]byte(strings.Repeat("x", n)))
+	}).Methods("GET")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/big/1000", nil))
+	if logs.Len() != 0 {
+		t.Errorf("small response was logged: %q", logs.String())
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/big/204800", nil))
+	for _, want := range []string{"WARN large response", `route="GET /big/{n}"`, "method=GET", "bytes=204800"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("warning is missing %q: %q", want, logs.String())
+		}
+	}
+}
+
+// TestJSONDepthLimitMiddleware checks that deeply nested bodies get 400.
+func TestJSONDepthLimitMiddleware(t *testing.T) {
+	resetGlobalItems()
+	config.MaxJSONDepth = 5
+	defer func() { config.MaxJSONDepth = 0 }()
+	router := newRouter()
+	// nested returns an item body whose "extra" field makes it depth levels deep
+	nested := func(depth int) string {
+		return `{"name":"Nested","extra":` + strings.Repeat(`{"a":`, depth-2) + `[]` + strings.Repeat(`}`, depth-2) + `}`
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"At The Limit", nested(5), http.StatusCreated},
+		{"Over The Limit", nested(6), http.StatusBadRequest},
+		{"Malformed JSON Reaches Handler", `{"name":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		// Sub-test for each body
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(tt.body)))
+			if rr.Code != tt.want {
+				t.Errorf("handler returned wrong status code: got %v want %v: %s", rr.Code, tt.want, rr.Body)
+			}
+		})
+	}
+
+	// Sub-test for "Too Large"
+	t.Run("Too Large", func(t *testing.T) {
+		body := `{"name":"Big","description":"` + strings.Repeat("x", maxJSONBodySize) + `"}`
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(body)))
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	// Sub-test for "JSON Lines Checked Per Line"
+	t.Run("JSON Lines Checked Per Line", func(t *testing.T) {
+		body := `{"name":"Shallow"}` + "\n" + nested(6) + "\n"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/import/jsonl", strings.NewReader(body)))
+		var result struct {
+			Imported int    `json:"imported"`
+			Error    string `json:"error"`
+		}
+		json.NewDecoder(rr.Body).Decode(&result)
+		if rr.Code != http.StatusBadRequest || result.Imported != 1 || !strings.HasPrefix(result.Error, "line 2:") {
+			t.Errorf("wrong import result: got %v %+v", rr.Code, result)
+		}
+	})
+
+	// Sub-test for "Depth Count"
+	t.Run("Depth Count", func(t *testing.T) {
+		for body, want := range map[string]bool{
+			`1`:                     true,
+			`[[1],{"a":[2]}]`:       true,
+			`{"a":[[[]]]}`:          false,
+			"{}\n[[[[1]]]]\n":       false,
+			`"[[[[[[ string"`:       true,
+			`{"a":{"b":{}},"c":[]}`: true,
+		} {
+			if ok, err := checkJSONDepth(strings.NewReader(body), 3); ok != want || err != nil {
+				t.Errorf("%s: got %v, %v want %v", body, ok, err, want)
+			}
+		}
+	})
+}
+
+// buildMiddlewareChain wraps handler in mws the way mux.Router.Use does,
+// so the first middleware is the outermost and runs first.
+func buildMiddlewareChain(handler http.Handler, mws ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
+
+// TestMiddlewareOrder checks that the router's middleware runs in the
+// declared order, so a layer moved around in middlewareStack fails here.
+func TestMiddlewareOrder(t *testing.T) {
+	resetGlobalItems()
+	oldConfig := config
+	defer func() { config = oldConfig }()
+	// Turn on every optional middleware
+	config.MaxURLLength = 2048
+	config.MaxResponseBytes = 1 << 20
+	config.BlockedCIDRs = []string{"10.0.0.0/8"}
+	config.RateLimitRPS = 1000
+	config.MaxConcurrentRequests = 10
+	config.CORSAllowedOrigins = []string{"https://app.example.com"}
+	config.MaxJSONDepth = 5
+	config.ArtificialDelay = time.Millisecond
+
+	want := []string{
+		"tracing", "requestID", "timing", "inFlight", "requestCount", "maintenance",
+		"urlLength", "compression", "responseSize", "ipBlocklist", "rateLimit", "concurrencyLimit",
+		"cors", "apiKey", "adminView", "charset", "jsonDepth", "storeSwap", "dryRun",
+		"lock", "artificialDelay", "handler",
+	}
+
+	// 1. Put a recording layer in front of each real middleware
+	var got []string
+	record := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = append(got, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	var mws []func(http.Handler) http.Handler
+	for _, m := range middlewareStack() {
+		mws = append(mws, record(m.name), m.middleware)
+	}
+	handler := buildMiddlewareChain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, "handler")
+		w.WriteHeader(http.StatusNoContent)
+	}), mws...)
+
+	// 2. A plain request must get through every layer, in order
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("middleware ran in the wrong order:\ngot  %v\nwant %v", got, want)
+	}
+}
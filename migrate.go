@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// storeWriteLock keeps writes and POST /admin/migrate apart. Writes hold it
+// for reading while they run, so a migration waits for the writes in flight
+// and holds back new ones until it has swapped the store.
+var storeWriteLock sync.RWMutex
+
+// storeSwapLock guards the global store variable itself. It is only held
+// while a request picks the store up and while a migration swaps it, so
+// reads never wait for a migration's copy.
+var storeSwapLock sync.RWMutex
+
+// currentStore returns the global store, safe against a concurrent swap.
+func currentStore() Storage {
+	storeSwapLock.RLock()
+	defer storeSwapLock.RUnlock()
+	return store
+}
+
+// storeSwapMiddleware pins the current global store into the request context.
+// Writes also hold storeWriteLock while they run. Reads do not, so they keep
+// being served from the old backend during a migration. Admin endpoints are
+// left alone, or the migration request would wait for itself.
+func storeSwapMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if bodyMethods[r.Method] || r.Method == http.MethodDelete {
+			storeWriteLock.RLock()
+			defer storeWriteLock.RUnlock()
+		}
+		next.ServeHTTP(w, r.WithContext(withStore(r.Context(), currentStore())))
+	})
+}
+
+// migrationTarget opens an empty Storage to migrate the items into. It must
+// be a Replacer, so the items can be copied without being stamped as new.
+// path is the "path" from the request body, for file-based backends.
+type migrationTarget func(path string) (Replacer, error)
+
+// migrationTargets are the backends POST /admin/migrate can move the items to.
+var migrationTargets = map[string]migrationTarget{
+	"memory": func(string) (Replacer, error) { return NewMemoryStore(), nil },
+	"sqlite": func(path string) (Replacer, error) {
+		if path == "" {
+			return nil, errors.New("path is required for the sqlite backend")
+		}
+		return NewSQLiteStore(path)
+	},
+}
+
+// withBackend returns a HistoryStore over s that starts with a copy of h's history.
+func (h *HistoryStore) withBackend(s Storage) *HistoryStore {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	moved := NewHistoryStore(s)
+	for id, entries := range h.history {
+		moved.history[id] = append([]HistoricalItem(nil), entries...)
+	}
+	return moved
+}
+
+// migrateStorage (POST /admin/migrate)
+// This copies every item into a new backend, {"target_backend": "sqlite",
+// "path": "items.db"} or {"target_backend": "memory"}, and switches the
+// server over to it. Writes wait until the copy is done; reads carry on
+// against the old backend. On failure the server keeps the old backend.
+// Items are copied as they are, versions and update times included. The new
+// backend is wrapped like the one it replaces (see wrapStore): it keeps the
+// item history and the shadow backend, and gets a fresh cache and name index.
+func migrateStorage(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		TargetBackend string `json:"target_backend"`
+		Path          string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	open, ok := migrationTargets[request.TargetBackend]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("unknown target_backend '%s'", request.TargetBackend))
+		return
+	}
+	target, err := open(request.Path)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("failed to open target backend: %v", err))
+		return
+	}
+
+	start := time.Now()
+	// Only this lock is held during the copy, so reads carry on meanwhile;
+	// no other migration can swap the store, so reading it needs no lock
+	storeWriteLock.Lock()
+	defer storeWriteLock.Unlock()
+
+	var shadow Storage
+	if s, ok := findWrapper[*ShadowStore](store); ok {
+		shadow = s.shadow
+	}
+	items, err := store.GetAll(r.Context())
+	if err == nil {
+		// ReplaceAll keeps Version and UpdatedAt, which CreateBatch would reset
+		err = target.ReplaceAll(r.Context(), items)
+	}
+	var index *NameIndex
+	if err == nil {
+		index, err = wrapStore(r.Context(), target, shadow)
+	}
+	if err != nil {
+		log.Printf("migration to %s failed: %v", request.TargetBackend, err)
+		if c, ok := target.(io.Closer); ok {
+			c.Close()
+		}
+		respondWithError(w, http.StatusInternalServerError, "migration failed; still using the old backend")
+		return
+	}
+	var migrated Storage
+	if h, ok := historyOf(store); ok {
+		migrated = h.withBackend(index)
+	} else {
+		migrated = NewHistoryStore(index)
+	}
+	storeSwapLock.Lock()
+	store = migrated
+	storeSwapLock.Unlock()
+	touchLastModified()
+	log.Printf("Migrated %d items to %s storage", len(items), request.TargetBackend)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"migrated":   len(items),
+		"elapsed_ms": time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMigrateStorage (POST /admin/migrate)
+func TestMigrateStorage(t *testing.T) {
+	resetGlobalItems()
+	old := store
+	target := NewMemoryStore()
+	migrationTargets["test"] = func(string) (Replacer, error) { return target, nil }
+	config.AdminToken = "admin-secret"
+	defer func() {
+		delete(migrationTargets, "test")
+		config.AdminToken = ""
+		resetGlobalItems()
+	}()
+	router := newRouter()
+
+	migrate := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/admin/migrate", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Requires Admin Token", func(t *testing.T) {
+		if rr := migrate("wrong", `{"target_backend":"test"}`); rr.Code != http.StatusUnauthorized {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnauthorized)
+		}
+		if rr := migrate("admin-secret", `{"target_backend":"nope"}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("unknown backend: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		if store != old {
+			t.Error("a rejected migration swapped the store")
+		}
+	})
+
+	t.Run("Migrate", func(t *testing.T) {
+		rr := migrate("admin-secret", `{"target_backend":"test"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		var body struct {
+			Migrated  int   `json:"migrated"`
+			ElapsedMS int64 `json:"elapsed_ms"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.Migrated != 2 || body.ElapsedMS < 0 {
+			t.Errorf("wrong response: got %+v", body)
+		}
+		items, _ := target.GetAll(context.Background())
+		if len(items) != 2 || items[0].ID != "1" || items[1].ID != "2" {
+			t.Errorf("items were not copied to the new backend: %+v", items)
+		}
+	})
+
+	t.Run("Writes Go to the New Backend", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"After"}`)))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		if items, _ := target.GetAll(context.Background()); len(items) != 3 {
+			t.Errorf("new item did not reach the new backend: %+v", items)
+		}
+		if items, _ := old.GetAll(context.Background()); len(items) != 2 {
+			t.Errorf("new item reached the old backend: %+v", items)
+		}
+	})
+	t.Run("Keeps Versions", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("PUT", "/items/1", strings.NewReader(`{"name":"Updated"}`)))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+		}
+		before, _ := store.GetByID(context.Background(), "1")
+		if before.Version != 4 {
+			t.Fatalf("wrong version before the migration: got %d want 4", before.Version)
+		}
+
+		path := filepath.Join(t.TempDir(), "items.db")
+		if rr := migrate("admin-secret", `{"target_backend":"sqlite","path":"`+path+`"}`); rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		if sqlite, ok := findWrapper[*SQLiteStore](store); ok {
+			defer sqlite.Close()
+		}
+		after, err := store.GetByID(context.Background(), "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after.Version != before.Version || !after.UpdatedAt.Equal(before.UpdatedAt) || !after.CreatedAt.Equal(before.CreatedAt) {
+			t.Errorf("migration changed the bookkeeping fields: got %+v want %+v", after, before)
+		}
+	})
+
+	t.Run("Keeps Wrappers", func(t *testing.T) {
+		oldCacheSize := config.CacheSize
+		defer func() { config.CacheSize = oldCacheSize }()
+		config.CacheSize = 8
+		shadow := NewMemoryStore()
+		store = NewHistoryStore(NewShadowStore(NewMemoryStore(Item{ID: "1", Name: "Shadowed"}), shadow))
+
+		if rr := migrate("admin-secret", `{"target_backend":"test"}`); rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		if s, ok := findWrapper[*ShadowStore](store); !ok || s.shadow != shadow {
+			t.Error("the migrated store lost its shadow backend")
+		}
+		if _, ok := findWrapper[*CachedStore](store); !ok {
+			t.Error("the migrated store lost its cache")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/cache/warm", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("POST /cache/warm after the migration: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("Closes Target on Failure", func(t *testing.T) {
+		failing := &failingReplacer{MemoryStore: NewMemoryStore()}
+		migrationTargets["failing"] = func(string) (Replacer, error) { return failing, nil }
+		defer delete(migrationTargets, "failing")
+		before := store
+
+		if rr := migrate("admin-secret", `{"target_backend":"failing"}`); rr.Code != http.StatusInternalServerError {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusInternalServerError)
+		}
+		if !failing.closed {
+			t.Error("the target of a failed migration was not closed")
+		}
+		if store != before {
+			t.Error("a failed migration swapped the store")
+		}
+	})
+}
+
+// failingReplacer fails every ReplaceAll and records whether it was closed.
+type failingReplacer struct {
+	*MemoryStore
+	closed bool
+}
+
+func (f *failingReplacer) ReplaceAll(ctx context.Context, items []Item) error {
+	return errors.New("disk full")
+}
+
+func (f *failingReplacer) Close() error {
+	f.closed = true
+	return nil
+}
+
+// blockingReplacer holds up ReplaceAll until release is closed, to catch a
+// migration in the middle of its copy.
+type blockingReplacer struct {
+	*MemoryStore
+	copying chan struct{}
+	release chan struct{}
+}
+
+func (b blockingReplacer) ReplaceAll(ctx context.Context, items []Item) error {
+	close(b.copying)
+	<-b.release
+	return b.MemoryStore.ReplaceAll(ctx, items)
+}
+
+// TestMigrateStorageReadsCarryOn checks that reads are served during a
+// migration's copy while writes wait for it.
+func TestMigrateStorageReadsCarryOn(t *testing.T) {
+	resetGlobalItems()
+	target := blockingReplacer{NewMemoryStore(), make(chan struct{}), make(chan struct{})}
+	migrationTargets["blocking"] = func(string) (Replacer, error) { return target, nil }
+	config.AdminToken = "admin-secret"
+	defer func() {
+		delete(migrationTargets, "blocking")
+		config.AdminToken = ""
+		resetGlobalItems()
+	}()
+	router := newRouter()
+
+	// 1. Start a migration and wait until it is copying
+	migrated := make(chan int)
+	go func() {
+		req := httptest.NewRequest("POST", "/admin/migrate", strings.NewReader(`{"target_backend":"blocking"}`))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		migrated <- rr.Code
+	}()
+	<-target.copying
+
+	// 2. A read is answered from the old backend meanwhile
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/1", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	// 3. A write waits for the copy, then lands in the new backend
+	written := make(chan int)
+	go func() {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"During"}`)))
+		written <- rr.Code
+	}()
+	select {
+	case code := <-written:
+		t.Fatalf("write finished during the copy with %v", code)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(target.release)
+	if code := <-migrated; code != http.StatusOK {
+		t.Fatalf("migration returned wrong status code: got %v want %v", code, http.StatusOK)
+	}
+	if code := <-written; code != http.StatusCreated {
+		t.Errorf("write returned wrong status code: got %v want %v", code, http.StatusCreated)
+	}
+	if items, _ := target.GetAll(context.Background()); len(items) != 3 {
+		t.Errorf("write did not reach the new backend: %+v", items)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// NameIndex wraps a Storage with a map from lowercased item names to IDs,
+// kept current by every write that goes through it, so case-insensitive
+// name lookups do not have to scan every item. When several items share a
+// name, the index points at the earliest one.
+type NameIndex struct {
+	Storage
+
+	mu     sync.RWMutex
+	byName map[string]string // lowercased name -> item ID
+	byID   map[string]string // item ID -> lowercased name
+}
+
+// NewNameIndex returns s with an empty name index; call Rebuild to fill it.
+func NewNameIndex(s Storage) *NameIndex {
+	return &NameIndex{Storage: s, byName: make(map[string]string), byID: make(map[string]string)}
+}
+
+// Unwrap returns the wrapped Storage.
+func (n *NameIndex) Unwrap() Storage {
+	return n.Storage
+}
+
+// Ping checks the wrapped backend, if it can be checked.
+func (n *NameIndex) Ping(ctx context.Context) error {
+	if p, ok := n.Storage.(pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// Rebuild indexes every item from scratch, for startup and for changes
+// made to the backend behind the index's back.
+func (n *NameIndex) Rebuild(ctx context.Context) error {
+	items, err := n.Storage.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.byName = make(map[string]string, len(items))
+	n.byID = make(map[string]string, len(items))
+	for _, item := range items {
+		n.add(item)
+	}
+	return nil
+}
+
+// add indexes item. The caller must hold n.mu.
+func (n *NameIndex) add(item Item) {
+	name := strings.ToLower(item.Name)
+	n.byID[item.ID] = name
+	if _, taken := n.byName[name]; !taken {
+		n.byName[name] = item.ID
+	}
+}
+
+// remove drops id from the index, handing its name to another item that
+// has it, if any. The caller must hold n.mu.
+func (n *NameIndex) remove(id string) {
+	name, ok := n.byID[id]
+	if !ok {
+		return
+	}
+	delete(n.byID, id)
+	if n.byName[name] != id {
+		return
+	}
+	delete(n.byName, name)
+	for other, otherName := range n.byID {
+		if otherName == name {
+			n.byName[name] = other
+			return
+		}
+	}
+}
+
+// set re-indexes items after they were written.
+func (n *NameIndex) set(items ...Item) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, item := range items {
+		if n.byID[item.ID] == strings.ToLower(item.Name) {
+			continue
+		}
+		n.remove(item.ID)
+		n.add(item)
+	}
+}
+
+// GetByName returns the item whose lowercased name is lowercasedName.
+func (n *NameIndex) GetByName(lowercasedName string) (Item, bool) {
+	n.mu.RLock()
+	id, ok := n.byName[lowercasedName]
+	n.mu.RUnlock()
+	if !ok {
+		return Item{}, false
+	}
+	item, err := n.Storage.GetByID(context.Background(), id)
+	// The item may have been renamed since the lookup
+	if err != nil || strings.ToLower(item.Name) != lowercasedName {
+		return Item{}, false
+	}
+	return item, true
+}
+
+// Create indexes the new item.
+func (n *NameIndex) Create(ctx context.Context, item Item) (Item, error) {
+	created, err := n.Storage.Create(ctx, item)
+	if err == nil {
+		n.set(created)
+	}
+	return created, err
+}
+
+// CreateBatch indexes the new items.
+func (n *NameIndex) CreateBatch(ctx context.Context, items []Item) ([]Item, error) {
+	created, err := n.Storage.CreateBatch(ctx, items)
+	if err == nil {
+		n.set(created...)
+	}
+	return created, err
+}
+
+// Update re-indexes the item under its new name.
+func (n *NameIndex) Update(ctx context.Context, id string, fn func(item *Item) error) (Item, error) {
+	updated, err := n.Storage.Update(ctx, id, fn)
+	if err == nil {
+		n.set(updated)
+	}
+	return updated, err
+}
+
+// UpdateBatch re-indexes the items under their new names.
+func (n *NameIndex) UpdateBatch(ctx context.Context, ids []string, fn func(item *Item) error) ([]Item, error) {
+	updated, err := n.Storage.UpdateBatch(ctx, ids, fn)
+	if err == nil {
+		n.set(updated...)
+	}
+	return updated, err
+}
+
+// Delete drops the item from the index.
+func (n *NameIndex) Delete(ctx context.Context, id string) error {
+	err := n.Storage.Delete(ctx, id)
+	if err == nil {
+		n.mu.Lock()
+		n.remove(id)
+		n.mu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestNameIndex
+func TestNameIndex(t *testing.T) {
+	ctx := t.Context()
+	index := NewNameIndex(NewMemoryStore(Item{ID: "1", Name: "Loaded At Startup"}))
+	if err := index.Rebuild(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sub-test for "Rebuilt On Startup"
+	t.Run("Rebuilt On Startup", func(t *testing.T) {
+		if item, ok := index.GetByName("loaded at startup"); !ok || item.ID != "1" {
+			t.Errorf("startup item not indexed: %+v %v", item, ok)
+		}
+	})
+
+	// Sub-test for "Case-Insensitive Lookup"
+	t.Run("Case-Insensitive Lookup", func(t *testing.T) {
+		index.Create(ctx, Item{ID: "2", Name: "MiXeD CaSe"})
+		index.Create(ctx, Item{ID: "3", Name: "ÉCLAIR"})
+		if item, ok := index.GetByName("mixed case"); !ok || item.ID != "2" {
+			t.Errorf("wrong lookup: %+v %v", item, ok)
+		}
+		if item, ok := index.GetByName("éclair"); !ok || item.ID != "3" {
+			t.Errorf("wrong lookup: %+v %v", item, ok)
+		}
+		if _, ok := index.GetByName("nothing"); ok {
+			t.Error("unknown name resolved")
+		}
+	})
+
+	// Sub-test for "Rename"
+	t.Run("Rename", func(t *testing.T) {
+		index.Update(ctx, "2", func(item *Item) error {
+			item.Name = "Renamed"
+			return nil
+		})
+		if _, ok := index.GetByName("mixed case"); ok {
+			t.Error("old name still resolves")
+		}
+		if item, ok := index.GetByName("renamed"); !ok || item.ID != "2" {
+			t.Errorf("new name does not resolve: %+v %v", item, ok)
+		}
+	})
+
+	// Sub-test for "Shared Name"
+	t.Run("Shared Name", func(t *testing.T) {
+		index.Create(ctx, Item{ID: "4", Name: "renamed"})
+		if item, _ := index.GetByName("renamed"); item.ID != "2" {
+			t.Errorf("the earliest item should win: got %s", item.ID)
+		}
+		index.Delete(ctx, "2")
+		if item, ok := index.GetByName("renamed"); !ok || item.ID != "4" {
+			t.Errorf("name was not handed over on delete: %+v %v", item, ok)
+		}
+	})
+}
+
+// TestCreateItemDedupNameIndexed (POST /items with X-Dedup-Name over a NameIndex)
+func TestCreateItemDedupNameIndexed(t *testing.T) {
+	resetGlobalItems()
+	index := NewNameIndex(store)
+	index.Rebuild(t.Context())
+	store = NewHistoryStore(index)
+	defer resetGlobalItems()
+	router := newRouter()
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(body))
+		req.Header.Set(dedupNameHeader, "true")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := create(`{"name":"MOCK ITEM 1"}`); rr.Code != http.StatusOK || rr.Header().Get(deduplicatedHeader) != "true" {
+		t.Errorf("existing item was not found through the index: %v %s", rr.Code, rr.Body)
+	}
+	if rr := create(`{"name":"Brand New"}`); rr.Code != http.StatusCreated {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+	if rr := create(`{"name":"brand new"}`); rr.Code != http.StatusOK {
+		t.Errorf("new item was not indexed: got %v want %v", rr.Code, http.StatusOK)
+	}
+}
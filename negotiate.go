@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// negotiate picks the offered media type the client prefers according to its
+// Accept header, honouring q-values and wildcards such as "text/*" and "*/*".
+// The first offer is the default: it is returned when Accept is empty or
+// nothing offered is acceptable. Among equally weighted matches, earlier offers win.
+func negotiate(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	best, bestQ := offers[0], 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(accept, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the q-value the Accept header gives mediaType,
+// using the most specific matching range. Zero means not acceptable.
+func acceptQuality(accept, mediaType string) float64 {
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+
+		var s int
+		switch {
+		case mediaRange == mediaType:
+			s = 2
+		case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+			s = 1
+		case mediaRange == "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+
+		rangeQ := 1.0
+		for _, param := range params[1:] {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					rangeQ = parsed
+				}
+			}
+		}
+		q, specificity = rangeQ, s
+	}
+	return q
+}
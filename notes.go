@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Note is a timestamped comment left on an item. Notes are kept apart from
+// the item, so adding or removing one does not change its UpdatedAt.
+type Note struct {
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NotesStore keeps the notes of every item in memory, oldest first.
+type NotesStore struct {
+	mu    sync.RWMutex
+	notes map[string][]Note
+}
+
+// NewNotesStore returns an empty NotesStore.
+func NewNotesStore() *NotesStore {
+	return &NotesStore{notes: make(map[string][]Note)}
+}
+
+// notes holds the item notes used by the handlers.
+var notes = NewNotesStore()
+
+// Add appends a new note to an item, filling in its ID and CreatedAt.
+func (s *NotesStore) Add(itemID string, n Note) Note {
+	n.ID = newRandomID()
+	n.CreatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notes[itemID] = append(s.notes[itemID], n)
+	return n
+}
+
+// List returns the notes of an item, newest first.
+func (s *NotesStore) List(itemID string) []Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := slices.Clone(s.notes[itemID])
+	slices.Reverse(list)
+	if list == nil {
+		list = []Note{}
+	}
+	return list
+}
+
+// Delete removes a note from an item and reports whether it existed.
+func (s *NotesStore) Delete(itemID, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.notes[itemID], func(n Note) bool { return n.ID == id })
+	if i < 0 {
+		return false
+	}
+	s.notes[itemID] = slices.Delete(s.notes[itemID], i, i+1)
+	return true
+}
+
+// DeleteItem removes every note of an item.
+func (s *NotesStore) DeleteItem(itemID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.notes, itemID)
+}
+
+// addNote (POST /items/{id}/notes)
+// This appends {"text": "...", "author": "..."} to the item's notes.
+func addNote(w http.ResponseWriter, r *http.Request) {
+	itemID := mux.Vars(r)["id"]
+	if _, err := storeFromContext(r.Context()).GetByID(r.Context(), itemID); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	var n Note
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if strings.TrimSpace(n.Text) == "" {
+		respondWithError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+	n = Note{Text: n.Text, Author: n.Author}
+	if isDryRun(r.Context()) {
+		n.CreatedAt = time.Now().UTC()
+	} else {
+		n = notes.Add(itemID, n)
+	}
+	respondWithJSON(w, http.StatusCreated, n)
+}
+
+// listNotes (GET /items/{id}/notes)
+// This returns the item's notes, newest first.
+func listNotes(w http.ResponseWriter, r *http.Request) {
+	itemID := mux.Vars(r)["id"]
+	if _, err := storeFromContext(r.Context()).GetByID(r.Context(), itemID); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, notes.List(itemID))
+}
+
+// deleteNote (DELETE /items/{id}/notes/{nid})
+// This removes a note from an item.
+func deleteNote(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	itemID, id := params["id"], params["nid"]
+	found := false
+	if isDryRun(r.Context()) {
+		found = slices.ContainsFunc(notes.List(itemID), func(n Note) bool { return n.ID == id })
+	} else {
+		found = notes.Delete(itemID, id)
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Note not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "note_deleted": id})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestNotes (POST, GET and DELETE /items/{id}/notes)
+func TestNotes(t *testing.T) {
+	resetGlobalItems()
+	notes = NewNotesStore()
+	router := newRouter()
+	before, _ := store.GetByID(t.Context(), "1")
+
+	listNotes := func() []Note {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/1/notes", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var list []Note
+		json.NewDecoder(rr.Body).Decode(&list)
+		return list
+	}
+
+	// 1. Add three notes
+	var added []Note
+	for _, text := range []string{"first", "second", "third"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/1/notes", strings.NewReader(`{"text":"`+text+`","author":"sam"}`)))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body)
+		}
+		var n Note
+		json.NewDecoder(rr.Body).Decode(&n)
+		if n.ID == "" || n.Text != text || n.Author != "sam" || n.CreatedAt.IsZero() {
+			t.Errorf("wrong note: %+v", n)
+		}
+		added = append(added, n)
+	}
+
+	// 2. List, newest first
+	list := listNotes()
+	if len(list) != 3 || list[0].Text != "third" || list[2].Text != "first" {
+		t.Errorf("wrong note list: %+v", list)
+	}
+	if after, _ := store.GetByID(t.Context(), "1"); !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("notes changed the item's updated_at: got %v want %v", after.UpdatedAt, before.UpdatedAt)
+	}
+
+	// 3. Delete one
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/1/notes/"+added[1].ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if list := listNotes(); len(list) != 2 || list[0].ID != added[2].ID || list[1].ID != added[0].ID {
+		t.Errorf("wrong notes after delete: %+v", list)
+	}
+
+	// 4. Delete it again
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/items/1/notes/"+added[1].ID, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+
+	// 5. Empty text
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/1/notes", strings.NewReader(`{"text":" "}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	// 6. Unknown item
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/999/notes", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
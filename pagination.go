@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// pageTokenHeader carries the token for the next page of GET /items.
+	pageTokenHeader = "X-Page-Token"
+	// defaultPerPage is the page size when ?page= is given without ?per_page=.
+	defaultPerPage = 20
+	// maxPerPage caps ?per_page= so a single page stays cheap to serve.
+	maxPerPage = 1000
+)
+
+// errInvalidPageToken is returned for page tokens that are malformed or tampered with.
+var errInvalidPageToken = errors.New("invalid page_token")
+
+// pageState is the pagination state carried by a page token.
+type pageState struct {
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+	Total   int    `json:"total"`
+	Sort    string `json:"sort,omitempty"`
+	Order   string `json:"order,omitempty"`
+}
+
+// generatedPageTokenSecret signs page tokens when PAGE_TOKEN_SECRET is unset.
+// It is random per process, so such tokens stop working after a restart.
+var generatedPageTokenSecret = sync.OnceValue(func() []byte {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return b
+})
+
+// pageTokenSecret returns the HMAC key for page tokens.
+func pageTokenSecret() []byte {
+	if config.PageTokenSecret != "" {
+		return []byte(config.PageTokenSecret)
+	}
+	return generatedPageTokenSecret()
+}
+
+// pageTokenMAC returns the HMAC-SHA256 of payload.
+func pageTokenMAC(payload []byte) []byte {
+	mac := hmac.New(sha256.New, pageTokenSecret())
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// generatePageToken encodes state as "<payload>.<signature>", both base64url.
+func generatePageToken(state pageState) string {
+	payload, _ := json.Marshal(state) // a pageState always marshals
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(pageTokenMAC(payload))
+}
+
+// validatePageToken checks a token's signature and returns the state it carries.
+func validatePageToken(token string) (pageState, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return pageState{}, errInvalidPageToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return pageState{}, errInvalidPageToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, pageTokenMAC(payload)) {
+		return pageState{}, errInvalidPageToken
+	}
+	var state pageState
+	if err := json.Unmarshal(payload, &state); err != nil || state.Page < 1 || state.PerPage < 1 {
+		return pageState{}, errInvalidPageToken
+	}
+	return state, nil
+}
+
+// parsePageParams reads ?page= and ?per_page= or a ?page_token=.
+// It reports false when the request is not paginated at all.
+func parsePageParams(query url.Values) (pageState, bool, error) {
+	if token := query.Get("page_token"); token != "" {
+		state, err := validatePageToken(token)
+		return state, err == nil, err
+	}
+	if query.Get("page") == "" && query.Get("per_page") == "" {
+		return pageState{}, false, nil
+	}
+	state := pageState{Page: 1, PerPage: defaultPerPage}
+	if value := query.Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return pageState{}, false, errors.New("page must be a positive integer")
+		}
+		state.Page = n
+	}
+	if value := query.Get("per_page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPerPage {
+			return pageState{}, false, errors.New("per_page must be between 1 and 1000")
+		}
+		state.PerPage = n
+	}
+	return state, true, nil
+}
+
+// pageCount is how many pages state.Total items fill.
+func (state pageState) pageCount() int {
+	return (state.Total + state.PerPage - 1) / state.PerPage
+}
+
+// next returns the state of the following page, if there is one.
+func (state pageState) next() (pageState, bool) {
+	if state.Page >= state.pageCount() {
+		return pageState{}, false
+	}
+	state.Page++
+	return state, true
+}
+
+// paginate returns the items on the given page, which may be empty.
+func paginate(items []Item, state pageState) []Item {
+	if state.Page > len(items)/state.PerPage+1 {
+		return []Item{}
+	}
+	start := (state.Page - 1) * state.PerPage
+	end := min(start+state.PerPage, len(items))
+	return items[start:end]
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestPageToken checks that page tokens round-trip and cannot be altered.
+func TestPageToken(t *testing.T) {
+	config.PageTokenSecret = "test-secret"
+	defer func() { config.PageTokenSecret = "" }()
+
+	state := pageState{Page: 2, PerPage: 10, Total: 35, Sort: "name", Order: "desc"}
+	token := generatePageToken(state)
+
+	t.Run("Round Trip", func(t *testing.T) {
+		got, err := validatePageToken(token)
+		if err != nil {
+			t.Fatalf("valid token rejected: %v", err)
+		}
+		if got != state {
+			t.Errorf("wrong state: got %+v want %+v", got, state)
+		}
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		forged := state
+		forged.Page = 3
+		payload, _ := json.Marshal(forged)
+		_, signature, _ := strings.Cut(token, ".")
+		tampered := []string{
+			"",
+			"garbage",
+			base64.RawURLEncoding.EncodeToString(payload) + "." + signature,
+			"f" + token[1:], // the payload always starts with "eyJ", base64 for `{"`
+		}
+		for _, tok := range tampered {
+			if _, err := validatePageToken(tok); err == nil {
+				t.Errorf("tampered token %q was accepted", tok)
+			}
+		}
+	})
+
+	t.Run("Other Secret", func(t *testing.T) {
+		config.PageTokenSecret = "another-secret"
+		defer func() { config.PageTokenSecret = "test-secret" }()
+		if _, err := validatePageToken(token); err == nil {
+			t.Error("token signed with a different secret was accepted")
+		}
+	})
+}
+
+// TestGetItemsPagination (GET /items?page=&per_page=&page_token=)
+func TestGetItemsPagination(t *testing.T) {
+	var seed []Item
+	for i := 1; i <= 5; i++ {
+		seed = append(seed, Item{ID: strconv.Itoa(i), Name: "Item " + strconv.Itoa(i)})
+	}
+	store = NewMemoryStore(seed...)
+	defer resetGlobalItems()
+	router := newRouter()
+
+	get := func(path string) (*httptest.ResponseRecorder, []string) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return rr, ids
+	}
+
+	// 1. Walk every page by following the tokens
+	rr, ids := get("/items?per_page=2&sort=name&order=desc")
+	pages := [][]string{ids}
+	for token := rr.Header().Get(pageTokenHeader); token != ""; token = rr.Header().Get(pageTokenHeader) {
+		if len(pages) > 5 {
+			t.Fatal("page tokens never ran out")
+		}
+		rr, ids = get("/items?page_token=" + token)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		pages = append(pages, ids)
+	}
+	want := [][]string{{"5", "4"}, {"3", "2"}, {"1"}}
+	if len(pages) != len(want) {
+		t.Fatalf("wrong pages: got %v want %v", pages, want)
+	}
+	for i := range want {
+		if len(pages[i]) != len(want[i]) || pages[i][0] != want[i][0] {
+			t.Errorf("page %d: got %v want %v", i+1, pages[i], want[i])
+		}
+	}
+
+	// 2. A tampered token is rejected
+	first, _ := get("/items?per_page=2")
+	token := first.Header().Get(pageTokenHeader)
+	if rr, _ := get("/items?page_token=f" + token[1:]); rr.Code != http.StatusBadRequest {
+		t.Errorf("tampered token: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	// 3. Bad page parameters are rejected, and no parameters means no pagination
+	if rr, _ := get("/items?page=0"); rr.Code != http.StatusBadRequest {
+		t.Errorf("page=0: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+	if rr, ids := get("/items"); len(ids) != 5 || rr.Header().Get(pageTokenHeader) != "" {
+		t.Errorf("unpaginated request: got %v items, token %q", ids, rr.Header().Get(pageTokenHeader))
+	}
+}
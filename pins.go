@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// setPinned pins or unpins an item. Pinning an already pinned item is not an error.
+func setPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	item, err := storeFromContext(r.Context()).Update(withCounterUpdate(r.Context()), id, func(item *Item) error {
+		item.Pinned = pinned
+		return nil
+	})
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemUpdated, item)
+
+	respondWithJSON(w, http.StatusOK, item)
+}
+
+// pinItem (POST /items/{id}/pin)
+// This pins an item, so GET /items lists it before every unpinned item.
+func pinItem(w http.ResponseWriter, r *http.Request) {
+	setPinned(w, r, true)
+}
+
+// unpinItem (POST /items/{id}/unpin)
+// This unpins an item, returning it to its place in the normal sort order.
+func unpinItem(w http.ResponseWriter, r *http.Request) {
+	setPinned(w, r, false)
+}
+
+// filterUnpinned keeps only the items that are not pinned.
+func filterUnpinned(items []Item) []Item {
+	filtered := []Item{}
+	for _, item := range items {
+		if !item.Pinned {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestPinnedItems (POST /items/{id}/pin, POST /items/{id}/unpin)
+func TestPinnedItems(t *testing.T) {
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "Alpha"},
+		Item{ID: "2", Name: "Bravo"},
+		Item{ID: "3", Name: "Charlie"},
+		Item{ID: "4", Name: "Delta"},
+	)
+	defer resetGlobalItems()
+	router := newRouter()
+
+	post := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", path, nil))
+		return rr
+	}
+	list := func(path string) []string {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	for _, id := range []string{"4", "2"} {
+		rr := post("/items/" + id + "/pin")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		if !item.Pinned {
+			t.Errorf("item %s was not pinned: %+v", id, item)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/items?sort=name", []string{"2", "4", "1", "3"}},
+		{"/items?sort=name&order=desc", []string{"4", "2", "3", "1"}},
+		{"/items?sort=name&include_pinned=false", []string{"1", "3"}},
+	}
+	for _, tt := range tests {
+		if got := list(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET %s: got %v want %v", tt.path, got, tt.want)
+		}
+	}
+
+	t.Run("Unpin", func(t *testing.T) {
+		post("/items/4/unpin")
+		if got, want := list("/items?sort=name"), []string{"2", "1", "3", "4"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("after unpinning: got %v want %v", got, want)
+		}
+		if rr := post("/items/missing/pin"); rr.Code != http.StatusNotFound {
+			t.Errorf("unknown item: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
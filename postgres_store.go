@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // PostgreSQL driver, registered as "pgx"
+)
+
+// Connection pool limits for PostgresStore.
+const (
+	postgresMaxOpenConns    = 25
+	postgresMaxIdleConns    = 5
+	postgresConnMaxLifetime = 30 * time.Minute
+)
+
+// postgresSchema creates the items table if it does not exist yet.
+// It mirrors the SQLite schema; seq records insertion order. Columns added
+// after the first schema are added to existing tables by the ALTER statements.
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS items (
+	seq         BIGSERIAL,
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL,
+	tags_json   JSONB NOT NULL DEFAULT '[]',
+	created_at  TIMESTAMPTZ NOT NULL,
+	updated_at  TIMESTAMPTZ NOT NULL,
+	deleted_at  TIMESTAMPTZ,
+	version     INTEGER NOT NULL DEFAULT 1
+);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS extra_json JSONB NOT NULL DEFAULT '{}'`
+
+// PostgresStore is a Storage backed by a PostgreSQL database.
+// All queries are parameterized; user input is never spliced into SQL.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database at databaseURL, checks the
+// connection and makes sure the schema exists.
+func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(postgresMaxOpenConns)
+	db.SetMaxIdleConns(postgresMaxIdleConns)
+	db.SetConnMaxLifetime(postgresConnMaxLifetime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connecting to database: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, postgresSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema: %w", err)
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Close releases every pooled connection.
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks that the database is reachable; it backs GET /health.
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// postgresItemColumns is the column list read by scanPostgresItem.
+const postgresItemColumns = "id, name, description, tags_json, created_at, updated_at, version, extra_json"
+
+// scanPostgresItem reads the columns selected by postgresItemColumns into an Item.
+func scanPostgresItem(row rowScanner) (Item, error) {
+	var item Item
+	var id, name, description, tags, extra string
+	var createdAt, updatedAt time.Time
+	var version int
+	if err := row.Scan(&id, &name, &description, &tags, &createdAt, &updatedAt, &version, &extra); err != nil {
+		return Item{}, err
+	}
+	if err := decodeExtra(extra, &item); err != nil {
+		return Item{}, err
+	}
+	item.ID, item.Name, item.Description, item.Version = id, name, description, version
+	item.CreatedAt, item.UpdatedAt = createdAt, updatedAt
+	var err error
+	if item.Tags, err = decodeTags(tags); err != nil {
+		return Item{}, err
+	}
+	item.CreatedAt = item.CreatedAt.UTC()
+	item.UpdatedAt = item.UpdatedAt.UTC()
+	return item, nil
+}
+
+// GetAll returns every non-deleted item in insertion order.
+func (s *PostgresStore) GetAll(ctx context.Context) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+postgresItemColumns+" FROM items WHERE deleted_at IS NULL ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Item{}
+	for rows.Next() {
+		item, err := scanPostgresItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetByID returns the non-deleted item with the given ID.
+func (s *PostgresStore) GetByID(ctx context.Context, id string) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	item, err := scanPostgresItem(s.db.QueryRowContext(ctx,
+		"SELECT "+postgresItemColumns+" FROM items WHERE id = $1 AND deleted_at IS NULL", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Item{}, errItemNotFound
+	}
+	return item, err
+}
+
+// postgresExecer is satisfied by both *sql.DB and *sql.Tx.
+type postgresExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertPostgresItem inserts a new item, inside or outside a transaction.
+func insertPostgresItem(ctx context.Context, db postgresExecer, item Item) (Item, error) {
+	// Soft-deleted rows still own their ID, so the conflict covers them too
+	stampCreated(&item)
+	result, err := db.ExecContext(ctx,
+		`INSERT INTO items (id, name, description, tags_json, created_at, updated_at, version, extra_json)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (id) DO NOTHING`,
+		item.ID, item.Name, item.Description, encodeTags(item.Tags), item.CreatedAt, item.UpdatedAt, item.Version, encodeExtra(item))
+	if err != nil {
+		return Item{}, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return Item{}, err
+	} else if n == 0 {
+		return Item{}, errDuplicateID
+	}
+	return item, nil
+}
+
+// updatePostgresItem applies fn to a stored item and writes the result back as part of tx.
+// The row is locked with SELECT ... FOR UPDATE until the transaction ends.
+func updatePostgresItem(ctx context.Context, tx *sql.Tx, id string, fn func(*Item) error) (Item, error) {
+	item, err := scanPostgresItem(tx.QueryRowContext(ctx,
+		"SELECT "+postgresItemColumns+" FROM items WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Item{}, errItemNotFound
+	}
+	if err != nil {
+		return Item{}, err
+	}
+	if err := fn(&item); err != nil {
+		return Item{}, err
+	}
+	stampUpdated(ctx, &item)
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE items SET name = $1, description = $2, tags_json = $3, updated_at = $4, version = $5, extra_json = $6 WHERE id = $7",
+		item.Name, item.Description, encodeTags(item.Tags), item.UpdatedAt, item.Version, encodeExtra(item), id); err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+// Create inserts a new item. A single INSERT is atomic, so no transaction is needed.
+func (s *PostgresStore) Create(ctx context.Context, item Item) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	return insertPostgresItem(ctx, s.db, item)
+}
+
+// CreateBatch inserts every item inside a single transaction.
+func (s *PostgresStore) CreateBatch(ctx context.Context, items []Item) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created := make([]Item, len(items))
+	for i, item := range items {
+		if created[i], err = insertPostgresItem(ctx, tx, item); err != nil {
+			return nil, err
+		}
+	}
+	return created, tx.Commit()
+}
+
+// Update applies fn to the stored item and writes the result back inside a transaction.
+func (s *PostgresStore) Update(ctx context.Context, id string, fn func(*Item) error) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Item{}, err
+	}
+	defer tx.Rollback()
+
+	item, err := updatePostgresItem(ctx, tx, id, fn)
+	if err != nil {
+		return Item{}, err
+	}
+	return item, tx.Commit()
+}
+
+// UpdateBatch applies fn to every listed item inside a single transaction.
+func (s *PostgresStore) UpdateBatch(ctx context.Context, ids []string, fn func(*Item) error) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updated := make([]Item, len(ids))
+	for i, id := range ids {
+		if updated[i], err = updatePostgresItem(ctx, tx, id, fn); err != nil {
+			return nil, err
+		}
+	}
+	return updated, tx.Commit()
+}
+
+// Delete soft-deletes the item with the given ID.
+func (s *PostgresStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE items SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errItemNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted removes the rows of soft-deleted items for good and returns
+// how many it removed. Their IDs can be used again afterwards.
+func (s *PostgresStore) PurgeDeleted(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	result, err := s.db.ExecContext(ctx, "DELETE FROM items WHERE deleted_at IS NOT NULL")
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
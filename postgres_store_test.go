@@ -0,0 +1,65 @@
+//go:build postgres
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// startPostgres runs a throwaway PostgreSQL container and returns a store connected to it.
+// It needs a working Docker daemon.
+func startPostgres(t *testing.T) *PostgresStore {
+	t.Helper()
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx, "postgres:16-alpine",
+		postgres.WithDatabase("demojam"),
+		postgres.WithUsername("demojam"),
+		postgres.WithPassword("demojam"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(60*time.Second)),
+	)
+	if err != nil {
+		t.Fatalf("failed to start PostgreSQL container: %v", err)
+	}
+	t.Cleanup(func() { container.Terminate(ctx) })
+
+	databaseURL, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("failed to get connection string: %v", err)
+	}
+	s, err := NewPostgresStore(databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open PostgreSQL store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// TestPostgresStore runs the storage test table against a real PostgreSQL server.
+func TestPostgresStore(t *testing.T) {
+	s := startPostgres(t)
+
+	runStorageTests(t, func(t *testing.T) Storage {
+		// Every case starts from an empty table
+		if _, err := s.db.Exec("TRUNCATE items"); err != nil {
+			t.Fatalf("failed to truncate items: %v", err)
+		}
+		return s
+	})
+
+	// Sub-test for "Ping"
+	t.Run("Ping", func(t *testing.T) {
+		if err := s.Ping(context.Background()); err != nil {
+			t.Errorf("Ping failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+)
+
+// pusherOf finds the http.Pusher behind w, looking through the middleware
+// writers that wrap it. It reports false on HTTP/1.x connections.
+func pusherOf(w http.ResponseWriter) (http.Pusher, bool) {
+	for {
+		if p, ok := w.(http.Pusher); ok {
+			return p, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// pushItems uses HTTP/2 server push to send the detail pages of the first
+// HTTP2_PUSH_COUNT items along with the list, so clients that go on to fetch
+// them already have them. The pushed requests carry the caller's API key and
+// Accept header. It does nothing when the connection cannot push.
+func pushItems(w http.ResponseWriter, r *http.Request, items []Item) {
+	pusher, ok := pusherOf(w)
+	if !ok || config.HTTP2PushCount <= 0 {
+		return
+	}
+	header := http.Header{}
+	for _, name := range []string{"Accept", apiKeyHeader} {
+		if value := r.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	for _, item := range items[:min(config.HTTP2PushCount, len(items))] {
+		err := pusher.Push("/items/"+item.ID, &http.PushOptions{Header: header})
+		if errors.Is(err, http.ErrNotSupported) {
+			return // the client turned push off
+		}
+		if err != nil {
+			log.Printf("failed to push item %s: %v", item.ID, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// pushedResponse is a resource the server pushed, as seen by h2Get.
+type pushedResponse struct {
+	header http.Header
+	body   bytes.Buffer
+	done   bool
+}
+
+// h2Get fetches path over a raw HTTP/2 connection with push enabled, which
+// net/http's client does not support. It returns the main response body and
+// the pushed resources by path.
+func h2Get(t *testing.T, srv *httptest.Server, path string) (string, map[string]*pushedResponse) {
+	t.Helper()
+	conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"h2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	conn.Write([]byte(http2.ClientPreface))
+	framer := http2.NewFramer(conn, conn)
+	framer.WriteSettings(http2.Setting{ID: http2.SettingEnablePush, Val: 1})
+
+	var block bytes.Buffer
+	enc := hpack.NewEncoder(&block)
+	for _, f := range [][2]string{{":method", "GET"}, {":scheme", "https"}, {":authority", "localhost"}, {":path", path}} {
+		enc.WriteField(hpack.HeaderField{Name: f[0], Value: f[1]})
+	}
+	framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: block.Bytes(), EndStream: true, EndHeaders: true})
+
+	var body bytes.Buffer
+	pushed := map[string]*pushedResponse{}
+	streams := map[uint32]*pushedResponse{}
+	mainDone := false
+	dec := hpack.NewDecoder(4096, nil)
+	finished := func() bool {
+		for _, p := range streams {
+			if !p.done {
+				return false
+			}
+		}
+		return mainDone
+	}
+	for !finished() {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatalf("reading frames: %v", err)
+		}
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PushPromiseFrame:
+			fields, _ := dec.DecodeFull(f.HeaderBlockFragment())
+			p := &pushedResponse{header: http.Header{}}
+			for _, field := range fields {
+				if field.Name == ":path" {
+					pushed[field.Value] = p
+				}
+			}
+			streams[f.PromiseID] = p
+		case *http2.HeadersFrame:
+			fields, _ := dec.DecodeFull(f.HeaderBlockFragment())
+			if p, ok := streams[f.StreamID]; ok {
+				for _, field := range fields {
+					p.header.Add(field.Name, field.Value)
+				}
+				p.done = f.StreamEnded()
+			} else {
+				mainDone = f.StreamEnded()
+			}
+		case *http2.DataFrame:
+			if p, ok := streams[f.StreamID]; ok {
+				p.body.Write(f.Data())
+				p.done = f.StreamEnded()
+			} else {
+				body.Write(f.Data())
+				mainDone = f.StreamEnded()
+			}
+		case *http2.GoAwayFrame:
+			t.Fatalf("server sent GOAWAY: %v", f.ErrCode)
+		}
+	}
+	return body.String(), pushed
+}
+
+// TestHTTP2Push (GET /items over HTTP/2)
+func TestHTTP2Push(t *testing.T) {
+	resetGlobalItems()
+	oldConfig := config
+	defer func() { config = oldConfig }()
+
+	srv := httptest.NewUnstartedServer(newRouter())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	// Sub-test for "Detail Pages Pushed"
+	t.Run("Detail Pages Pushed", func(t *testing.T) {
+		config.HTTP2PushCount = 5
+		body, pushed := h2Get(t, srv, "/items")
+		if !bytes.Contains([]byte(body), []byte(`"id":"1"`)) {
+			t.Errorf("unexpected list body: %s", body)
+		}
+		if len(pushed) != 2 {
+			t.Fatalf("got %d pushed resources want 2", len(pushed))
+		}
+		for _, path := range []string{"/items/1", "/items/2"} {
+			p, ok := pushed[path]
+			if !ok {
+				t.Fatalf("%s was not pushed", path)
+			}
+			if ct := p.header.Get("content-type"); ct != "application/json" {
+				t.Errorf("%s pushed with Content-Type %q", path, ct)
+			}
+			if p.header.Get(":status") != "200" || p.body.Len() == 0 {
+				t.Errorf("%s pushed without a body: %v", path, p.header)
+			}
+		}
+	})
+
+	// Sub-test for "Push Count Limit"
+	t.Run("Push Count Limit", func(t *testing.T) {
+		config.HTTP2PushCount = 1
+		if _, pushed := h2Get(t, srv, "/items"); len(pushed) != 1 || pushed["/items/1"] == nil {
+			t.Errorf("wrong pushed resources: %v", pushed)
+		}
+	})
+
+	// Sub-test for "Client Without Push"
+	t.Run("Client Without Push", func(t *testing.T) {
+		config.HTTP2PushCount = 5
+		resp, err := srv.Client().Get(srv.URL + "/items")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != 2 {
+			t.Fatalf("expected HTTP/2, got %s", resp.Proto)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", resp.StatusCode, http.StatusOK)
+		}
+	})
+
+	// Sub-test for "HTTP/1.1"
+	t.Run("HTTP/1.1", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		newRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Message is a unit of work carried by the MessageQueue.
+type Message struct {
+	Event       ItemEvent
+	PublishedAt time.Time
+}
+
+// MessageQueue decouples write handlers from event delivery.
+// Publish drops into a buffered channel and returns at once; Start runs
+// worker goroutines that hand each message to every subscriber.
+// Messages are delivered in order only when there is a single worker.
+type MessageQueue struct {
+	messages chan Message
+	workers  int
+
+	mu          sync.RWMutex
+	subscribers []func(Message)
+
+	wg sync.WaitGroup
+}
+
+// NewMessageQueue returns a queue holding up to depth pending messages,
+// drained by the given number of workers once Start is called.
+func NewMessageQueue(depth, workers int) *MessageQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &MessageQueue{
+		messages: make(chan Message, depth),
+		workers:  workers,
+	}
+}
+
+// events carries item events to their subscribers.
+// When nil (as in most tests) events are handed to the hub directly.
+var events *MessageQueue
+
+// Subscribe registers handler to be called for every delivered message.
+// Handlers may be called from several workers at once.
+func (q *MessageQueue) Subscribe(handler func(Message)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.subscribers = append(q.subscribers, handler)
+}
+
+// Publish queues msg without blocking. If the queue is full the message is
+// dropped, counted in the dropped_messages metric, and false is returned.
+func (q *MessageQueue) Publish(msg Message) bool {
+	select {
+	case q.messages <- msg:
+		return true
+	default:
+		metrics.droppedMessages.Add(1)
+		return false
+	}
+}
+
+// Start launches the worker goroutines.
+func (q *MessageQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Close stops accepting messages and waits for the workers to deliver what is queued.
+// Publish must not be called after Close.
+func (q *MessageQueue) Close() {
+	close(q.messages)
+	q.wg.Wait()
+}
+
+// work delivers messages until the queue is closed.
+func (q *MessageQueue) work() {
+	defer q.wg.Done()
+	for msg := range q.messages {
+		q.mu.RLock()
+		subscribers := q.subscribers
+		q.mu.RUnlock()
+
+		for _, handler := range subscribers {
+			handler(msg)
+		}
+	}
+}
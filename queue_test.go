@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestMessageQueueDeliversAll publishes a burst of messages into a queue
+// with enough room and checks that every one reaches the subscriber.
+func TestMessageQueueDeliversAll(t *testing.T) {
+	const count = 1000
+	metrics.droppedMessages.Store(0)
+
+	q := NewMessageQueue(count, 4)
+	var mu sync.Mutex
+	received := make(map[string]bool)
+	q.Subscribe(func(msg Message) {
+		mu.Lock()
+		received[msg.Event.Item.ID] = true
+		mu.Unlock()
+	})
+	q.Start()
+
+	for i := 0; i < count; i++ {
+		if !q.Publish(Message{Event: ItemEvent{Type: eventItemCreated, Item: Item{ID: strconv.Itoa(i)}}}) {
+			t.Fatalf("message %d was dropped", i)
+		}
+	}
+	q.Close() // Waits for the workers to drain the queue
+
+	if len(received) != count {
+		t.Errorf("not every message was delivered: got %d want %d", len(received), count)
+	}
+	if dropped := metrics.droppedMessages.Load(); dropped != 0 {
+		t.Errorf("messages were dropped: got %d want 0", dropped)
+	}
+}
+
+// TestMessageQueueDropsWhenFull checks that Publish never blocks and that drops show up at /metrics.
+func TestMessageQueueDropsWhenFull(t *testing.T) {
+	metrics.droppedMessages.Store(0)
+
+	// Not started, so nothing drains the single slot
+	q := NewMessageQueue(1, 1)
+	q.Publish(Message{})
+	q.Publish(Message{})
+	q.Publish(Message{})
+
+	rr := httptest.NewRecorder()
+	serveMetrics(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), "\ndropped_messages 2\n") {
+		t.Errorf("metrics do not report 2 dropped messages:\n%s", rr.Body.String())
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRateLimitClients is how many clients the rate limiter tracks before it
+// forgets the ones whose buckets have filled up again.
+const maxRateLimitClients = 10000
+
+// tokenBucket holds up to burst tokens and gains rps of them every second.
+// Each request takes one.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per client.
+type rateLimiter struct {
+	rps   float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// newRateLimiter returns a limiter allowing rps requests per second with
+// bursts of up to burst requests for each client.
+func newRateLimiter(rps, burst int) *rateLimiter {
+	return &rateLimiter{rps: float64(rps), burst: float64(burst), buckets: make(map[string]*tokenBucket)}
+}
+
+// refill adds the tokens b has gained since it was last used.
+func (l *rateLimiter) refill(b *tokenBucket, now time.Time) {
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rps)
+	b.last = now
+}
+
+// take spends one of client's tokens if there is one. It returns whether
+// the request may go ahead, how many whole tokens are left and when the
+// bucket will be full again.
+func (l *rateLimiter) take(client string, now time.Time) (bool, int, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitClients {
+			l.forgetFull(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	l.refill(b, now)
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	full := now.Add(time.Duration((l.burst - b.tokens) / l.rps * float64(time.Second)))
+	return allowed, int(b.tokens), full
+}
+
+// forgetFull drops the buckets that have filled up again; a new bucket
+// starts full, so nothing changes for those clients.
+func (l *rateLimiter) forgetFull(now time.Time) {
+	for client, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// rateLimitClient identifies who a request counts against: its API key if
+// that is valid, otherwise the peer address. This runs before
+// apiKeyMiddleware, so the key has to be checked here; made-up keys must
+// not get a fresh bucket each.
+func rateLimitClient(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" && apiKeys != nil && apiKeys.Valid(r.Context(), key) {
+		return "key:" + key
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// rateLimitMiddleware allows each client rps requests per second, in bursts
+// of up to burst, and turns away the rest with 429 and Retry-After. Every
+// response carries the client's state: X-RateLimit-Limit (the burst),
+// X-RateLimit-Remaining and X-RateLimit-Reset, the Unix time at which the
+// bucket is full again.
+func rateLimitMiddleware(rps, burst int) func(http.Handler) http.Handler {
+	limiter := newRateLimiter(rps, burst)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			allowed, remaining, full := limiter.take(rateLimitClient(r), now)
+			header := w.Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(burst))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			header.Set("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(float64(full.UnixMilli())/1000)), 10))
+			if !allowed {
+				// A token is at most 1/rps seconds away
+				header.Set("Retry-After", strconv.Itoa(int(math.Ceil(1/limiter.rps))))
+				respondWithError(w, http.StatusTooManyRequests, "rate limit exceeded, try again later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
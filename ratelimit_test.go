@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestRateLimitMiddleware checks the rate limit headers and the 429 once a client runs out.
+func TestRateLimitMiddleware(t *testing.T) {
+	resetGlobalItems()
+	config.RateLimitRPS, config.RateLimitBurst = 1, 10
+	defer func() { config.RateLimitRPS, config.RateLimitBurst = 0, 0 }()
+	router := newRouter()
+
+	get := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 1. Each request takes one token; the bucket refills by one a second
+	for i := 1; i <= 5; i++ {
+		rr := get("192.0.2.1:1234")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if got := rr.Header().Get("X-RateLimit-Limit"); got != "10" {
+			t.Errorf("wrong X-RateLimit-Limit: got %s want 10", got)
+		}
+		if got := rr.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(10-i) {
+			t.Errorf("request %d: wrong X-RateLimit-Remaining: got %s want %d", i, got, 10-i)
+		}
+		reset, err := strconv.ParseInt(rr.Header().Get("X-RateLimit-Reset"), 10, 64)
+		now := time.Now().Unix()
+		if err != nil || reset <= now || reset > now+int64(i)+1 {
+			t.Errorf("request %d: X-RateLimit-Reset %q is not %d seconds ahead", i, rr.Header().Get("X-RateLimit-Reset"), i)
+		}
+	}
+
+	// 2. Running out gets a 429, still with the headers
+	for i := 6; i <= 10; i++ {
+		get("192.0.2.1:1234")
+	}
+	rr := get("192.0.2.1:1234")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+	if rr.Header().Get("X-RateLimit-Remaining") != "0" || rr.Header().Get("Retry-After") != "1" {
+		t.Errorf("wrong headers on the 429: %v", rr.Header())
+	}
+
+	// 3. Other clients have buckets of their own
+	if rr := get("192.0.2.2:1234"); rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Remaining") != "9" {
+		t.Errorf("second client was limited: got %v with %s remaining", rr.Code, rr.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+// TestRateLimitInvalidKeys checks that only valid API keys get a bucket of
+// their own, so made-up keys cannot dodge the limit.
+func TestRateLimitInvalidKeys(t *testing.T) {
+	resetGlobalItems()
+	withAPIKeys(t, NewAPIKeyStore([]string{"valid-key"}, nil))
+	config.RateLimitRPS, config.RateLimitBurst = 1, 3
+	defer func() { config.RateLimitRPS, config.RateLimitBurst = 0, 0 }()
+	router := newRouter()
+
+	get := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/items", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(apiKeyHeader, key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 1. A new invalid key every time still drains the IP's bucket
+	for i := 1; i <= 3; i++ {
+		if rr := get("made-up-" + strconv.Itoa(i)); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnauthorized)
+		}
+	}
+	if rr := get("made-up-4"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusTooManyRequests)
+	}
+
+	// 2. A valid key from the same address has its own bucket
+	if rr := get("valid-key"); rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Remaining") != "2" {
+		t.Errorf("valid key was limited: got %v with %s remaining", rr.Code, rr.Header().Get("X-RateLimit-Remaining"))
+	}
+}
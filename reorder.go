@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Reorderer is a Storage whose item order can be set explicitly.
+// Only MemoryStore keeps an order of its own; the SQL backends list by creation.
+type Reorderer interface {
+	Storage
+	Reorder(ctx context.Context, ids []string) error
+}
+
+// reorderError lists how an ordering differs from the stored items.
+type reorderError struct {
+	Missing    []string `json:"missing,omitempty"`
+	Unknown    []string `json:"unknown,omitempty"`
+	Duplicates []string `json:"duplicates,omitempty"`
+}
+
+func (e *reorderError) Error() string {
+	return "ordered_ids must list every item exactly once"
+}
+
+// checkReorder compares ids with the stored items.
+func checkReorder(stored []Item, ids []string) error {
+	var e reorderError
+	known := make(map[string]bool, len(stored))
+	for _, item := range stored {
+		known[item.ID] = true
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		switch {
+		case seen[id]:
+			e.Duplicates = append(e.Duplicates, id)
+		case !known[id]:
+			e.Unknown = append(e.Unknown, id)
+		}
+		seen[id] = true
+	}
+	for _, item := range stored {
+		if !seen[item.ID] {
+			e.Missing = append(e.Missing, item.ID)
+		}
+	}
+	if e.Missing == nil && e.Unknown == nil && e.Duplicates == nil {
+		return nil
+	}
+	return &e
+}
+
+// reorderItems (POST /items/reorder)
+// This sets the order GET /items lists items in when no ?sort= is given.
+// The body {"ordered_ids":[...]} must name every item exactly once.
+func reorderItems(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		OrderedIDs []string `json:"ordered_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	s, ok := findWrapper[Reorderer](storeFromContext(r.Context()))
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "this storage backend cannot reorder items")
+		return
+	}
+	var err error
+	if isDryRun(r.Context()) {
+		// Reorder bypasses the dry-run wrapper, so only check the ordering
+		var items []Item
+		if items, err = s.GetAll(r.Context()); err == nil {
+			err = checkReorder(items, request.OrderedIDs)
+		}
+	} else if err = s.Reorder(r.Context(), request.OrderedIDs); err == nil {
+		touchLastModified()
+	}
+	if err != nil {
+		respondWithReorderError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"result": "success", "ordered_ids": request.OrderedIDs})
+}
+
+// respondWithReorderError maps an error from a reorder to a JSON error
+// response, listing the discrepancies when the ordering was wrong.
+func respondWithReorderError(w http.ResponseWriter, err error) {
+	var e *reorderError
+	if !errors.As(err, &e) {
+		respondWithStorageError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+		"error":      e.Error(),
+		"missing":    e.Missing,
+		"unknown":    e.Unknown,
+		"duplicates": e.Duplicates,
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReorderItems (POST /items/reorder)
+func TestReorderItems(t *testing.T) {
+	store = NewMemoryStore(Item{ID: "a", Name: "A"}, Item{ID: "b", Name: "B"}, Item{ID: "c", Name: "C"})
+	defer resetGlobalItems()
+	router := newRouter()
+
+	reorder := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/reorder", strings.NewReader(body)))
+		return rr
+	}
+	order := func() []string {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	// 1. A complete ordering is applied
+	if rr := reorder(`{"ordered_ids":["c","a","b"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	if got, want := order(), []string{"c", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GET /items ignored the new order: got %v want %v", got, want)
+	}
+
+	// 2. An incomplete ordering is rejected with the discrepancies
+	rr := reorder(`{"ordered_ids":["a","x","a"]}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+	var body reorderError
+	json.NewDecoder(rr.Body).Decode(&body)
+	want := reorderError{Missing: []string{"c", "b"}, Unknown: []string{"x"}, Duplicates: []string{"a"}}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("wrong discrepancies: got %+v want %+v", body, want)
+	}
+	if got, want := order(), []string{"c", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("a rejected ordering changed the order: got %v want %v", got, want)
+	}
+
+	// 3. Dry runs only check the ordering
+	if rr := reorder(`{"ordered_ids":["a","b","c"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/reorder?dry_run=true", strings.NewReader(`{"ordered_ids":["b","c","a"]}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("dry run returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if got, want := order(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dry run changed the order: got %v want %v", got, want)
+	}
+}
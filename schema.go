@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// itemSchema is the JSON Schema from SCHEMA_FILE that item payloads must
+// match, on top of validateItem's checks. It is nil when none is configured.
+var itemSchema *jsonschema.Schema
+
+// loadItemSchema compiles the JSON Schema in the file at path.
+// Schemas without a $schema keyword are read as draft 7.
+func loadItemSchema(path string) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft7)
+	return c.Compile(path)
+}
+
+// schemaFieldError is one reason a payload does not match the item schema.
+// Field is a JSON pointer into the payload, "" for the payload itself.
+type schemaFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// checkItemSchema validates a request body against itemSchema and returns
+// every failing field. It returns nil when the body matches or no schema is set.
+func checkItemSchema(body []byte) []schemaFieldError {
+	if itemSchema == nil {
+		return nil
+	}
+	payload, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return []schemaFieldError{{Message: "payload is not valid JSON"}}
+	}
+	err = itemSchema.Validate(payload)
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []schemaFieldError{{Message: err.Error()}}
+	}
+	var details []schemaFieldError
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error != nil {
+			details = append(details, schemaFieldError{Field: unit.InstanceLocation, Message: unit.Error.String()})
+		}
+	}
+	return details
+}
+
+// respondWithSchemaErrors sends 422 Unprocessable Entity listing the schema failures.
+func respondWithSchemaErrors(w http.ResponseWriter, details []schemaFieldError) {
+	respondWithJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"error":   "item does not match the schema",
+		"details": details,
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestItemSchema checks that SCHEMA_FILE rules apply to creates and updates.
+func TestItemSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "item.schema.json")
+	schemaJSON := `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {"name": {"type": "string", "pattern": "^[A-Z][a-z]+$"}}
+	}`
+	if err := os.WriteFile(path, []byte(schemaJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := loadItemSchema(path)
+	if err != nil {
+		t.Fatalf("failed to load schema: %v", err)
+	}
+	resetGlobalItems()
+	itemSchema = schema
+	defer func() { itemSchema = nil }()
+	router := newRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+
+	t.Run("Matching Name", func(t *testing.T) {
+		if rr := send("POST", "/items", `{"name":"Widget"}`); rr.Code != http.StatusCreated {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+	})
+
+	for _, method := range []string{"POST", "PUT"} {
+		t.Run(method+" Non-Matching Name", func(t *testing.T) {
+			path := "/items"
+			if method == "PUT" {
+				path = "/items/1"
+			}
+			rr := send(method, path, `{"name":"widget-2"}`)
+			if rr.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusUnprocessableEntity)
+			}
+			var body struct {
+				Error   string             `json:"error"`
+				Details []schemaFieldError `json:"details"`
+			}
+			json.NewDecoder(rr.Body).Decode(&body)
+			if len(body.Details) != 1 || body.Details[0].Field != "/name" || !strings.Contains(body.Details[0].Message, "^[A-Z][a-z]+$") {
+				t.Errorf("wrong schema error detail: %+v", body)
+			}
+		})
+	}
+
+	t.Run("Bad Schema File", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.json")
+		os.WriteFile(bad, []byte(`{"type": 42}`), 0o600)
+		if _, err := loadItemSchema(bad); err == nil {
+			t.Error("an invalid schema was compiled")
+		}
+	})
+}
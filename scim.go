@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// scimMediaType is the media type of SCIM 2.0 requests and responses (RFC 7644).
+	scimMediaType = "application/scim+json"
+	// scimListResponseSchema identifies a SCIM list response.
+	scimListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	// scimItemSchema identifies items as a SCIM resource type of their own.
+	scimItemSchema = "urn:demojam:params:scim:schemas:core:1.0:Item"
+)
+
+// scimListResponse is a page of SCIM resources, as GET /items returns it
+// for Accept: application/scim+json.
+type scimListResponse struct {
+	Schemas      []string                 `json:"schemas"`
+	TotalResults int                      `json:"totalResults"`
+	StartIndex   int                      `json:"startIndex"`
+	ItemsPerPage int                      `json:"itemsPerPage"`
+	Resources    []map[string]interface{} `json:"Resources"`
+}
+
+// toSCIMResource maps an item to a SCIM resource: the name becomes
+// displayName and the timestamps and version go into "meta". The version is
+// an internal field, so it is only included when admin is set.
+func toSCIMResource(item Item, admin bool) map[string]interface{} {
+	lastModified := item.UpdatedAt
+	if lastModified.IsZero() {
+		lastModified = item.CreatedAt
+	}
+	meta := map[string]interface{}{
+		"resourceType": "Item",
+		"created":      item.CreatedAt.Format(time.RFC3339),
+		"lastModified": lastModified.Format(time.RFC3339),
+		"location":     "/items/" + item.ID,
+	}
+	if admin {
+		meta["version"] = `W/"` + strconv.Itoa(item.Version) + `"`
+	}
+	resource := map[string]interface{}{
+		"schemas":     []string{scimItemSchema},
+		"id":          item.ID,
+		"displayName": item.Name,
+		"description": item.Description,
+		"meta":        meta,
+	}
+	if len(item.Tags) > 0 {
+		resource["tags"] = item.Tags
+	}
+	return resource
+}
+
+// scimList wraps a page of items in a SCIM list response. total is the
+// number of items on all pages, and startIndex the 1-based position of the
+// first item on this one. admin is passed on to toSCIMResource.
+func scimList(items []Item, total, startIndex int, admin bool) scimListResponse {
+	resources := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		resources = append(resources, toSCIMResource(item, admin))
+	}
+	return scimListResponse{
+		Schemas:      []string{scimListResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(items),
+		Resources:    resources,
+	}
+}
+
+// isSCIMRequest reports whether r's body is a SCIM resource, by Content-Type.
+func isSCIMRequest(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == scimMediaType
+}
+
+// scimToItemJSON translates a SCIM create request into the item JSON that
+// POST /items takes, so it goes through the same checks as any other body.
+func scimToItemJSON(body []byte) ([]byte, error) {
+	var resource struct {
+		Schemas     []string `json:"schemas"`
+		DisplayName string   `json:"displayName"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+	}
+	if err := json.Unmarshal(body, &resource); err != nil {
+		return nil, errors.New("Invalid request payload")
+	}
+	if !slices.Contains(resource.Schemas, scimItemSchema) {
+		return nil, errors.New("schemas must include " + scimItemSchema)
+	}
+	return json.Marshal(map[string]interface{}{
+		"name":        resource.DisplayName,
+		"description": resource.Description,
+		"tags":        resource.Tags,
+	})
+}
+
+// writeSCIM writes payload as application/scim+json with the given status code.
+func writeSCIM(w http.ResponseWriter, code int, payload interface{}) {
+	response, err := json.Marshal(payload)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to marshal JSON response")
+		return
+	}
+	w.Header().Set("Content-Type", scimMediaType)
+	w.WriteHeader(code)
+	w.Write(response)
+}
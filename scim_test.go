@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestSCIM (GET and POST /items as application/scim+json)
+func TestSCIM(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	// Sub-test for "List Response"
+	t.Run("List Response", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/items?per_page=1&page=2", nil)
+		req.Header.Set("Accept", scimMediaType)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if got := rr.Header().Get("Content-Type"); got != scimMediaType {
+			t.Errorf("Content-Type: got %q want %q", got, scimMediaType)
+		}
+		var list scimListResponse
+		if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Schemas) != 1 || list.Schemas[0] != scimListResponseSchema {
+			t.Errorf("schemas: got %v want [%s]", list.Schemas, scimListResponseSchema)
+		}
+		if list.TotalResults != 2 || list.StartIndex != 2 || list.ItemsPerPage != 1 || len(list.Resources) != 1 {
+			t.Fatalf("unexpected list: %+v", list)
+		}
+		if got := list.Resources[0]["displayName"]; got != "Mock Item 2" {
+			t.Errorf("displayName: got %v want %q", got, "Mock Item 2")
+		}
+	})
+
+	// Sub-test for "Create"
+	t.Run("Create", func(t *testing.T) {
+		body := `{"schemas":["` + scimItemSchema + `"],"displayName":"SCIM Item","description":"From an IdP","tags":["sync"]}`
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(body))
+		req.Header.Set("Content-Type", scimMediaType)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusCreated, rr.Body)
+		}
+		var resource map[string]interface{}
+		json.NewDecoder(rr.Body).Decode(&resource)
+		id, _ := resource["id"].(string)
+		item, err := store.GetByID(t.Context(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Name != "SCIM Item" || item.Description != "From an IdP" || len(item.Tags) != 1 {
+			t.Errorf("unexpected item: %+v", item)
+		}
+	})
+
+	// Sub-test for "Missing Schema"
+	t.Run("Missing Schema", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"displayName":"No Schema"}`))
+		req.Header.Set("Content-Type", scimMediaType)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
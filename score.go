@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// computedFields are the JSON fields worked out on every read. Clients may
+// not send them when creating an item, just like read-only fields in updates.
+var computedFields = map[string]bool{"score": true}
+
+// findComputedField returns the first computed field in a JSON object body, or "".
+func findComputedField(body []byte) string {
+	var fields map[string]json.RawMessage
+	json.Unmarshal(body, &fields) // callers have already decoded body once
+	for key := range fields {
+		if computedFields[strings.ToLower(key)] {
+			return key
+		}
+	}
+	return ""
+}
+
+// computeScore rates an item's engagement from 0 to 100: likes and views
+// raise it, and the newness bonus fades over its first 20 days.
+func computeScore(item Item) float64 {
+	days := time.Since(item.CreatedAt).Hours() / 24
+	score := 0.4*float64(item.Likes) + 0.3*float64(item.ViewCount) + 0.3*(20-days)
+	return min(max(score, 0), 100)
+}
+
+// withScores fills in the Score of every item in place.
+func withScores(items []Item) {
+	for i := range items {
+		items[i].Score = computeScore(items[i])
+	}
+}
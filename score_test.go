@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestComputeScore
+func TestComputeScore(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		item Item
+		want float64
+	}{
+		{"New", Item{CreatedAt: now}, 6},
+		{"Engaged", Item{CreatedAt: now.Add(-10 * 24 * time.Hour), Likes: 10, ViewCount: 20}, 4 + 6 + 3},
+		{"Old", Item{CreatedAt: now.Add(-365 * 24 * time.Hour)}, 0},
+		{"Viral", Item{CreatedAt: now, Likes: 1000}, 100},
+	}
+	for _, tt := range tests {
+		// Sub-test for each item
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeScore(tt.item); got < tt.want-0.01 || got > tt.want+0.01 {
+				t.Errorf("wrong score: got %v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestItemScore (score in GET /items and GET /items/{id})
+func TestItemScore(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"Scored"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+	var created Item
+	json.NewDecoder(rr.Body).Decode(&created)
+
+	for _, path := range []string{"/items/" + created.ID, "/items?names=Scored"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		body := strings.TrimSpace(rr.Body.String())
+		if strings.HasPrefix(body, "[") {
+			body = strings.TrimSuffix(strings.TrimPrefix(body, "["), "]")
+		}
+		var item map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &item); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		score, ok := item["score"].(float64)
+		if !ok || score < 0 || score > 100 {
+			t.Errorf("%s: score missing or out of range: %v", path, item["score"])
+		}
+	}
+
+	// The score is computed, so clients cannot send it
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"Cheat","score":100}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+	if stored, _ := store.GetByID(t.Context(), created.ID); stored.Score != 0 {
+		t.Errorf("score was stored: %v", stored.Score)
+	}
+}
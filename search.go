@@ -0,0 +1,135 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// SearchResult is one match returned by GET /search.
+type SearchResult struct {
+	Type    string  `json:"type"`
+	ID      string  `json:"id"`
+	Score   float64 `json:"score"`
+	Summary string  `json:"summary"`
+}
+
+// Searchable is implemented by every entity type GET /search can look through.
+// Search returns the matches for query, best first; a failure returns no matches.
+type Searchable interface {
+	Search(ctx context.Context, query string) []SearchResult
+}
+
+// searchFunc adapts a plain function to a Searchable.
+type searchFunc func(ctx context.Context, query string) []SearchResult
+
+func (f searchFunc) Search(ctx context.Context, query string) []SearchResult {
+	return f(ctx, query)
+}
+
+// searchables holds the entity types GET /search covers, keyed by the name
+// used in ?types= and in the response. Register them before serving.
+var searchables = map[string]Searchable{
+	"items":     searchFunc(searchItems),
+	"templates": searchFunc(func(ctx context.Context, query string) []SearchResult { return templates.Search(ctx, query) }),
+}
+
+// matchScore counts the case-insensitive occurrences of query in each field.
+// Matches in the first field, the entity's name, count double.
+func matchScore(query string, name string, fields ...string) float64 {
+	query = strings.ToLower(query)
+	score := 2 * float64(strings.Count(strings.ToLower(name), query))
+	for _, field := range fields {
+		score += float64(strings.Count(strings.ToLower(field), query))
+	}
+	return score
+}
+
+// sortResults puts the best matches first, breaking ties by ID.
+func sortResults(results []SearchResult) {
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+// searchItems searches the names, descriptions and tags of the request's items.
+func searchItems(ctx context.Context, query string) []SearchResult {
+	items, err := storeFromContext(ctx).GetAll(ctx)
+	if err != nil {
+		log.Printf("item search failed: %v", err)
+		return nil
+	}
+	var results []SearchResult
+	for _, item := range items {
+		if score := matchScore(query, item.Name, append([]string{item.Description}, item.Tags...)...); score > 0 {
+			results = append(results, SearchResult{Type: "item", ID: item.ID, Score: score, Summary: item.Name})
+		}
+	}
+	sortResults(results)
+	return results
+}
+
+// searchTypeNames lists the searchable types for error messages.
+func searchTypeNames() string {
+	names := make([]string, 0, len(searchables))
+	for name := range searchables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// search (GET /search?q=term&types=items,templates)
+// This searches every requested entity type at once (all of them without
+// ?types=) and returns the matches grouped by type.
+func search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	types := slices.Compact(slices.Sorted(slices.Values(splitList(r.URL.Query().Get("types")))))
+	if len(types) == 0 {
+		for name := range searchables {
+			types = append(types, name)
+		}
+	}
+	for _, name := range types {
+		if _, ok := searchables[name]; !ok {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("cannot search '%s': must be one of %s", name, searchTypeNames()))
+			return
+		}
+	}
+
+	response := map[string]interface{}{}
+	total := 0
+	var mu sync.Mutex
+	g, ctx := errgroup.WithContext(r.Context())
+	for _, name := range types {
+		g.Go(func() error {
+			results := searchables[name].Search(ctx, query)
+			if results == nil {
+				results = []SearchResult{}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			response[name] = results
+			total += len(results)
+			return nil
+		})
+	}
+	g.Wait()
+	response["total_results"] = total
+	respondWithJSON(w, http.StatusOK, response)
+}
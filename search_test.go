@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSearch (GET /search)
+func TestSearch(t *testing.T) {
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "Gadget", Description: "A shiny gadget"},
+		Item{ID: "2", Name: "Widget", Description: "Not a match"},
+		Item{ID: "3", Name: "Other", Tags: []string{"gadget"}},
+	)
+	defer resetGlobalItems()
+	templates = NewTemplateStore()
+	defer func() { templates = NewTemplateStore() }()
+	templates.Put(Template{Name: "widget", Fields: map[string]string{"description": "Widget template"}})
+	router := newRouter()
+
+	get := func(target string) (map[string]json.RawMessage, int) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+		var body map[string]json.RawMessage
+		json.NewDecoder(rr.Body).Decode(&body)
+		return body, rr.Code
+	}
+
+	// 1. A term only present in items
+	body, code := get("/search?q=GADGET&types=items,templates")
+	if code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", code, http.StatusOK)
+	}
+	var items, tmpls []SearchResult
+	var total int
+	json.Unmarshal(body["items"], &items)
+	json.Unmarshal(body["templates"], &tmpls)
+	json.Unmarshal(body["total_results"], &total)
+	if len(items) != 2 || items[0].ID != "1" || items[1].ID != "3" {
+		t.Errorf("wrong item results, want the name match first: %+v", items)
+	}
+	if tmpls == nil || len(tmpls) != 0 {
+		t.Errorf("wrong template results: %s", body["templates"])
+	}
+	if total != 2 {
+		t.Errorf("wrong total_results: got %d want %d", total, 2)
+	}
+
+	// 2. ?types= limits the search
+	body, _ = get("/search?q=widget&types=templates")
+	if _, ok := body["items"]; ok {
+		t.Errorf("items were searched although only templates were asked for: %v", body)
+	}
+	json.Unmarshal(body["templates"], &tmpls)
+	if len(tmpls) != 1 || tmpls[0].ID != "widget" || tmpls[0].Type != "template" {
+		t.Errorf("wrong template results: %+v", tmpls)
+	}
+
+	// 3. Bad requests
+	for _, target := range []string{"/search", "/search?q=x&types=users"} {
+		if _, code := get(target); code != http.StatusBadRequest {
+			t.Errorf("%s: handler returned wrong status code: got %v want %v", target, code, http.StatusBadRequest)
+		}
+	}
+}
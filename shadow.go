@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"time"
+)
+
+// ShadowStore sends every write to both a primary and a shadow Storage, and
+// checks reads against the shadow, so a new backend can be validated on live
+// traffic before switching to it. Callers only ever see the primary's results;
+// shadow failures and differences are logged and counted in shadow_mismatches.
+type ShadowStore struct {
+	primary Storage
+	shadow  Storage
+}
+
+// NewShadowStore returns a Storage serving from primary and mirroring into shadow.
+func NewShadowStore(primary, shadow Storage) *ShadowStore {
+	return &ShadowStore{primary: primary, shadow: shadow}
+}
+
+// Unwrap returns the primary Storage.
+func (s *ShadowStore) Unwrap() Storage {
+	return s.primary
+}
+
+// Ping checks the primary backend, if it can be checked.
+func (s *ShadowStore) Ping(ctx context.Context) error {
+	if p, ok := s.primary.(pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// shadowComparable prepares item for comparison with the other backend. Each
+// backend stamps UpdatedAt with its own clock, and SQL backends read empty
+// tags back as nil, so neither counts as a difference.
+func shadowComparable(item Item) Item {
+	item.UpdatedAt = time.Time{}
+	if len(item.Tags) == 0 {
+		item.Tags = nil
+	}
+	return item
+}
+
+// compare logs and counts a difference between the primary and shadow results.
+func (s *ShadowStore) compare(op string, primary, shadow []Item, primaryErr, shadowErr error) {
+	same := (primaryErr == nil) == (shadowErr == nil) && len(primary) == len(shadow)
+	for i := 0; same && i < len(primary); i++ {
+		same = reflect.DeepEqual(shadowComparable(primary[i]), shadowComparable(shadow[i]))
+	}
+	if same {
+		return
+	}
+	metrics.shadowMismatches.Add(1)
+	log.Printf("WARNING: shadow store mismatch in %s: primary %+v (err %v), shadow %+v (err %v)",
+		op, primary, primaryErr, shadow, shadowErr)
+}
+
+// mirrored returns an update callback that makes the shadow's item match
+// the primary's result. The version is left to the shadow's own stamping.
+func mirrored(results map[string]Item) func(item *Item) error {
+	return func(item *Item) error {
+		version := item.Version
+		*item = results[item.ID]
+		item.Version = version
+		return nil
+	}
+}
+
+// mirrorReplace copies a ReplaceAll made on the primary to the shadow. A
+// shadow that cannot replace its items, or fails to, counts as a mismatch.
+func (s *ShadowStore) mirrorReplace(ctx context.Context, items []Item) {
+	err := errors.New("shadow backend cannot replace its items")
+	if shadow, ok := findWrapper[Replacer](s.shadow); ok {
+		err = shadow.ReplaceAll(ctx, items)
+	}
+	if err != nil {
+		metrics.shadowMismatches.Add(1)
+		log.Printf("WARNING: shadow store mismatch in ReplaceAll: %v", err)
+	}
+}
+
+// GetAll returns the primary's items, checking them against the shadow's.
+func (s *ShadowStore) GetAll(ctx context.Context) ([]Item, error) {
+	items, err := s.primary.GetAll(ctx)
+	shadowItems, shadowErr := s.shadow.GetAll(ctx)
+	s.compare("GetAll", items, shadowItems, err, shadowErr)
+	return items, err
+}
+
+// GetByID returns the primary's item, checking it against the shadow's.
+func (s *ShadowStore) GetByID(ctx context.Context, id string) (Item, error) {
+	item, err := s.primary.GetByID(ctx, id)
+	shadowItem, shadowErr := s.shadow.GetByID(ctx, id)
+	s.compare("GetByID "+id, []Item{item}, []Item{shadowItem}, err, shadowErr)
+	return item, err
+}
+
+// Create stores item in the primary, then the created item in the shadow.
+func (s *ShadowStore) Create(ctx context.Context, item Item) (Item, error) {
+	created, err := s.primary.Create(ctx, item)
+	if err != nil {
+		return created, err
+	}
+	shadowItem, shadowErr := s.shadow.Create(ctx, created)
+	s.compare("Create "+created.ID, []Item{created}, []Item{shadowItem}, nil, shadowErr)
+	return created, nil
+}
+
+// CreateBatch stores items in the primary, then the created items in the shadow.
+func (s *ShadowStore) CreateBatch(ctx context.Context, items []Item) ([]Item, error) {
+	created, err := s.primary.CreateBatch(ctx, items)
+	if err != nil {
+		return created, err
+	}
+	shadowItems, shadowErr := s.shadow.CreateBatch(ctx, created)
+	s.compare("CreateBatch", created, shadowItems, nil, shadowErr)
+	return created, nil
+}
+
+// Update runs fn against the primary only, then copies the result to the
+// shadow, so fn's side effects happen once.
+func (s *ShadowStore) Update(ctx context.Context, id string, fn func(item *Item) error) (Item, error) {
+	updated, err := s.primary.Update(ctx, id, fn)
+	if err != nil {
+		return updated, err
+	}
+	shadowItem, shadowErr := s.shadow.Update(ctx, id, mirrored(map[string]Item{id: updated}))
+	s.compare("Update "+id, []Item{updated}, []Item{shadowItem}, nil, shadowErr)
+	return updated, nil
+}
+
+// UpdateBatch runs fn against the primary only, then copies the results to the shadow.
+func (s *ShadowStore) UpdateBatch(ctx context.Context, ids []string, fn func(item *Item) error) ([]Item, error) {
+	updated, err := s.primary.UpdateBatch(ctx, ids, fn)
+	if err != nil {
+		return updated, err
+	}
+	results := make(map[string]Item, len(updated))
+	for _, item := range updated {
+		results[item.ID] = item
+	}
+	shadowItems, shadowErr := s.shadow.UpdateBatch(ctx, ids, mirrored(results))
+	s.compare("UpdateBatch", updated, shadowItems, nil, shadowErr)
+	return updated, nil
+}
+
+// Delete removes the item from both stores.
+func (s *ShadowStore) Delete(ctx context.Context, id string) error {
+	if err := s.primary.Delete(ctx, id); err != nil {
+		return err
+	}
+	shadowErr := s.shadow.Delete(ctx, id)
+	s.compare("Delete "+id, nil, nil, nil, shadowErr)
+	return nil
+}
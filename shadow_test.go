@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+// mutatingStore is a Storage whose reads always come back changed.
+type mutatingStore struct {
+	Storage
+}
+
+func (m mutatingStore) GetByID(ctx context.Context, id string) (Item, error) {
+	item, err := m.Storage.GetByID(ctx, id)
+	item.Name += " (mutated)"
+	return item, err
+}
+
+// captureLog sends the standard logger to a buffer for the rest of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	saved := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(saved) })
+	return &buf
+}
+
+// TestShadowStore runs the storage test table against a ShadowStore that
+// mirrors a MemoryStore into SQLite; the two must never disagree.
+func TestShadowStore(t *testing.T) {
+	metrics.shadowMismatches.Store(0)
+	runStorageTests(t, func(t *testing.T) Storage {
+		return NewShadowStore(NewMemoryStore(), newTestSQLiteStore(t))
+	})
+	if n := metrics.shadowMismatches.Load(); n != 0 {
+		t.Errorf("MemoryStore and SQLiteStore disagreed %d times", n)
+	}
+}
+
+// TestShadowStoreMismatch checks that a differing shadow is logged and
+// counted while the primary's result is returned.
+func TestShadowStoreMismatch(t *testing.T) {
+	metrics.shadowMismatches.Store(0)
+	logs := captureLog(t)
+	ctx := context.Background()
+
+	s := NewShadowStore(NewMemoryStore(), mutatingStore{NewMemoryStore()})
+	if _, err := s.Create(ctx, Item{ID: "1", Name: "Original"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if n := metrics.shadowMismatches.Load(); n != 0 {
+		t.Fatalf("matching write counted as a mismatch: %d", n)
+	}
+
+	item, err := s.GetByID(ctx, "1")
+	if err != nil || item.Name != "Original" {
+		t.Errorf("GetByID did not return the primary's item: %+v %v", item, err)
+	}
+	if n := metrics.shadowMismatches.Load(); n != 1 {
+		t.Errorf("wrong shadow_mismatches: got %d want %d", n, 1)
+	}
+	if !strings.Contains(logs.String(), "shadow store mismatch in GetByID 1") {
+		t.Errorf("mismatch was not logged: %q", logs.String())
+	}
+}
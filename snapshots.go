@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Replacer is a Storage whose whole contents can be swapped in one step.
+// Items are stored verbatim, bookkeeping fields included. MemoryStore and
+// SQLiteStore implement it, for snapshots and POST /admin/migrate.
+type Replacer interface {
+	Storage
+	ReplaceAll(ctx context.Context, items []Item) error
+}
+
+// Snapshot is a named copy of every item, taken with POST /snapshots.
+type Snapshot struct {
+	Name    string    `json:"snapshot"`
+	TakenAt time.Time `json:"taken_at"`
+	Items   []Item    `json:"items"`
+}
+
+// summary describes a snapshot without its items.
+func (s Snapshot) summary() map[string]interface{} {
+	return map[string]interface{}{"snapshot": s.Name, "item_count": len(s.Items), "taken_at": s.TakenAt}
+}
+
+// SnapshotStore keeps snapshots in memory, separately from the items.
+type SnapshotStore struct {
+	mu        sync.RWMutex
+	snapshots map[string]Snapshot
+}
+
+// NewSnapshotStore returns an empty SnapshotStore.
+func NewSnapshotStore() *SnapshotStore {
+	return &SnapshotStore{snapshots: make(map[string]Snapshot)}
+}
+
+// snapshots holds the snapshots used by the handlers.
+var snapshots = NewSnapshotStore()
+
+// Get returns the named snapshot.
+func (s *SnapshotStore) Get(name string) (Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snap, ok := s.snapshots[name]
+	return snap, ok
+}
+
+// Names returns the name of every snapshot, sorted.
+func (s *SnapshotStore) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.snapshots))
+	for name := range s.snapshots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Put adds a snapshot, replacing any snapshot with the same name.
+func (s *SnapshotStore) Put(snap Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[snap.Name] = snap
+}
+
+// Delete removes the named snapshot and reports whether it existed.
+func (s *SnapshotStore) Delete(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.snapshots[name]
+	delete(s.snapshots, name)
+	return ok
+}
+
+// createSnapshot (POST /snapshots)
+// This saves a copy of every item under {"name": ...}, replacing an
+// existing snapshot with the same name.
+func createSnapshot(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if request.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "snapshot name is required")
+		return
+	}
+
+	items, err := storeFromContext(r.Context()).GetAll(r.Context())
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	for i := range items {
+		ownTags(&items[i])
+	}
+	snap := Snapshot{Name: request.Name, TakenAt: time.Now().UTC(), Items: items}
+	snapshots.Put(snap)
+	respondWithJSON(w, http.StatusCreated, snap.summary())
+}
+
+// listSnapshots (GET /snapshots)
+// This returns the names of all snapshots.
+func listSnapshots(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, snapshots.Names())
+}
+
+// getSnapshot (GET /snapshots/{name})
+// This returns a snapshot with its items, without restoring it.
+func getSnapshot(w http.ResponseWriter, r *http.Request) {
+	snap, ok := snapshots.Get(mux.Vars(r)["name"])
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Snapshot not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, snap)
+}
+
+// restoreSnapshot (POST /snapshots/{name}/restore)
+// This replaces every item with the snapshot's copies. The snapshot is kept,
+// so it can be restored again. While any item is locked the restore is
+// refused with 409, as it would overwrite the locked items.
+func restoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	snap, ok := snapshots.Get(mux.Vars(r)["name"])
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Snapshot not found")
+		return
+	}
+	if locked := itemLocks.Locked(); len(locked) > 0 {
+		respondWithError(w, http.StatusConflict, "cannot restore while items are locked: "+strings.Join(locked, ", "))
+		return
+	}
+	current := storeFromContext(r.Context())
+	s, ok := findWrapper[Replacer](current)
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "this storage backend cannot restore snapshots")
+		return
+	}
+	if err := s.ReplaceAll(r.Context(), snap.Items); err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	// The restore went around the wrappers in front of the backend
+	if shadow, ok := findWrapper[*ShadowStore](current); ok {
+		shadow.mirrorReplace(r.Context(), snap.Items)
+	}
+	if c, ok := cacheOf(current); ok {
+		c.Clear()
+	}
+	if index, ok := findWrapper[*NameIndex](current); ok {
+		if err := index.Rebuild(r.Context()); err != nil {
+			log.Printf("failed to rebuild the name index after a restore: %v", err)
+		}
+	}
+	touchLastModified()
+	respondWithJSON(w, http.StatusOK, snap.summary())
+}
+
+// deleteSnapshot (DELETE /snapshots/{name})
+// This removes a snapshot. The items are not affected.
+func deleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if !snapshots.Delete(name) {
+		respondWithError(w, http.StatusNotFound, "Snapshot not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "snapshot_deleted": name})
+}
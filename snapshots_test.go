@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSnapshots (POST /snapshots, POST /snapshots/{name}/restore)
+func TestSnapshots(t *testing.T) {
+	store = NewMemoryStore()
+	snapshots = NewSnapshotStore()
+	defer func() {
+		resetGlobalItems()
+		snapshots = NewSnapshotStore()
+	}()
+	router := newRouter()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rr
+	}
+	count := func() int {
+		var items []Item
+		json.NewDecoder(do("GET", "/items", "").Body).Decode(&items)
+		return len(items)
+	}
+
+	for _, name := range []string{"one", "two"} {
+		do("POST", "/items", `{"name":"`+name+`","tags":["x"]}`)
+	}
+
+	t.Run("Take Snapshot", func(t *testing.T) {
+		rr := do("POST", "/snapshots", `{"name":"before-tests"}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		var body struct {
+			Snapshot  string `json:"snapshot"`
+			ItemCount int    `json:"item_count"`
+		}
+		json.NewDecoder(rr.Body).Decode(&body)
+		if body.Snapshot != "before-tests" || body.ItemCount != 2 {
+			t.Errorf("wrong snapshot summary: got %+v", body)
+		}
+	})
+
+	t.Run("Restore Snapshot", func(t *testing.T) {
+		do("POST", "/items", `{"name":"three"}`)
+		if got := count(); got != 3 {
+			t.Fatalf("expected 3 items before the restore, got %d", got)
+		}
+		if rr := do("POST", "/snapshots/before-tests/restore", ""); rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if got := count(); got != 2 {
+			t.Errorf("wrong item count after the restore: got %d want 2", got)
+		}
+	})
+
+	t.Run("Locked Items", func(t *testing.T) {
+		itemLocks = NewItemLocks()
+		defer func() { itemLocks = NewItemLocks() }()
+		do("POST", "/items", `{"name":"locked"}`)
+		items, _ := store.GetAll(t.Context())
+		itemLocks.Lock(items[2].ID, "job-7", "importer", time.Minute)
+		if rr := do("POST", "/snapshots/before-tests/restore", ""); rr.Code != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+		}
+		if got := count(); got != 3 {
+			t.Errorf("a refused restore changed the items: got %d want 3", got)
+		}
+		itemLocks.Unlock(items[2].ID, "job-7")
+		if rr := do("POST", "/snapshots/before-tests/restore", ""); rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("Restore Reaches the Shadow", func(t *testing.T) {
+		shadow := NewMemoryStore()
+		primary := store
+		store = NewShadowStore(primary, shadow)
+		defer func() { store = primary }()
+		do("POST", "/items", `{"name":"shadowed"}`)
+		if rr := do("POST", "/snapshots/before-tests/restore", ""); rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if items, _ := shadow.GetAll(t.Context()); len(items) != 2 {
+			t.Errorf("the restore did not reach the shadow: got %d items want 2", len(items))
+		}
+	})
+
+	t.Run("Snapshot Is a Copy", func(t *testing.T) {
+		items, _ := store.GetAll(t.Context())
+		store.Update(t.Context(), items[0].ID, func(item *Item) error {
+			item.Tags[0] = "changed"
+			return nil
+		})
+		snap, _ := snapshots.Get("before-tests")
+		if snap.Items[0].Tags[0] != "x" {
+			t.Errorf("updating a restored item changed the snapshot: %+v", snap.Items[0])
+		}
+	})
+
+	t.Run("List and Delete", func(t *testing.T) {
+		do("POST", "/snapshots", `{"name":"another"}`)
+		var names []string
+		json.NewDecoder(do("GET", "/snapshots", "").Body).Decode(&names)
+		if want := []string{"another", "before-tests"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("wrong snapshot names: got %v want %v", names, want)
+		}
+		if rr := do("DELETE", "/snapshots/another", ""); rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if rr := do("GET", "/snapshots/another", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("deleted snapshot still served: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if rr := do("POST", "/snapshots", `{}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("missing name: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		if rr := do("POST", "/snapshots/missing/restore", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("unknown snapshot: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+		if rr := do("DELETE", "/snapshots/missing", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("unknown snapshot: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
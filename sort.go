@@ -0,0 +1,68 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// itemSorters compare items by each field GET /items can be sorted by.
+var itemSorters = map[string]func(a, b Item) int{
+	"id":          func(a, b Item) int { return cmp.Compare(a.ID, b.ID) },
+	"name":        func(a, b Item) int { return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+	"created_at":  func(a, b Item) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at":  func(a, b Item) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+	"likes":       func(a, b Item) int { return cmp.Compare(a.Likes, b.Likes) },
+	"description": func(a, b Item) int { return cmp.Compare(a.Description, b.Description) },
+	"view_count":  func(a, b Item) int { return cmp.Compare(a.ViewCount, b.ViewCount) },
+	"clone_count": func(a, b Item) int { return cmp.Compare(a.CloneCount, b.CloneCount) },
+}
+
+// sortFieldNames lists the sortable fields for error messages.
+func sortFieldNames() string {
+	names := make([]string, 0, len(itemSorters))
+	for name := range itemSorters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// sortItems sorts items in place by field, in order "asc" (the default) or "desc".
+// An empty field keeps insertion order. Ties keep insertion order too.
+func sortItems(items []Item, field, order string) error {
+	if field == "" {
+		return nil
+	}
+	compare, ok := itemSorters[field]
+	if !ok {
+		return fmt.Errorf("cannot sort by '%s': must be one of %s", field, sortFieldNames())
+	}
+	switch order {
+	case "", "asc":
+	case "desc":
+		asc := compare
+		compare = func(a, b Item) int { return asc(b, a) }
+	default:
+		return fmt.Errorf("invalid order '%s': must be asc or desc", order)
+	}
+	slices.SortStableFunc(items, compare)
+	return nil
+}
+
+// pinnedFirst moves pinned items ahead of the others in place. Both groups
+// keep the order they had, so each stays sorted.
+func pinnedFirst(items []Item) {
+	slices.SortStableFunc(items, func(a, b Item) int {
+		switch {
+		case a.Pinned == b.Pinned:
+			return 0
+		case a.Pinned:
+			return -1
+		default:
+			return 1
+		}
+	})
+}
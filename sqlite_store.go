@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // CGo-free SQLite driver, registered as "sqlite"
+)
+
+// sqliteSchema creates the items table if it does not exist yet.
+// Rows are soft-deleted by setting deleted_at. tags_json holds the item's
+// tags as a JSON array. extra_json holds the whole item as JSON, so fields
+// without a column of their own survive a round trip; the other columns
+// always take precedence over it.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS items (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL,
+	tags_json   TEXT NOT NULL DEFAULT '[]',
+	created_at  TEXT NOT NULL,
+	updated_at  TEXT NOT NULL,
+	deleted_at  TEXT,
+	version     INTEGER NOT NULL DEFAULT 1,
+	extra_json  TEXT NOT NULL DEFAULT '{}'
+)`
+
+// sqliteAddedColumns are columns added after the first schema. Databases
+// created before them get them added on open.
+var sqliteAddedColumns = []struct{ name, definition string }{
+	{"extra_json", "TEXT NOT NULL DEFAULT '{}'"},
+}
+
+// migrateSQLite adds any of sqliteAddedColumns that the items table is missing.
+func migrateSQLite(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('items')")
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range sqliteAddedColumns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE items ADD COLUMN " + column.name + " " + column.definition); err != nil {
+			return fmt.Errorf("adding column %s: %w", column.name, err)
+		}
+	}
+	return nil
+}
+
+// SQLiteStore is a Storage backed by a SQLite database file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the database at path and makes sure the
+// schema exists. Pass ":memory:" for a throwaway in-memory database.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer anyway, and every connection to
+	// ":memory:" would otherwise get its own empty database.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema: %w", err)
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close releases the underlying database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks that the database is usable; it backs GET /health.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// formatDBTime and parseDBTime convert timestamps to and from their TEXT column form.
+func formatDBTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseDBTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// encodeTags and decodeTags convert tags to and from their JSON column form.
+// No tags are stored as "[]" and read back as nil.
+func encodeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(tags)
+	return string(data)
+}
+
+func decodeTags(value string) ([]string, error) {
+	var tags []string
+	if err := json.Unmarshal([]byte(value), &tags); err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return tags, nil
+}
+
+// encodeExtra and decodeExtra convert a whole item to and from its extra_json form.
+func encodeExtra(item Item) string {
+	data, _ := json.Marshal(item) // an Item always marshals
+	return string(data)
+}
+
+func decodeExtra(value string, item *Item) error {
+	return json.Unmarshal([]byte(value), item)
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanItem reads the columns selected by itemColumns into an Item.
+func scanItem(row rowScanner) (Item, error) {
+	var item Item
+	var id, name, description, tags, createdAt, updatedAt, extra string
+	var version int
+	if err := row.Scan(&id, &name, &description, &tags, &createdAt, &updatedAt, &version, &extra); err != nil {
+		return Item{}, err
+	}
+	if err := decodeExtra(extra, &item); err != nil {
+		return Item{}, err
+	}
+	item.ID, item.Name, item.Description, item.Version = id, name, description, version
+	var err error
+	if item.Tags, err = decodeTags(tags); err != nil {
+		return Item{}, err
+	}
+	if item.CreatedAt, err = parseDBTime(createdAt); err != nil {
+		return Item{}, err
+	}
+	if item.UpdatedAt, err = parseDBTime(updatedAt); err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+// itemColumns is the column list read by scanItem.
+const itemColumns = "id, name, description, tags_json, created_at, updated_at, version, extra_json"
+
+// GetAll returns every non-deleted item in insertion order.
+func (s *SQLiteStore) GetAll(ctx context.Context) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+itemColumns+" FROM items WHERE deleted_at IS NULL ORDER BY rowid")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetByID returns the non-deleted item with the given ID.
+func (s *SQLiteStore) GetByID(ctx context.Context, id string) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	return getSQLiteItem(ctx, s.db, id)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// getSQLiteItem loads a single non-deleted item, inside or outside a transaction.
+func getSQLiteItem(ctx context.Context, q queryRower, id string) (Item, error) {
+	item, err := scanItem(q.QueryRowContext(ctx,
+		"SELECT "+itemColumns+" FROM items WHERE id = ? AND deleted_at IS NULL", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Item{}, errItemNotFound
+	}
+	return item, err
+}
+
+// insertSQLiteItem inserts a new item as part of tx.
+func insertSQLiteItem(ctx context.Context, tx *sql.Tx, item Item) (Item, error) {
+	// Soft-deleted rows still own their ID, so check every row
+	var exists bool
+	if err := tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM items WHERE id = ?)", item.ID).Scan(&exists); err != nil {
+		return Item{}, err
+	}
+	if exists {
+		return Item{}, errDuplicateID
+	}
+
+	stampCreated(&item)
+	if err := writeSQLiteRow(ctx, tx, item); err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+// writeSQLiteRow inserts item as it is, bookkeeping fields included, as part of tx.
+func writeSQLiteRow(ctx context.Context, tx *sql.Tx, item Item) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO items (id, name, description, tags_json, created_at, updated_at, version, extra_json) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		item.ID, item.Name, item.Description, encodeTags(item.Tags),
+		formatDBTime(item.CreatedAt), formatDBTime(item.UpdatedAt), item.Version, encodeExtra(item))
+	return err
+}
+
+// updateSQLiteItem applies fn to a stored item and writes the result back as part of tx.
+func updateSQLiteItem(ctx context.Context, tx *sql.Tx, id string, fn func(*Item) error) (Item, error) {
+	item, err := getSQLiteItem(ctx, tx, id)
+	if err != nil {
+		return Item{}, err
+	}
+	if err := fn(&item); err != nil {
+		return Item{}, err
+	}
+	stampUpdated(ctx, &item)
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE items SET name = ?, description = ?, tags_json = ?, updated_at = ?, version = ?, extra_json = ? WHERE id = ?",
+		item.Name, item.Description, encodeTags(item.Tags), formatDBTime(item.UpdatedAt), item.Version, encodeExtra(item), id); err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+// Create inserts a new item inside a transaction.
+func (s *SQLiteStore) Create(ctx context.Context, item Item) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Item{}, err
+	}
+	defer tx.Rollback()
+
+	if item, err = insertSQLiteItem(ctx, tx, item); err != nil {
+		return Item{}, err
+	}
+	return item, tx.Commit()
+}
+
+// CreateBatch inserts every item inside a single transaction.
+func (s *SQLiteStore) CreateBatch(ctx context.Context, items []Item) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created := make([]Item, len(items))
+	for i, item := range items {
+		if created[i], err = insertSQLiteItem(ctx, tx, item); err != nil {
+			return nil, err
+		}
+	}
+	return created, tx.Commit()
+}
+
+// Update applies fn to the stored item and writes the result back inside a transaction.
+func (s *SQLiteStore) Update(ctx context.Context, id string, fn func(*Item) error) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Item{}, err
+	}
+	defer tx.Rollback()
+
+	item, err := updateSQLiteItem(ctx, tx, id, fn)
+	if err != nil {
+		return Item{}, err
+	}
+	return item, tx.Commit()
+}
+
+// UpdateBatch applies fn to every listed item inside a single transaction.
+func (s *SQLiteStore) UpdateBatch(ctx context.Context, ids []string, fn func(*Item) error) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updated := make([]Item, len(ids))
+	for i, id := range ids {
+		if updated[i], err = updateSQLiteItem(ctx, tx, id, fn); err != nil {
+			return nil, err
+		}
+	}
+	return updated, tx.Commit()
+}
+
+// Delete soft-deletes the item with the given ID inside a transaction.
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE items SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL",
+		formatDBTime(time.Now()), id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errItemNotFound
+	}
+	return tx.Commit()
+}
+
+// PurgeDeleted removes the rows of soft-deleted items for good and returns
+// how many it removed. Their IDs can be used again afterwards.
+func (s *SQLiteStore) PurgeDeleted(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	result, err := s.db.ExecContext(ctx, "DELETE FROM items WHERE deleted_at IS NOT NULL")
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// ReplaceAll swaps every row, soft-deleted ones included, for items inside
+// a single transaction. Items are stored as they are, so their Version and
+// UpdatedAt survive.
+func (s *SQLiteStore) ReplaceAll(ctx context.Context, items []Item) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM items"); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := writeSQLiteRow(ctx, tx, item); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// newTestSQLiteStore opens an in-memory SQLite database that is closed when the test ends.
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	s, err := NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open SQLite store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// TestSQLiteStore runs the storage test table against SQLiteStore.
+func TestSQLiteStore(t *testing.T) {
+	runStorageTests(t, func(t *testing.T) Storage {
+		return newTestSQLiteStore(t)
+	})
+}
+
+// TestSQLiteStoreSoftDelete checks that deleted rows are kept but hidden.
+func TestSQLiteStoreSoftDelete(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	s.Create(ctx, Item{ID: "1", Name: "Mock Item 1"})
+	if err := s.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	var deletedAt *string
+	if err := s.db.QueryRow("SELECT deleted_at FROM items WHERE id = ?", "1").Scan(&deletedAt); err != nil {
+		t.Fatalf("deleted row is gone: %v", err)
+	}
+	if deletedAt == nil {
+		t.Error("deleted row has no deleted_at")
+	}
+}
+
+// TestSQLiteStoreVersion checks that every update bumps the row version.
+func TestSQLiteStoreVersion(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	ctx := context.Background()
+
+	s.Create(ctx, Item{ID: "1", Name: "Mock Item 1"})
+	for i := 0; i < 2; i++ {
+		s.Update(ctx, "1", func(item *Item) error { return nil })
+	}
+
+	var version int
+	s.db.QueryRow("SELECT version FROM items WHERE id = ?", "1").Scan(&version)
+	if version != 3 {
+		t.Errorf("row has wrong version: got %d want %d", version, 3)
+	}
+}
+
+// TestSQLiteStoreMigration checks that a database created before extra_json existed can still be opened.
+func TestSQLiteStoreMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to create old database: %v", err)
+	}
+	if _, err := db.Exec(`CREATE TABLE items (
+		id TEXT PRIMARY KEY, name TEXT NOT NULL, description TEXT NOT NULL,
+		tags_json TEXT NOT NULL DEFAULT '[]', created_at TEXT NOT NULL, updated_at TEXT NOT NULL,
+		deleted_at TEXT, version INTEGER NOT NULL DEFAULT 1)`); err != nil {
+		t.Fatalf("failed to create old schema: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO items (id, name, description, created_at, updated_at)
+		VALUES ('1', 'Old Item', 'from before', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z')`); err != nil {
+		t.Fatalf("failed to insert old row: %v", err)
+	}
+	db.Close()
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("failed to open old database: %v", err)
+	}
+	defer s.Close()
+	item, err := s.GetByID(context.Background(), "1")
+	if err != nil || item.Name != "Old Item" {
+		t.Errorf("old row was not readable: %+v, %v", item, err)
+	}
+}
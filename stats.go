@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// itemStats is the response body of GET /items/stats.
+// Items have no archived state and deletes remove them, so every stored item
+// counts as active; archived and deleted are kept for dashboards that expect them.
+type itemStats struct {
+	Total           int        `json:"total"`
+	Active          int        `json:"active"`
+	Archived        int        `json:"archived"`
+	Deleted         int        `json:"deleted"`
+	OldestCreatedAt *time.Time `json:"oldest_created_at,omitempty"`
+	NewestCreatedAt *time.Time `json:"newest_created_at,omitempty"`
+	AvgNameLength   float64    `json:"avg_name_length"`
+	UniqueTags      []string   `json:"unique_tags"`
+}
+
+// computeStats aggregates items in a single pass.
+// Name lengths count characters, not bytes, and unique_tags is sorted.
+func computeStats(items []Item) itemStats {
+	stats := itemStats{UniqueTags: []string{}}
+	var oldest, newest time.Time
+	nameLength := 0
+	seenTags := map[string]bool{}
+	for _, item := range items {
+		stats.Total++
+		stats.Active++
+		nameLength += utf8.RuneCountInString(item.Name)
+		if oldest.IsZero() || item.CreatedAt.Before(oldest) {
+			oldest = item.CreatedAt
+		}
+		if item.CreatedAt.After(newest) {
+			newest = item.CreatedAt
+		}
+		for _, tag := range item.Tags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				stats.UniqueTags = append(stats.UniqueTags, tag)
+			}
+		}
+	}
+	if stats.Total > 0 {
+		stats.OldestCreatedAt, stats.NewestCreatedAt = &oldest, &newest
+		stats.AvgNameLength = float64(nameLength) / float64(stats.Total)
+	}
+	sort.Strings(stats.UniqueTags)
+	return stats
+}
+
+// statsCache remembers the last stats computed, so that many dashboards
+// polling GET /items/stats do not each walk the whole store.
+type statsCache struct {
+	mu         sync.Mutex
+	result     itemStats
+	computedAt time.Time
+	now        func() time.Time // tests replace this to move time forward
+}
+
+// newStatsCache returns an empty statsCache.
+func newStatsCache() *statsCache {
+	return &statsCache{now: time.Now}
+}
+
+// itemStatsCache holds the stats served by statsItems.
+var itemStatsCache = newStatsCache()
+
+// get returns the cached stats if they are younger than ttl, and otherwise
+// computes them from s. Concurrent misses wait for a single computation.
+func (c *statsCache) get(ctx context.Context, s Storage, ttl time.Duration) (itemStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.computedAt.IsZero() && c.now().Sub(c.computedAt) < ttl {
+		return c.result, nil
+	}
+	items, err := s.GetAll(ctx)
+	if err != nil {
+		return itemStats{}, err
+	}
+	c.result, c.computedAt = computeStats(items), c.now()
+	return c.result, nil
+}
+
+// statsItems (GET /items/stats)
+// This returns aggregate statistics about the stored items. The store takes
+// its read lock once for the snapshot, then the snapshot is walked once.
+// Results are reused for STATS_CACHE_TTL_SECONDS, so they may lag behind writes.
+func statsItems(w http.ResponseWriter, r *http.Request) {
+	stats, err := itemStatsCache.get(r.Context(), storeFromContext(r.Context()), config.StatsCacheTTL)
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, stats)
+}
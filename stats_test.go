@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestStatsItems (GET /items/stats)
+func TestStatsItems(t *testing.T) {
+	oldest := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newest := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	store = NewMemoryStore(
+		Item{ID: "1", Name: "ab", Tags: []string{"red", "blue"}, CreatedAt: newest},
+		Item{ID: "2", Name: "abcd", Tags: []string{"blue"}, CreatedAt: oldest},
+		Item{ID: "3", Name: "héllo", CreatedAt: oldest.Add(time.Hour)},
+	)
+	defer resetGlobalItems()
+	itemStatsCache = newStatsCache()
+
+	rr := httptest.NewRecorder()
+	newRouter().ServeHTTP(rr, httptest.NewRequest("GET", "/items/stats", nil))
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+
+	var stats itemStats
+	if err := json.NewDecoder(rr.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	if stats.Total != 3 || stats.Active != 3 || stats.Archived != 0 || stats.Deleted != 0 {
+		t.Errorf("wrong counts: %+v", stats)
+	}
+	if stats.OldestCreatedAt == nil || !stats.OldestCreatedAt.Equal(oldest) {
+		t.Errorf("wrong oldest_created_at: got %v want %v", stats.OldestCreatedAt, oldest)
+	}
+	if stats.NewestCreatedAt == nil || !stats.NewestCreatedAt.Equal(newest) {
+		t.Errorf("wrong newest_created_at: got %v want %v", stats.NewestCreatedAt, newest)
+	}
+	if want := 11.0 / 3; stats.AvgNameLength != want {
+		t.Errorf("wrong avg_name_length: got %v want %v", stats.AvgNameLength, want)
+	}
+	if want := []string{"blue", "red"}; !reflect.DeepEqual(stats.UniqueTags, want) {
+		t.Errorf("wrong unique_tags: got %v want %v", stats.UniqueTags, want)
+	}
+}
+
+// TestComputeStatsEmpty checks the stats of an empty store.
+func TestComputeStatsEmpty(t *testing.T) {
+	stats := computeStats(nil)
+	if stats.Total != 0 || stats.AvgNameLength != 0 || stats.OldestCreatedAt != nil || stats.NewestCreatedAt != nil {
+		t.Errorf("wrong stats for no items: %+v", stats)
+	}
+	if stats.UniqueTags == nil {
+		t.Error("unique_tags should be an empty list, not null")
+	}
+}
+
+// countingStore counts GetAll calls on the wrapped Storage.
+type countingStore struct {
+	Storage
+	getAlls int
+}
+
+func (c *countingStore) GetAll(ctx context.Context) ([]Item, error) {
+	c.getAlls++
+	return c.Storage.GetAll(ctx)
+}
+
+// TestStatsCache checks that GET /items/stats reuses its result within the TTL.
+func TestStatsCache(t *testing.T) {
+	counting := &countingStore{Storage: NewMemoryStore(Item{ID: "1", Name: "Cached"})}
+	store = counting
+	defer resetGlobalItems()
+	config.StatsCacheTTL = 30 * time.Second
+	defer func() { config.StatsCacheTTL = 0 }()
+	itemStatsCache = newStatsCache()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	itemStatsCache.now = func() time.Time { return now }
+	router := newRouter()
+
+	get := func() {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/stats", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	}
+
+	get()
+	now = now.Add(10 * time.Second)
+	get()
+	if counting.getAlls != 1 {
+		t.Errorf("store was read %d times within the TTL, want %d", counting.getAlls, 1)
+	}
+
+	now = now.Add(30 * time.Second)
+	get()
+	if counting.getAlls != 2 {
+		t.Errorf("store was read %d times after the TTL, want %d", counting.getAlls, 2)
+	}
+}
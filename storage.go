@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// errItemNotFound is returned by Storage methods when no item has the given ID.
+var errItemNotFound = errors.New("item not found")
+
+// errDuplicateID is returned by Create when an item with the same ID already exists.
+var errDuplicateID = errors.New("duplicate item ID")
+
+// Storage is the persistence layer behind the handlers.
+// Every method takes the request context so a cancelled request can stop
+// work early; implementations return ctx.Err() once the context is done.
+type Storage interface {
+	// GetAll returns every stored item in insertion order.
+	GetAll(ctx context.Context) ([]Item, error)
+	// GetByID returns the item with the given ID, or errItemNotFound.
+	GetByID(ctx context.Context, id string) (Item, error)
+	// Create stores a new item. The caller is responsible for setting its ID;
+	// errDuplicateID is returned if it is already taken. The returned item
+	// has Version 1 and UpdatedAt equal to CreatedAt.
+	Create(ctx context.Context, item Item) (Item, error)
+	// Update applies fn to the item with the given ID and stores the result.
+	// If fn returns an error the item is left unchanged and the error is returned.
+	// Otherwise Version is bumped and UpdatedAt set to the current time, unless
+	// ctx comes from withCounterUpdate. No other writer can change the item
+	// while fn runs.
+	Update(ctx context.Context, id string, fn func(*Item) error) (Item, error)
+	// Delete removes the item with the given ID, or returns errItemNotFound.
+	Delete(ctx context.Context, id string) error
+	// CreateBatch stores several new items at once, like Create. Either all of
+	// them are stored or, on any error, none are.
+	CreateBatch(ctx context.Context, items []Item) ([]Item, error)
+	// UpdateBatch applies fn to each of the items with the given IDs, like
+	// Update. Either every item is updated or, if fn fails or an ID does not
+	// exist, none are.
+	UpdateBatch(ctx context.Context, ids []string, fn func(*Item) error) ([]Item, error)
+}
+
+// stampCreated sets the bookkeeping fields of a newly created item.
+func stampCreated(item *Item) {
+	item.Version = 1
+	item.UpdatedAt = item.CreatedAt
+}
+
+// counterUpdateKey is the context key marking updates that only change counters.
+type counterUpdateKey struct{}
+
+// withCounterUpdate returns a copy of ctx whose updates only change engagement
+// counters such as likes, pins and clone counts. Those are not new revisions
+// of the item, so they keep its Version and UpdatedAt and leave no history.
+func withCounterUpdate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, counterUpdateKey{}, true)
+}
+
+// isCounterUpdate reports whether ctx was marked by withCounterUpdate.
+func isCounterUpdate(ctx context.Context) bool {
+	counter, _ := ctx.Value(counterUpdateKey{}).(bool)
+	return counter
+}
+
+// stampUpdated records that an item has just been changed, unless ctx marks
+// a counter update.
+func stampUpdated(ctx context.Context, item *Item) {
+	if isCounterUpdate(ctx) {
+		return
+	}
+	item.Version++
+	item.UpdatedAt = time.Now().UTC()
+}
+
+// ownTags gives item its own copy of the tags slice and metadata map, so
+// items handed out by MemoryStore never share them with the stored ones.
+func ownTags(item *Item) {
+	item.Tags = slices.Clone(item.Tags)
+	item.Metadata = maps.Clone(item.Metadata)
+}
+
+// MemoryStore is the in-memory "database".
+// It keeps items in a slice so that GET /items preserves insertion order.
+type MemoryStore struct {
+	mu    sync.RWMutex // Guards items to make our slice-based DB thread-safe
+	items []Item
+}
+
+// NewMemoryStore returns a MemoryStore pre-populated with the given items.
+func NewMemoryStore(items ...Item) *MemoryStore {
+	m := &MemoryStore{items: append([]Item(nil), items...)}
+	for i := range m.items {
+		ownTags(&m.items[i])
+		stampCreated(&m.items[i])
+	}
+	return m
+}
+
+// store is the Storage used by the handlers.
+var store Storage = NewMemoryStore()
+
+// storeKey is the context key under which a request-specific Storage is stored.
+type storeKey struct{}
+
+// withStore returns a copy of ctx whose handlers use s instead of the global store.
+func withStore(ctx context.Context, s Storage) context.Context {
+	return context.WithValue(ctx, storeKey{}, s)
+}
+
+// storeFromContext returns the Storage stored in ctx, falling back to the global store.
+func storeFromContext(ctx context.Context) Storage {
+	if s, ok := ctx.Value(storeKey{}).(Storage); ok {
+		return s
+	}
+	return store
+}
+
+// GetAll returns a copy of every stored item.
+func (m *MemoryStore) GetAll(ctx context.Context) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Item, len(m.items))
+	copy(result, m.items)
+	for i := range result {
+		ownTags(&result[i])
+	}
+	return result, nil
+}
+
+// GetByID returns the item with the given ID.
+func (m *MemoryStore) GetByID(ctx context.Context, id string) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, item := range m.items {
+		if item.ID == id {
+			ownTags(&item)
+			return item, nil
+		}
+	}
+	return Item{}, errItemNotFound
+}
+
+// Create appends item to the store.
+func (m *MemoryStore) Create(ctx context.Context, item Item) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.items {
+		if existing.ID == item.ID {
+			return Item{}, errDuplicateID
+		}
+	}
+	ownTags(&item)
+	stampCreated(&item)
+	m.items = append(m.items, item)
+	return item, nil
+}
+
+// Update modifies the item with the given ID in place.
+// fn works on a copy, so a failed update never leaves a half-modified item behind.
+func (m *MemoryStore) Update(ctx context.Context, id string, fn func(*Item) error) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for index, item := range m.items {
+		if item.ID == id {
+			ownTags(&item)
+			if err := fn(&item); err != nil {
+				return Item{}, err
+			}
+			stampUpdated(ctx, &item)
+			m.items[index] = item
+			return item, nil
+		}
+	}
+	return Item{}, errItemNotFound
+}
+
+// CreateBatch appends every item, or none if any ID is already taken.
+func (m *MemoryStore) CreateBatch(ctx context.Context, items []Item) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	taken := make(map[string]bool, len(m.items)+len(items))
+	for _, existing := range m.items {
+		taken[existing.ID] = true
+	}
+	created := make([]Item, len(items))
+	for i, item := range items {
+		if taken[item.ID] {
+			return nil, errDuplicateID
+		}
+		taken[item.ID] = true
+		ownTags(&item)
+		stampCreated(&item)
+		created[i] = item
+	}
+	m.items = append(m.items, created...)
+	return created, nil
+}
+
+// UpdateBatch works on copies and only writes them back once fn has succeeded for every item.
+func (m *MemoryStore) UpdateBatch(ctx context.Context, ids []string, fn func(*Item) error) ([]Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index := make(map[string]int, len(m.items))
+	for i, item := range m.items {
+		index[item.ID] = i
+	}
+	updated := make([]Item, len(ids))
+	for i, id := range ids {
+		pos, ok := index[id]
+		if !ok {
+			return nil, errItemNotFound
+		}
+		item := m.items[pos]
+		ownTags(&item)
+		if err := fn(&item); err != nil {
+			return nil, err
+		}
+		stampUpdated(ctx, &item)
+		updated[i] = item
+	}
+	for i, id := range ids {
+		m.items[index[id]] = updated[i]
+	}
+	return updated, nil
+}
+
+// Delete removes the item with the given ID.
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for index, item := range m.items {
+		if item.ID == id {
+			// Remove the item from the slice
+			// This syntax means "append everything before this index...
+			// with everything after this index."
+			m.items = append(m.items[:index], m.items[index+1:]...)
+			return nil
+		}
+	}
+	return errItemNotFound
+}
+
+// Reorder puts the items in the order of ids, which must list every stored
+// item exactly once. Otherwise nothing changes and a *reorderError says why.
+func (m *MemoryStore) Reorder(ctx context.Context, ids []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := checkReorder(m.items, ids); err != nil {
+		return err
+	}
+	byID := make(map[string]Item, len(m.items))
+	for _, item := range m.items {
+		byID[item.ID] = item
+	}
+	for i, id := range ids {
+		m.items[i] = byID[id]
+	}
+	return nil
+}
+
+// ReplaceAll swaps every stored item for items, keeping their order.
+func (m *MemoryStore) ReplaceAll(ctx context.Context, items []Item) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	replacement := make([]Item, len(items))
+	for i, item := range items {
+		ownTags(&item)
+		replacement[i] = item
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = replacement
+	return nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// storageFactory builds an empty Storage for a single test case.
+type storageFactory func(t *testing.T) Storage
+
+// runStorageTests runs the same table of behaviour checks against any Storage implementation.
+func runStorageTests(t *testing.T, newStore storageFactory) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	seed := func(t *testing.T) Storage {
+		s := newStore(t)
+		for _, item := range []Item{
+			{ID: "1", Name: "Mock Item 1", Description: "First mock item", CreatedAt: created},
+			{ID: "2", Name: "Mock Item 2", Description: "Second mock item", CreatedAt: created},
+		} {
+			if _, err := s.Create(context.Background(), item); err != nil {
+				t.Fatalf("failed to seed store: %v", err)
+			}
+		}
+		return s
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s Storage)
+	}{
+		{"GetAll keeps insertion order", func(t *testing.T, s Storage) {
+			items, err := s.GetAll(context.Background())
+			if err != nil {
+				t.Fatalf("GetAll failed: %v", err)
+			}
+			if len(items) != 2 || items[0].ID != "1" || items[1].ID != "2" {
+				t.Errorf("GetAll returned wrong items: %+v", items)
+			}
+		}},
+		{"GetByID found", func(t *testing.T, s Storage) {
+			item, err := s.GetByID(context.Background(), "1")
+			if err != nil {
+				t.Fatalf("GetByID failed: %v", err)
+			}
+			if item.Name != "Mock Item 1" || !item.CreatedAt.Equal(created) {
+				t.Errorf("GetByID returned wrong item: %+v", item)
+			}
+		}},
+		{"GetByID not found", func(t *testing.T, s Storage) {
+			if _, err := s.GetByID(context.Background(), "999"); !errors.Is(err, errItemNotFound) {
+				t.Errorf("GetByID returned wrong error: got %v want %v", err, errItemNotFound)
+			}
+		}},
+		{"Tags round trip", func(t *testing.T, s Storage) {
+			if _, err := s.Create(context.Background(), Item{ID: "3", Name: "Tagged", Tags: []string{"a", "b"}}); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			item, _ := s.GetByID(context.Background(), "3")
+			if len(item.Tags) != 2 || item.Tags[0] != "a" || item.Tags[1] != "b" {
+				t.Errorf("tags were not stored: got %v", item.Tags)
+			}
+			if item, _ = s.GetByID(context.Background(), "1"); item.Tags != nil {
+				t.Errorf("untagged item has tags: got %#v", item.Tags)
+			}
+		}},
+		{"Returned tags are copies", func(t *testing.T, s Storage) {
+			s.Create(context.Background(), Item{ID: "3", Name: "Tagged", Tags: []string{"a", "b"}})
+			item, _ := s.GetByID(context.Background(), "3")
+			item.Tags[0] = "changed"
+			items, _ := s.GetAll(context.Background())
+			items[2].Tags[1] = "changed"
+			if item, _ = s.GetByID(context.Background(), "3"); item.Tags[0] != "a" || item.Tags[1] != "b" {
+				t.Errorf("changing returned tags changed the stored ones: got %v", item.Tags)
+			}
+		}},
+		{"Returned metadata is a copy", func(t *testing.T, s Storage) {
+			s.Create(context.Background(), Item{ID: "3", Name: "Described", Metadata: map[string]string{"color": "red"}})
+			item, _ := s.GetByID(context.Background(), "3")
+			item.Metadata["color"] = "blue"
+			if item, _ = s.GetByID(context.Background(), "3"); item.Metadata["color"] != "red" {
+				t.Errorf("changing returned metadata changed the stored map: got %v", item.Metadata)
+			}
+		}},
+		{"Likes round trip", func(t *testing.T, s Storage) {
+			s.Update(context.Background(), "1", func(item *Item) error {
+				item.Likes = 3
+				return nil
+			})
+			if item, _ := s.GetByID(context.Background(), "1"); item.Likes != 3 {
+				t.Errorf("likes were not stored: got %d want %d", item.Likes, 3)
+			}
+		}},
+		{"Create rejects duplicate ID", func(t *testing.T, s Storage) {
+			if _, err := s.Create(context.Background(), Item{ID: "1", Name: "Copy"}); !errors.Is(err, errDuplicateID) {
+				t.Errorf("Create returned wrong error: got %v want %v", err, errDuplicateID)
+			}
+		}},
+		{"Update changes stored item", func(t *testing.T, s Storage) {
+			updated, err := s.Update(context.Background(), "1", func(item *Item) error {
+				item.Name = "Updated Name"
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			if updated.Name != "Updated Name" {
+				t.Errorf("Update returned wrong item: %+v", updated)
+			}
+			item, _ := s.GetByID(context.Background(), "1")
+			if item.Name != "Updated Name" {
+				t.Errorf("Update was not stored: got name %q", item.Name)
+			}
+		}},
+		{"Update bumps version", func(t *testing.T, s Storage) {
+			item, _ := s.GetByID(context.Background(), "1")
+			if item.Version != 1 || !item.UpdatedAt.Equal(created) {
+				t.Fatalf("new item has wrong bookkeeping: version %d, updated_at %v", item.Version, item.UpdatedAt)
+			}
+			updated, err := s.Update(context.Background(), "1", func(*Item) error { return nil })
+			if err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			if updated.Version != 2 || !updated.UpdatedAt.After(created) {
+				t.Errorf("Update did not bump version: version %d, updated_at %v", updated.Version, updated.UpdatedAt)
+			}
+			if item, _ = s.GetByID(context.Background(), "1"); item.Version != 2 {
+				t.Errorf("bumped version was not stored: got %d want %d", item.Version, 2)
+			}
+		}},
+		{"Counter update keeps version", func(t *testing.T, s Storage) {
+			updated, err := s.Update(withCounterUpdate(context.Background()), "1", func(item *Item) error {
+				item.Likes++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			item, _ := s.GetByID(context.Background(), "1")
+			if updated.Version != 1 || item.Version != 1 || !item.UpdatedAt.Equal(created) || item.Likes != 1 {
+				t.Errorf("counter update changed the bookkeeping: version %d, updated_at %v, likes %d", item.Version, item.UpdatedAt, item.Likes)
+			}
+		}},
+		{"Update error leaves item unchanged", func(t *testing.T, s Storage) {
+			errBoom := errors.New("boom")
+			_, err := s.Update(context.Background(), "1", func(item *Item) error {
+				item.Name = "Changed"
+				return errBoom
+			})
+			if !errors.Is(err, errBoom) {
+				t.Fatalf("Update returned wrong error: got %v want %v", err, errBoom)
+			}
+			item, _ := s.GetByID(context.Background(), "1")
+			if item.Name != "Mock Item 1" {
+				t.Errorf("item was modified by failed update: got name %q", item.Name)
+			}
+		}},
+		{"Update not found", func(t *testing.T, s Storage) {
+			_, err := s.Update(context.Background(), "999", func(*Item) error { return nil })
+			if !errors.Is(err, errItemNotFound) {
+				t.Errorf("Update returned wrong error: got %v want %v", err, errItemNotFound)
+			}
+		}},
+		{"CreateBatch is all or nothing", func(t *testing.T, s Storage) {
+			created, err := s.CreateBatch(context.Background(), []Item{{ID: "3", Name: "Three"}, {ID: "4", Name: "Four"}})
+			if err != nil {
+				t.Fatalf("CreateBatch failed: %v", err)
+			}
+			if len(created) != 2 || created[1].ID != "4" || created[1].Version != 1 {
+				t.Errorf("CreateBatch returned wrong items: %+v", created)
+			}
+			_, err = s.CreateBatch(context.Background(), []Item{{ID: "5", Name: "Five"}, {ID: "1", Name: "Copy"}})
+			if !errors.Is(err, errDuplicateID) {
+				t.Fatalf("CreateBatch returned wrong error: got %v want %v", err, errDuplicateID)
+			}
+			if _, err := s.GetByID(context.Background(), "5"); !errors.Is(err, errItemNotFound) {
+				t.Errorf("failed CreateBatch stored part of the batch: %v", err)
+			}
+		}},
+		{"UpdateBatch is all or nothing", func(t *testing.T, s Storage) {
+			rename := func(item *Item) error {
+				item.Name = "Batch " + item.ID
+				return nil
+			}
+			if _, err := s.UpdateBatch(context.Background(), []string{"1", "999"}, rename); !errors.Is(err, errItemNotFound) {
+				t.Fatalf("UpdateBatch returned wrong error: got %v want %v", err, errItemNotFound)
+			}
+			if item, _ := s.GetByID(context.Background(), "1"); item.Name != "Mock Item 1" {
+				t.Errorf("failed UpdateBatch changed an item: got name %q", item.Name)
+			}
+
+			updated, err := s.UpdateBatch(context.Background(), []string{"1", "2"}, rename)
+			if err != nil {
+				t.Fatalf("UpdateBatch failed: %v", err)
+			}
+			if len(updated) != 2 || updated[1].Name != "Batch 2" || updated[1].Version != 2 {
+				t.Errorf("UpdateBatch returned wrong items: %+v", updated)
+			}
+			if item, _ := s.GetByID(context.Background(), "2"); item.Name != "Batch 2" {
+				t.Errorf("UpdateBatch was not stored: got name %q", item.Name)
+			}
+		}},
+		{"Delete removes item", func(t *testing.T, s Storage) {
+			if err := s.Delete(context.Background(), "1"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, err := s.GetByID(context.Background(), "1"); !errors.Is(err, errItemNotFound) {
+				t.Errorf("deleted item is still returned: %v", err)
+			}
+			if items, _ := s.GetAll(context.Background()); len(items) != 1 {
+				t.Errorf("GetAll returned wrong number of items after delete: got %d want %d", len(items), 1)
+			}
+			if err := s.Delete(context.Background(), "1"); !errors.Is(err, errItemNotFound) {
+				t.Errorf("second Delete returned wrong error: got %v want %v", err, errItemNotFound)
+			}
+		}},
+		{"Cancelled context", func(t *testing.T, s Storage) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			noop := func(*Item) error { return nil }
+			calls := map[string]func() error{
+				"GetAll":  func() error { _, err := s.GetAll(ctx); return err },
+				"GetByID": func() error { _, err := s.GetByID(ctx, "1"); return err },
+				"Create":  func() error { _, err := s.Create(ctx, Item{ID: "3"}); return err },
+				"Update":  func() error { _, err := s.Update(ctx, "1", noop); return err },
+				"Delete":  func() error { return s.Delete(ctx, "1") },
+			}
+			for name, call := range calls {
+				if err := call(); !errors.Is(err, context.Canceled) {
+					t.Errorf("%s returned wrong error: got %v want %v", name, err, context.Canceled)
+				}
+			}
+
+			// Nothing should have been written
+			items, _ := s.GetAll(context.Background())
+			if len(items) != 2 || items[0].Name != "Mock Item 1" {
+				t.Errorf("store was modified by cancelled calls: got %+v", items)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, seed(t))
+		})
+	}
+}
+
+// TestMemoryStore runs the storage test table against MemoryStore.
+func TestMemoryStore(t *testing.T) {
+	runStorageTests(t, func(t *testing.T) Storage {
+		return NewMemoryStore()
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// maxTags is the most tags an item may have.
+	maxTags = 20
+	// maxTagLength is the longest tag accepted, in characters, once trimmed.
+	maxTagLength = 50
+)
+
+// validateTags checks an item's tags before they are stored.
+func validateTags(tags []string) error {
+	if len(tags) > maxTags {
+		return fmt.Errorf("an item has at most %d tags, got %d", maxTags, len(tags))
+	}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return errors.New("tags must not be empty")
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return fmt.Errorf("tag '%s' is longer than %d characters", tag, maxTagLength)
+		}
+	}
+	return nil
+}
+
+// dedupeTags trims tags and drops those that repeat an earlier one,
+// ignoring case. The first spelling of each tag is kept.
+func dedupeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	unique := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if !slices.ContainsFunc(unique, func(u string) bool { return strings.EqualFold(u, tag) }) {
+			unique = append(unique, tag)
+		}
+	}
+	return unique
+}
+
+// normalizeTags checks the tags for POST /items/{id}/tags/replace, which
+// also rejects whitespace inside a tag, and returns them deduplicated.
+func normalizeTags(tags []string) ([]string, error) {
+	if err := validateTags(tags); err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if strings.ContainsFunc(tag, unicode.IsSpace) {
+			return nil, fmt.Errorf("tag '%s' contains spaces", tag)
+		}
+	}
+	return dedupeTags(tags), nil
+}
+
+// replaceItemTags (POST /items/{id}/tags/replace)
+// This swaps an item's whole tag set for {"tags": ["a", "b"]} in a single
+// update, so concurrent tag changes cannot interleave. Duplicates, in any
+// case, are dropped; an empty list clears the tags.
+func replaceItemTags(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var request struct {
+		Tags *[]string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if request.Tags == nil {
+		respondWithError(w, http.StatusBadRequest, "tags is required")
+		return
+	}
+	tags, err := normalizeTags(*request.Tags)
+	if err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	item, err := storeFromContext(r.Context()).Update(r.Context(), id, func(item *Item) error {
+		item.Tags = tags
+		return nil
+	})
+	if err != nil {
+		respondWithStorageError(w, err)
+		return
+	}
+	recordItemChange(r.Context(), eventItemUpdated, item)
+
+	respondWithJSON(w, http.StatusOK, item)
+}
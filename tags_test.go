@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestReplaceItemTags (POST /items/{id}/tags/replace)
+func TestReplaceItemTags(t *testing.T) {
+	resetGlobalItems()
+	store.Update(t.Context(), "1", func(item *Item) error {
+		item.Tags = []string{"x", "y"}
+		return nil
+	})
+	router := newRouter()
+
+	replace := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/1/tags/replace", strings.NewReader(body)))
+		return rr
+	}
+
+	// Sub-test for "Replace"
+	t.Run("Replace", func(t *testing.T) {
+		rr := replace(`{"tags":["a","b","a"]}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body)
+		}
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		if !slices.Equal(item.Tags, []string{"a", "b"}) {
+			t.Errorf("wrong tags in response: got %v want [a b]", item.Tags)
+		}
+		if stored, _ := store.GetByID(t.Context(), "1"); !slices.Equal(stored.Tags, []string{"a", "b"}) {
+			t.Errorf("wrong stored tags: got %v want [a b]", stored.Tags)
+		}
+	})
+
+	// Sub-test for "Clear"
+	t.Run("Clear", func(t *testing.T) {
+		rr := replace(`{"tags":[]}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var item map[string]interface{}
+		json.NewDecoder(rr.Body).Decode(&item)
+		if tags, ok := item["tags"]; ok && len(tags.([]interface{})) != 0 {
+			t.Errorf("tags were not cleared: %v", tags)
+		}
+		if stored, _ := store.GetByID(t.Context(), "1"); len(stored.Tags) != 0 {
+			t.Errorf("stored tags were not cleared: %v", stored.Tags)
+		}
+	})
+
+	// Sub-test for "Invalid Tags"
+	t.Run("Invalid Tags", func(t *testing.T) {
+		for _, body := range []string{`{"tags":["has space"]}`, `{"tags":["` + strings.Repeat("t", 51) + `"]}`, `{"tags":[""]}`} {
+			if rr := replace(body); rr.Code != http.StatusUnprocessableEntity {
+				t.Errorf("%s: handler returned wrong status code: got %v want %v", body, rr.Code, http.StatusUnprocessableEntity)
+			}
+		}
+		if rr := replace(`{}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	// Sub-test for "Unknown Item"
+	t.Run("Unknown Item", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/999/tags/replace", strings.NewReader(`{"tags":["a"]}`)))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// templateFields are the item fields a template may pre-populate.
+var templateFields = map[string]bool{"name": true, "description": true}
+
+// Template holds default field values for new items, applied with POST /items?template=<name>.
+type Template struct {
+	Name   string            `json:"name"`
+	Fields map[string]string `json:"fields"`
+}
+
+// apply copies the template's fields onto item.
+func (t Template) apply(item *Item) {
+	for field, value := range t.Fields {
+		switch field {
+		case "name":
+			item.Name = value
+		case "description":
+			item.Description = value
+		}
+	}
+}
+
+// TemplateStore keeps templates in memory, separately from the items.
+type TemplateStore struct {
+	mu        sync.RWMutex
+	templates map[string]Template
+}
+
+// NewTemplateStore returns an empty TemplateStore.
+func NewTemplateStore() *TemplateStore {
+	return &TemplateStore{templates: make(map[string]Template)}
+}
+
+// templates holds the templates used by the handlers.
+var templates = NewTemplateStore()
+
+// Get returns the template with the given name.
+func (s *TemplateStore) Get(name string) (Template, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.templates[name]
+	return t, ok
+}
+
+// List returns every template, sorted by name.
+func (s *TemplateStore) List() []Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Template, 0, len(s.templates))
+	for _, t := range s.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Put adds a template, replacing any template with the same name.
+func (s *TemplateStore) Put(t Template) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.templates[t.Name] = t
+}
+
+// Delete removes the named template and reports whether it existed.
+func (s *TemplateStore) Delete(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.templates[name]
+	delete(s.templates, name)
+	return ok
+}
+
+// Search finds the templates whose name or field values contain query.
+func (s *TemplateStore) Search(ctx context.Context, query string) []SearchResult {
+	var results []SearchResult
+	for _, t := range s.List() {
+		values := make([]string, 0, len(t.Fields))
+		for _, value := range t.Fields {
+			values = append(values, value)
+		}
+		if score := matchScore(query, t.Name, values...); score > 0 {
+			results = append(results, SearchResult{Type: "template", ID: t.Name, Score: score, Summary: t.Name})
+		}
+	}
+	sortResults(results)
+	return results
+}
+
+// createTemplate (POST /templates)
+// This registers a template, replacing an existing one with the same name.
+func createTemplate(w http.ResponseWriter, r *http.Request) {
+	var t Template
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+	if t.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "template name is required")
+		return
+	}
+	for field := range t.Fields {
+		if !templateFields[field] {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("field '%s' cannot be templated", field))
+			return
+		}
+	}
+	templates.Put(t)
+	respondWithJSON(w, http.StatusCreated, t)
+}
+
+// listTemplates (GET /templates)
+// This returns every registered template.
+func listTemplates(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, templates.List())
+}
+
+// deleteTemplate (DELETE /templates/{name})
+// This removes a template. Items created from it are not affected.
+func deleteTemplate(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if !templates.Delete(name) {
+		respondWithError(w, http.StatusNotFound, "Template not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success", "template_deleted": name})
+}
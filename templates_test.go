@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestItemTemplates covers the template CRUD and creating items from a template.
+func TestItemTemplates(t *testing.T) {
+	resetGlobalItems()
+	templates = NewTemplateStore()
+	defer func() { templates = NewTemplateStore() }()
+	router := newRouter()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	// Sub-test for "Create Template"
+	t.Run("Create Template", func(t *testing.T) {
+		rr := do("POST", "/templates", `{"name":"default","fields":{"description":"Standard item: "}}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+
+		rr = do("GET", "/templates", "")
+		var list []Template
+		json.NewDecoder(rr.Body).Decode(&list)
+		if len(list) != 1 || list[0].Name != "default" {
+			t.Errorf("template list: got %+v", list)
+		}
+	})
+
+	// Sub-test for "Create Item From Template"
+	t.Run("Create Item From Template", func(t *testing.T) {
+		rr := do("POST", "/items?template=default", `{"name":"Templated"}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+		}
+		var item Item
+		json.NewDecoder(rr.Body).Decode(&item)
+		if !strings.HasPrefix(item.Description, "Standard item: ") || item.Name != "Templated" {
+			t.Errorf("template was not applied: got %+v", item)
+		}
+
+		// Fields in the body take precedence over the template
+		rr = do("POST", "/items?template=default", `{"name":"Custom","description":"my own"}`)
+		json.NewDecoder(rr.Body).Decode(&item)
+		if item.Description != "my own" {
+			t.Errorf("body did not override the template: got %q", item.Description)
+		}
+	})
+
+	// Sub-test for "Invalid Templates"
+	t.Run("Invalid Templates", func(t *testing.T) {
+		if rr := do("POST", "/items?template=missing", `{"name":"x"}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("unknown template: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		if rr := do("POST", "/templates", `{"name":"bad","fields":{"id":"42"}}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("template with a read-only field: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	// Sub-test for "Delete Template"
+	t.Run("Delete Template", func(t *testing.T) {
+		if rr := do("DELETE", "/templates/default", ""); rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if rr := do("DELETE", "/templates/default", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// routeTimeoutBody is the response to a request that ran out of time.
+const routeTimeoutBody = `{"error":"Request timed out"}`
+
+// withTimeout gives a route d to answer. The handler's context is cancelled
+// after d, and if it has not answered by then the client gets 503 while the
+// handler's late output is discarded. Streaming and WebSocket routes cannot
+// be buffered like this, so they should not be given a timeout.
+func withTimeout(d time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		timeout := http.TimeoutHandler(next, d, routeTimeoutBody)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The timeout response goes straight to w; a handler's own headers replace this
+			w.Header().Set("Content-Type", "application/json")
+			timeout.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parseRouteTimeouts reads ROUTE_TIMEOUTS, a JSON object mapping
+// "METHOD /path/template" to a Go duration, e.g. {"GET /items/{id}": "50ms"}.
+func parseRouteTimeouts(value string) (map[string]time.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, err
+	}
+	timeouts := make(map[string]time.Duration, len(raw))
+	for route, duration := range raw {
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%s: invalid duration %q", route, duration)
+		}
+		timeouts[route] = d
+	}
+	return timeouts, nil
+}
+
+// routeTimeoutKey is how ROUTE_TIMEOUTS names a route.
+func routeTimeoutKey(method, path string) string {
+	return method + " " + path
+}
+
+// applyRouteTimeouts wraps every route named in timeouts with withTimeout.
+// Names that match no route are logged, since they are most likely typos.
+func applyRouteTimeouts(r *mux.Router, timeouts map[string]time.Duration) {
+	if len(timeouts) == 0 {
+		return
+	}
+	applied := make(map[string]bool, len(timeouts))
+	r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			key := routeTimeoutKey(method, path)
+			if d, ok := timeouts[key]; ok {
+				route.Handler(withTimeout(d)(route.GetHandler()))
+				applied[key] = true
+				break
+			}
+		}
+		return nil
+	})
+	for key := range timeouts {
+		if !applied[key] {
+			log.Printf("ignoring ROUTE_TIMEOUTS entry %q: no such route", key)
+		}
+	}
+}
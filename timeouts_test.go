@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestRouteTimeouts checks that ROUTE_TIMEOUTS entries cut slow routes off with 503.
+func TestRouteTimeouts(t *testing.T) {
+	timeouts, err := parseRouteTimeouts(`{"GET /slow/{id}": "100ms"}`)
+	if err != nil {
+		t.Fatalf("failed to parse timeouts: %v", err)
+	}
+
+	sleep := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		respondWithJSON(w, http.StatusOK, map[string]string{"result": "done"})
+	}
+	r := mux.NewRouter()
+	r.HandleFunc("/slow/{id}", sleep).Methods("GET")
+	r.HandleFunc("/untimed", sleep).Methods("GET")
+	applyRouteTimeouts(r, timeouts)
+
+	// Sub-test for "Timed Out"
+	t.Run("Timed Out", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/slow/1", nil))
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusServiceUnavailable)
+		}
+		if body := rr.Body.String(); body != routeTimeoutBody {
+			t.Errorf("handler returned wrong body: got %q want %q", body, routeTimeoutBody)
+		}
+	})
+
+	// Sub-test for "Other Routes Unaffected"
+	t.Run("Other Routes Unaffected", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/untimed", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	// Sub-test for "Invalid Config"
+	t.Run("Invalid Config", func(t *testing.T) {
+		for _, value := range []string{`not json`, `{"GET /items": "soon"}`, `{"GET /items": "-1s"}`} {
+			if _, err := parseRouteTimeouts(value); err == nil {
+				t.Errorf("ROUTE_TIMEOUTS=%s was accepted", value)
+			}
+		}
+	})
+}
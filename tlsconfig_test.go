@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// TestBuildTLSConfig
+func TestBuildTLSConfig(t *testing.T) {
+	c := buildTLSConfig()
+	if c.MinVersion != tls.VersionTLS12 {
+		t.Errorf("wrong MinVersion: got %x want %x", c.MinVersion, tls.VersionTLS12)
+	}
+	if !slices.Contains(c.CipherSuites, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384) {
+		t.Error("ECDHE-RSA-AES256-GCM-SHA384 is not offered")
+	}
+	for _, id := range c.CipherSuites {
+		for _, insecure := range tls.InsecureCipherSuites() {
+			if id == insecure.ID {
+				t.Errorf("insecure cipher suite offered: %s", insecure.Name)
+			}
+		}
+	}
+
+	// Sub-test for "Handshake"
+	t.Run("Handshake", func(t *testing.T) {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		srv.TLS = buildTLSConfig()
+		srv.StartTLS()
+		defer srv.Close()
+
+		for _, version := range []uint16{tls.VersionTLS10, tls.VersionTLS11} {
+			transport := srv.Client().Transport.(*http.Transport).Clone()
+			transport.TLSClientConfig.MaxVersion = version
+			client := &http.Client{Transport: transport}
+			if resp, err := client.Get(srv.URL); err == nil {
+				resp.Body.Close()
+				t.Errorf("TLS version %x was accepted", version)
+			}
+		}
+		resp, err := srv.Client().Get(srv.URL)
+		if err != nil {
+			t.Fatalf("TLS 1.2+ handshake failed: %v", err)
+		}
+		resp.Body.Close()
+	})
+}
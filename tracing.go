@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies this server's spans.
+const tracerName = "myapi"
+
+// tracerProvider creates the request and storage spans. It defaults to the
+// global OpenTelemetry provider, which drops spans until one is installed.
+// Tests may replace it, but should restore it afterwards.
+var tracerProvider trace.TracerProvider = otel.GetTracerProvider()
+
+// tracingMiddleware starts a server span for every request, named after the
+// matched route, e.g. "GET /items/{id}". An incoming W3C traceparent header
+// makes it part of the caller's trace.
+func tracingMiddleware() mux.MiddlewareFunc {
+	return otelhttp.NewMiddleware("http.server",
+		otelhttp.WithTracerProvider(tracerProvider),
+		otelhttp.WithPropagators(propagation.TraceContext{}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return routeName(r)
+		}),
+	)
+}
+
+// routeName names the route that matched r after its method and path
+// template, e.g. "GET /items/{id}", or just the method if none matched.
+func routeName(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if path, err := route.GetPathTemplate(); err == nil {
+			return r.Method + " " + path
+		}
+	}
+	return r.Method
+}
+
+// TracedStore wraps a Storage and records a span for every call, as a child
+// of the span in the caller's context, so slow queries show up in traces.
+type TracedStore struct {
+	Storage
+}
+
+// NewTracedStore returns s with a span around every storage call.
+func NewTracedStore(s Storage) *TracedStore {
+	return &TracedStore{Storage: s}
+}
+
+// Unwrap returns the wrapped Storage.
+func (t *TracedStore) Unwrap() Storage {
+	return t.Storage
+}
+
+// Ping checks the wrapped backend, if it can be checked.
+func (t *TracedStore) Ping(ctx context.Context) error {
+	if p, ok := t.Storage.(pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// start begins the span for a storage operation.
+func (t *TracedStore) start(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("storage.operation", op))
+	return tracerProvider.Tracer(tracerName).Start(ctx, "storage."+op,
+		trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+}
+
+// endSpan finishes a span, recording err on it as an exception event.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// GetAll traces the wrapped GetAll.
+func (t *TracedStore) GetAll(ctx context.Context) ([]Item, error) {
+	ctx, span := t.start(ctx, "GetAll")
+	items, err := t.Storage.GetAll(ctx)
+	span.SetAttributes(attribute.Int("storage.items", len(items)))
+	endSpan(span, err)
+	return items, err
+}
+
+// GetByID traces the wrapped GetByID.
+func (t *TracedStore) GetByID(ctx context.Context, id string) (Item, error) {
+	ctx, span := t.start(ctx, "GetByID", attribute.String("item.id", id))
+	item, err := t.Storage.GetByID(ctx, id)
+	endSpan(span, err)
+	return item, err
+}
+
+// Create traces the wrapped Create.
+func (t *TracedStore) Create(ctx context.Context, item Item) (Item, error) {
+	ctx, span := t.start(ctx, "Create", attribute.String("item.id", item.ID))
+	created, err := t.Storage.Create(ctx, item)
+	endSpan(span, err)
+	return created, err
+}
+
+// CreateBatch traces the wrapped CreateBatch.
+func (t *TracedStore) CreateBatch(ctx context.Context, items []Item) ([]Item, error) {
+	ctx, span := t.start(ctx, "CreateBatch", attribute.Int("storage.items", len(items)))
+	created, err := t.Storage.CreateBatch(ctx, items)
+	endSpan(span, err)
+	return created, err
+}
+
+// Update traces the wrapped Update, including the time spent in fn.
+func (t *TracedStore) Update(ctx context.Context, id string, fn func(item *Item) error) (Item, error) {
+	ctx, span := t.start(ctx, "Update", attribute.String("item.id", id))
+	item, err := t.Storage.Update(ctx, id, fn)
+	endSpan(span, err)
+	return item, err
+}
+
+// UpdateBatch traces the wrapped UpdateBatch, including the time spent in fn.
+func (t *TracedStore) UpdateBatch(ctx context.Context, ids []string, fn func(item *Item) error) ([]Item, error) {
+	ctx, span := t.start(ctx, "UpdateBatch", attribute.Int("storage.items", len(ids)))
+	items, err := t.Storage.UpdateBatch(ctx, ids, fn)
+	endSpan(span, err)
+	return items, err
+}
+
+// Delete traces the wrapped Delete.
+func (t *TracedStore) Delete(ctx context.Context, id string) error {
+	ctx, span := t.start(ctx, "Delete", attribute.String("item.id", id))
+	err := t.Storage.Delete(ctx, id)
+	endSpan(span, err)
+	return err
+}
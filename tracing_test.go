@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestTracedStore runs the storage test table against a TracedStore.
+func TestTracedStore(t *testing.T) {
+	runStorageTests(t, func(t *testing.T) Storage {
+		return NewTracedStore(NewMemoryStore())
+	})
+}
+
+// TestTracing checks that storage spans are nested under the request's server span.
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	saved := tracerProvider
+	tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	store = NewHistoryStore(NewTracedStore(NewMemoryStore(Item{ID: "1", Name: "Traced"})))
+	defer func() {
+		tracerProvider = saved
+		resetGlobalItems()
+	}()
+	router := newRouter()
+
+	get := func(path string) map[string]sdktrace.ReadOnlySpan {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		spans := make(map[string]sdktrace.ReadOnlySpan)
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = span
+		}
+		return spans
+	}
+
+	// Sub-test for "Nested Storage Span"
+	t.Run("Nested Storage Span", func(t *testing.T) {
+		spans := get("/items/1")
+		server, ok := spans["GET /items/{id}"]
+		if !ok {
+			t.Fatalf("no server span recorded: %v", spans)
+		}
+		storage, ok := spans["storage.GetByID"]
+		if !ok {
+			t.Fatalf("no storage span recorded: %v", spans)
+		}
+		if storage.Parent().SpanID() != server.SpanContext().SpanID() || storage.SpanContext().TraceID() != server.SpanContext().TraceID() {
+			t.Error("storage span is not a child of the server span")
+		}
+		var operation string
+		for _, attr := range storage.Attributes() {
+			if attr.Key == "storage.operation" {
+				operation = attr.Value.AsString()
+			}
+		}
+		if operation != "GetByID" {
+			t.Errorf("wrong storage.operation attribute: %q", operation)
+		}
+	})
+
+	// Sub-test for "Error Recorded"
+	t.Run("Error Recorded", func(t *testing.T) {
+		get("/items/missing")
+		spans := recorder.Ended()
+		storage := spans[len(spans)-2] // the server span ends last
+		if storage.Name() != "storage.GetByID" || storage.Status().Code != codes.Error {
+			t.Fatalf("storage error not recorded: %s %+v", storage.Name(), storage.Status())
+		}
+		if events := storage.Events(); len(events) != 1 || events[0].Name != "exception" {
+			t.Errorf("storage error not recorded as an event: %+v", events)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import "net/http"
+
+// validateNewItem (POST /items/validate)
+// This runs every check POST /items would run on the same request,
+// including the schema and UNIQUE_NAMES, without storing anything.
+// It always answers 200, with {"valid": true} or {"valid": false,
+// "errors": [...]}, so forms can validate before they submit.
+func validateNewItem(w http.ResponseWriter, r *http.Request) {
+	problems := []string{}
+	item, body, err := decodeNewItem(r)
+	if err != nil {
+		problems = append(problems, err.Error())
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"valid": false, "errors": problems})
+		return
+	}
+	for _, detail := range checkItemSchema(body) {
+		if detail.Field != "" {
+			problems = append(problems, detail.Field+": "+detail.Message)
+		} else {
+			problems = append(problems, detail.Message)
+		}
+	}
+	if err := validateItem(item); err != nil {
+		problems = append(problems, err.Error())
+	}
+	sanitizeItem(&item)
+	if config.UniqueNames && item.Name != "" {
+		_, found, err := findByName(r.Context(), storeFromContext(r.Context()), item.Namespace, item.Name)
+		if err != nil {
+			respondWithStorageError(w, err)
+			return
+		}
+		if found {
+			problems = append(problems, errNameTaken.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"valid": false, "errors": problems})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{"valid": true})
+}
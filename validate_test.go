@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestValidateItem (POST /items/validate)
+func TestValidateItem(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	validate := func(body string) (bool, []string) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items/validate", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var result struct {
+			Valid  bool     `json:"valid"`
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(rr.Body).Decode(&result)
+		return result.Valid, result.Errors
+	}
+
+	// Sub-test for "Valid Payload"
+	t.Run("Valid Payload", func(t *testing.T) {
+		if valid, problems := validate(`{"name":"Fresh"}`); !valid || len(problems) != 0 {
+			t.Errorf("expected a valid payload: got valid=%v errors=%v", valid, problems)
+		}
+		items, _ := store.GetAll(t.Context())
+		if len(items) != 2 {
+			t.Errorf("validation stored an item: got %d items want 2", len(items))
+		}
+	})
+
+	// Sub-test for "Empty Name"
+	t.Run("Empty Name", func(t *testing.T) {
+		valid, problems := validate(`{"name":""}`)
+		if valid || len(problems) == 0 {
+			t.Errorf("expected an invalid payload: got valid=%v errors=%v", valid, problems)
+		}
+	})
+
+	// Sub-test for "Duplicate Name"
+	t.Run("Duplicate Name", func(t *testing.T) {
+		config.UniqueNames = true
+		defer func() { config.UniqueNames = false }()
+		valid, problems := validate(`{"name":"mock item 1"}`)
+		if valid || len(problems) != 1 || problems[0] != "name already exists" {
+			t.Errorf("expected a duplicate name error: got valid=%v errors=%v", valid, problems)
+		}
+
+		// POST /items enforces the same rule
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"mock item 1"}`)))
+		if rr.Code != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestViewCount (GET /items/{id} counting views)
+func TestViewCount(t *testing.T) {
+	resetGlobalItems()
+	router := newRouter()
+
+	// Sub-test for "Concurrent Views"
+	t.Run("Concurrent Views", func(t *testing.T) {
+		const n = 100
+		var wg sync.WaitGroup
+		counts := make(chan int64, n)
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, httptest.NewRequest("GET", "/items/1", nil))
+				var item Item
+				json.NewDecoder(rr.Body).Decode(&item)
+				counts <- item.ViewCount
+			}()
+		}
+		wg.Wait()
+		close(counts)
+
+		seen := map[int64]bool{}
+		for count := range counts {
+			seen[count] = true
+		}
+		if len(seen) != n || !seen[n] {
+			t.Errorf("views were lost or counted twice: got %d distinct counts", len(seen))
+		}
+		if got := views.Count("1"); got != n {
+			t.Errorf("wrong view count: got %d want %d", got, n)
+		}
+	})
+
+	// Sub-test for "Sort By View Count"
+	t.Run("Sort By View Count", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/items?sort=view_count&order=desc", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var items []Item
+		json.NewDecoder(rr.Body).Decode(&items)
+		if len(items) != 2 || items[0].ID != "1" || items[0].ViewCount != 100 || items[1].ViewCount != 0 {
+			t.Errorf("wrong sort by view_count: %+v", items)
+		}
+	})
+
+}
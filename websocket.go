@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// hubBufferSize is how many events can wait for Run before Publish drops them.
+	hubBufferSize = 256
+	// clientBufferSize is how many messages a slow client may fall behind before it is dropped.
+	clientBufferSize = 16
+	// wsWriteTimeout bounds how long a single write to a client may take.
+	wsWriteTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{}
+
+// wsClient is a single connected WebSocket client.
+type wsClient struct {
+	send chan []byte
+	// public clients get items without their internal fields, see filterAdminFields
+	public bool
+}
+
+// Hub fans item events out to every connected WebSocket client.
+type Hub struct {
+	broadcast chan ItemEvent
+
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+// newHub returns a Hub with no clients. Run must be started for events to be delivered.
+func newHub() *Hub {
+	return &Hub{
+		broadcast: make(chan ItemEvent, hubBufferSize),
+		clients:   make(map[*wsClient]struct{}),
+	}
+}
+
+// hub is the Hub that handlers publish to and /ws/items serves.
+var hub = newHub()
+
+// Run delivers broadcast events to the connected clients until the broadcast channel is closed.
+func (h *Hub) Run() {
+	for event := range h.broadcast {
+		message, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("failed to marshal %s event: %v", event.Type, err)
+			continue
+		}
+		publicMessage, err := json.Marshal(filterAdminFields(event, false))
+		if err != nil {
+			log.Printf("failed to marshal %s event: %v", event.Type, err)
+			continue
+		}
+
+		h.mu.Lock()
+		for client := range h.clients {
+			m := message
+			if client.public {
+				m = publicMessage
+			}
+			select {
+			case client.send <- m:
+			default:
+				// The client is not keeping up, drop it rather than block everyone else
+				delete(h.clients, client)
+				close(client.send)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Publish queues an event for broadcast without blocking the caller.
+// Events are discarded when nobody is connected or the hub is backed up.
+func (h *Hub) Publish(event ItemEvent) {
+	h.mu.Lock()
+	listening := len(h.clients) > 0
+	h.mu.Unlock()
+	if !listening {
+		return
+	}
+
+	select {
+	case h.broadcast <- event:
+	default:
+		log.Printf("dropping %s event for item %s: hub is backed up", event.Type, event.Item.ID)
+	}
+}
+
+// add registers a client to receive events.
+func (h *Hub) add(client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client] = struct{}{}
+}
+
+// remove unregisters a client and closes its send channel.
+func (h *Hub) remove(client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		close(client.send)
+	}
+}
+
+// serveItemsWS (GET /ws/items)
+// This upgrades the connection to a WebSocket and streams item events to it.
+func serveItemsWS(w http.ResponseWriter, r *http.Request) {
+	// Register before upgrading so that no event published after the
+	// client sees the handshake complete can be missed.
+	client := &wsClient{
+		send:   make(chan []byte, clientBufferSize),
+		public: config.HideInternalFields && !hasAdminToken(r),
+	}
+	hub.add(client)
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an HTTP error
+		hub.remove(client)
+		return
+	}
+	defer conn.Close()
+
+	go writeEvents(conn, client.send)
+
+	// Clients only listen, but reading is how we notice they went away
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			hub.remove(client)
+			return
+		}
+	}
+}
+
+// writeEvents forwards queued messages to the connection until send is closed.
+func writeEvents(conn *websocket.Conn, send <-chan []byte) {
+	for message := range send {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			conn.Close()
+			return
+		}
+	}
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	conn.WriteMessage(websocket.CloseMessage, []byte{})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestItemsWebSocket (GET /ws/items)
+func TestItemsWebSocket(t *testing.T) {
+	resetGlobalItems()
+	hub = newHub()
+	go hub.Run()
+	// Later tests get a hub without this test's client
+	defer func() { hub = newHub() }()
+
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/items"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	// Create an item through the normal HTTP API
+	payload := []byte(`{"name":"Live Item", "description":"Should be broadcast"}`)
+	resp, err := http.Post(server.URL+"/items", "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		t.Fatalf("create request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	// The event should arrive within a second
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var event ItemEvent
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("did not receive an event: %v", err)
+	}
+	if event.Type != eventItemCreated {
+		t.Errorf("received wrong event type: got %q want %q", event.Type, eventItemCreated)
+	}
+	if event.Item.Name != "Live Item" || event.Item.ID == "" {
+		t.Errorf("received wrong item: got %+v", event.Item)
+	}
+}